@@ -17,21 +17,325 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"net/url"
+	"strings"
+
 	"go.wasmcloud.dev/operator/api/condition"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// NatsStorageSpec configures the persistent volumes backing JetStream.
+type NatsStorageSpec struct {
+	// StorageClassName of the volumes. Uses the cluster default when empty.
+	// Only applies to volumes created after a change.
+	// +kubebuilder:validation:Optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+	// Size of each server volume. Can be increased when the storage class allows volume expansion.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default="10Gi"
+	Size resource.Quantity `json:"size,omitempty"`
+	// Retention of the volumes when the Cluster is deleted or NATS is scaled down.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Retain;Delete
+	// +kubebuilder:default=Retain
+	Retention appsv1.PersistentVolumeClaimRetentionPolicyType `json:"retention,omitempty"`
+}
+
+// CertificateIssuerRef points at the cert-manager issuer signing the NATS certificates.
+type CertificateIssuerRef struct {
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+	// Kind of the issuer, usually Issuer or ClusterIssuer. Issuers must live in the Cluster namespace.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Issuer
+	Kind string `json:"kind,omitempty"`
+	// Group of the issuer, for external issuers.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default="cert-manager.io"
+	Group string `json:"group,omitempty"`
+}
+
+// NatsTLSSpec tunes the certificates the operator issues for the managed NATS servers.
+// Certificates are reissued once two thirds of their lifetime passed.
+type NatsTLSSpec struct {
+	// IssuerRef hands certificate issuance to cert-manager instead of the operator CA.
+	// The issuer must publish its CA in the "ca.crt" key of the issued Secret.
+	// +kubebuilder:validation:Optional
+	IssuerRef *CertificateIssuerRef `json:"issuerRef,omitempty"`
+	// KeyAlgorithm of the CA and server keys.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=ECDSA;Ed25519;RSA
+	// +kubebuilder:default=ECDSA
+	KeyAlgorithm string `json:"keyAlgorithm,omitempty"`
+	// CADuration is the lifetime of the Cluster CA. Unused with an IssuerRef.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default="87600h"
+	CADuration *metav1.Duration `json:"caDuration,omitempty"`
+	// Duration is the lifetime of the server certificates.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default="2160h"
+	Duration *metav1.Duration `json:"duration,omitempty"`
+}
+
 type NatsManagedSpec struct {
 	ReplicaSpec   `json:",inline"`
 	ContainerSpec `json:",inline"`
 	// +kubebuilder:validation:Required
-	// +kubebuilder:validation:Minimum=3
+	// +kubebuilder:validation:Minimum=1
 	Replicas int32 `json:"replicas,omitempty"`
+	// Storage for JetStream. Servers use ephemeral storage when unset.
+	// +kubebuilder:validation:Optional
+	Storage *NatsStorageSpec `json:"storage,omitempty"`
+	// TLS certificate settings. Servers always use TLS.
+	// +kubebuilder:validation:Optional
+	TLS *NatsTLSSpec `json:"tls,omitempty"`
+	// CredentialsTTL is the lifetime of the user JWTs issued to wadm and hosts.
+	// Credentials are renewed once two thirds of it have elapsed.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default="24h"
+	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('10m')",message="credentialsTTL must be at least 10m"
+	CredentialsTTL *metav1.Duration `json:"credentialsTTL,omitempty"`
+	// AuthCallout lets workloads connect with a Kubernetes ServiceAccount token instead of creds.
+	// A ServiceAccount joins the lattice named by its "k8s.wasmcloud.dev/lattice" annotation, or its
+	// namespace, once a HostGroup of this Cluster in its namespace serves that lattice.
+	// +kubebuilder:validation:Optional
+	AuthCallout *NatsAuthCalloutSpec `json:"authCallout,omitempty"`
+	// Keys ties the Cluster to an existing NATS trust chain.
+	// +kubebuilder:validation:Optional
+	Keys *NatsKeysSpec `json:"keys,omitempty"`
+	// Limits of the account wadm and hosts connect to. Unset limits are unlimited.
+	// +kubebuilder:validation:Optional
+	Limits *NatsAccountLimits `json:"limits,omitempty"`
+	// LeafNodeRemotes connect the servers to upstream NATS hubs as leafnodes, at most one per account.
+	// Changes roll the servers, as NATS can't reload remotes.
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=account
+	LeafNodeRemotes []NatsLeafNodeRemote `json:"leafNodeRemotes,omitempty"`
+	// Expose publishes the client and leafnode ports outside Kubernetes, for hosts running at the edge.
+	// +kubebuilder:validation:Optional
+	Expose *NatsExposeSpec `json:"expose,omitempty"`
+	// Gateways join the servers to other NATS clusters in a supercluster, so lattices span them.
+	// Members must share the operator, system account and Cluster account, see keys.
+	// The gateway is named after the Cluster, so Cluster names must be unique across the supercluster.
+	// Changes roll the servers, as NATS can't reload gateways.
+	// +kubebuilder:validation:Optional
+	Gateways *NatsGatewaysSpec `json:"gateways,omitempty"`
+	// Config is merged on top of the server config the operator renders, for settings it doesn't manage
+	// such as "max_payload", "write_deadline" or "ping_interval". Objects are merged key by key,
+	// null removes a setting and other values replace the rendered ones. Listeners, TLS, authentication
+	// and the trust chain are managed by the operator and can't be overridden.
+	// Changes roll the servers, as NATS can't reload every setting.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Config *runtime.RawExtension `json:"config,omitempty"`
+}
+
+// NatsGatewaysSpec enables the gateway listener and lists the gateways the servers connect to.
+type NatsGatewaysSpec struct {
+	// Remotes are the other members of the supercluster. Gateways gossip their members, so one
+	// remote is enough to join, listing more keeps the Cluster joining while some are down.
+	// A Cluster without remotes only accepts gateway connections.
+	// +kubebuilder:validation:Optional
+	Remotes []NatsGatewayRemote `json:"remotes,omitempty"`
+}
+
+// NatsGatewayRemote is another NATS cluster of the supercluster: a Cluster managed by the operator
+// in this Kubernetes cluster, or the gateway listeners of one reached through exposed endpoints.
+// +kubebuilder:validation:XValidation:rule="has(self.cluster) != has(self.urls)",message="exactly one of cluster or urls must be set"
+// +kubebuilder:validation:XValidation:rule="has(self.cluster) || has(self.name)",message="name is required with urls"
+// +kubebuilder:validation:XValidation:rule="!has(self.cluster) || !has(self.name) || self.name == self.cluster.name",message="name must match the cluster name"
+type NatsGatewayRemote struct {
+	// Name of the remote gateway, which is the name of its Cluster. Taken from cluster when set.
+	// +kubebuilder:validation:Optional
+	Name string `json:"name,omitempty"`
+	// Cluster in this Kubernetes cluster. Its servers and CA are found through it,
+	// and it is checked to share the operator trust.
+	// +kubebuilder:validation:Optional
+	Cluster *corev1.ObjectReference `json:"cluster,omitempty"`
+	// URLs of the remote gateway listeners, e.g. "tls://nats.eu.example.com:7222".
+	// +kubebuilder:validation:Optional
+	URLs []string `json:"urls,omitempty"`
+	// CASecret holds a PEM CA bundle the remote servers are verified with. Gateways verify each
+	// other both ways, so the remote must trust the CA of this Cluster too.
+	// +kubebuilder:validation:Optional
+	CASecret *corev1.SecretKeySelector `json:"caSecret,omitempty"`
+}
+
+// NatsExposeSpec publishes the client and leafnode ports, and advertises the address they are reachable at.
+// +kubebuilder:validation:XValidation:rule="self.type == 'LoadBalancer' || has(self.host)",message="host is required unless type is LoadBalancer"
+// +kubebuilder:validation:XValidation:rule="self.type != 'TLSRoute' || (has(self.tlsRoute) && has(self.leafNodeHost) && self.leafNodeHost != self.host)",message="tlsRoute and a leafNodeHost other than host are required with type TLSRoute"
+type NatsExposeSpec struct {
+	// Type of exposure. LoadBalancer and NodePort create a "nats-<cluster>-external" Service,
+	// which publishes the gateway port too when gateways are enabled.
+	// TLSRoute attaches Gateway API TLSRoutes to a Gateway passing TLS through; servers then expect
+	// TLS before anything else, so clients and leafnodes must be set up to handshake first.
+	// Gateways can't handshake first, and aren't published through TLSRoutes.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=LoadBalancer;NodePort;TLSRoute
+	// +kubebuilder:default=LoadBalancer
+	Type string `json:"type,omitempty"`
+	// Host clients reach the servers by. It is added to the server certificate.
+	// Defaults to the hostname or IP of the load balancer.
+	// +kubebuilder:validation:Optional
+	Host string `json:"host,omitempty"`
+	// LeafNodeHost leafnodes reach the servers by, when it differs from Host.
+	// TLSRoutes tell the two ports apart by hostname, so it must differ from Host there.
+	// +kubebuilder:validation:Optional
+	LeafNodeHost string `json:"leafNodeHost,omitempty"`
+	// Annotations of the external Service, e.g. to configure the cloud load balancer.
+	// +kubebuilder:validation:Optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// TLSRoute selects the Gateway the routes attach to.
+	// +kubebuilder:validation:Optional
+	TLSRoute *NatsTLSRouteSpec `json:"tlsRoute,omitempty"`
+}
+
+// NatsTLSRouteSpec points TLSRoutes at a Gateway listener in TLS passthrough mode.
+type NatsTLSRouteSpec struct {
+	// GatewayName of the parent Gateway.
+	// +kubebuilder:validation:Required
+	GatewayName string `json:"gatewayName"`
+	// GatewayNamespace of the parent Gateway. Defaults to the Cluster namespace.
+	// +kubebuilder:validation:Optional
+	GatewayNamespace string `json:"gatewayNamespace,omitempty"`
+	// SectionName of the Gateway listener, when the routes shouldn't attach to all of them.
+	// +kubebuilder:validation:Optional
+	SectionName string `json:"sectionName,omitempty"`
+	// Port of the Gateway listener, advertised to clients and leafnodes.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +kubebuilder:default=443
+	Port int32 `json:"port,omitempty"`
+}
+
+// NatsLeafNodeRemote bridges a Cluster account to an account of an upstream hub.
+// Messages flow both ways, so lattices of the Cluster account are joined to the hub ones.
+type NatsLeafNodeRemote struct {
+	// Account of the Cluster bridged to the hub: the account wadm and hosts use, or the system account.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Cluster;System
+	// +kubebuilder:default=Cluster
+	Account string `json:"account,omitempty"`
+	// URLs of the hub leafnode listeners, e.g. "tls://hub.example.com:7422".
+	// Servers connect to one of them and fail over to the others.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	URLs []string `json:"urls"`
+	// CredentialsSecret holds the creds file the hub authenticates the servers with.
+	// The hub account is the one of the creds user.
+	// +kubebuilder:validation:Optional
+	CredentialsSecret *corev1.SecretKeySelector `json:"credentialsSecret,omitempty"`
+	// TLS settings of the connection to the hub.
+	// +kubebuilder:validation:Optional
+	TLS *NatsLeafNodeTLS `json:"tls,omitempty"`
+}
+
+// NatsLeafNodeTLS configures TLS towards a hub.
+type NatsLeafNodeTLS struct {
+	// CASecret holds a PEM CA bundle the hub is verified with. System roots are used when unset.
+	// +kubebuilder:validation:Optional
+	CASecret *corev1.SecretKeySelector `json:"caSecret,omitempty"`
+	// CertificateSecret names a kubernetes.io/tls Secret presented to hubs requiring client certificates.
+	// +kubebuilder:validation:Optional
+	CertificateSecret string `json:"certificateSecret,omitempty"`
+}
+
+// NatsAccountLimits caps the resources the Cluster account may use across all its users.
+type NatsAccountLimits struct {
+	// Connections is the maximum number of client connections.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	Connections *int64 `json:"connections,omitempty"`
+	// Subscriptions is the maximum number of subscriptions.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	Subscriptions *int64 `json:"subscriptions,omitempty"`
+	// Payload is the maximum size of a message. Servers never accept more than 1Mi by default.
+	// +kubebuilder:validation:Optional
+	Payload *resource.Quantity `json:"payload,omitempty"`
+	// JetStream limits. JetStream is enabled with no limits when unset.
+	// +kubebuilder:validation:Optional
+	JetStream *NatsJetStreamLimits `json:"jetstream,omitempty"`
+}
+
+// NatsJetStreamLimits caps the JetStream resources of an account.
+type NatsJetStreamLimits struct {
+	// MemoryStorage is the total size of memory backed streams.
+	// +kubebuilder:validation:Optional
+	MemoryStorage *resource.Quantity `json:"memoryStorage,omitempty"`
+	// DiskStorage is the total size of file backed streams, across replicas.
+	// +kubebuilder:validation:Optional
+	DiskStorage *resource.Quantity `json:"diskStorage,omitempty"`
+	// Streams is the maximum number of streams, including KV buckets.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	Streams *int64 `json:"streams,omitempty"`
+	// Consumers is the maximum number of consumers.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	Consumers *int64 `json:"consumers,omitempty"`
+}
+
+// NatsKeysSpec supplies existing NATS keys and exports the ones the Cluster uses.
+type NatsKeysSpec struct {
+	// SecretName of a Secret in the Cluster namespace holding nkey seeds to use instead of generated ones,
+	// under "operator", "system", "account", "user", "system-user", "auth", "callout" and "sentinel".
+	// Seeds left out are generated. An "operator.jwt" entry is served as-is instead of an operator JWT
+	// signed by the operator; the "operator" seed may then be one of its signing keys,
+	// so the operator identity key can stay offline.
+	// +kubebuilder:validation:Optional
+	SecretName string `json:"secretName,omitempty"`
+	// Export publishes the operator and account JWTs under "nsc.tar.gz" in the "<cluster>-nats-nsc"
+	// ConfigMap, laid out as an nsc store: extract it in the nsc stores directory. Seeds aren't exported.
+	// +kubebuilder:validation:Optional
+	Export bool `json:"export,omitempty"`
+}
+
+// NatsAuthCalloutSpec configures the auth callout service run by the operator.
+// Clients present a ServiceAccount token as their connection token. The operator validates it
+// through a TokenReview and issues a user JWT limited to a lattice: the one named by the
+// "k8s.wasmcloud.dev/lattice" ServiceAccount annotation, or the ServiceAccount namespace.
+// Either way a HostGroup of this Cluster in the ServiceAccount namespace must serve the lattice,
+// otherwise the connection is refused. Users make wRPC calls and use the wadm API
+// of their lattice, and receive replies on the "_INBOX_<namespace>_<name>" inbox prefix only,
+// dots of the name replaced by "_".
+type NatsAuthCalloutSpec struct {
+	// Audiences the tokens must be issued for. Tokens for the API server audience are accepted when empty.
+	// +kubebuilder:validation:Optional
+	Audiences []string `json:"audiences,omitempty"`
 }
+
+// NatsExternalSpec points a Cluster at a NATS deployment the operator doesn't manage.
+type NatsExternalSpec struct {
+	// URLs of the NATS servers, e.g. "nats://nats.example.com:4222".
+	// Hosts and wadm connect to the first one.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	URLs []string `json:"urls"`
+	// CredentialsSecret holds a NATS creds file with the user JWT and seed.
+	// +kubebuilder:validation:Required
+	CredentialsSecret corev1.SecretKeySelector `json:"credentialsSecret"`
+	// CASecret holds a PEM CA bundle used to verify the servers.
+	// +kubebuilder:validation:Optional
+	CASecret *corev1.SecretKeySelector `json:"caSecret,omitempty"`
+	// JetStreamDomain used by wadm and hosts. Defaults to "default".
+	// +kubebuilder:validation:Optional
+	JetStreamDomain string `json:"jetstreamDomain,omitempty"`
+}
+
+// +kubebuilder:validation:XValidation:rule="has(self.managed) != has(self.external)",message="exactly one of managed or external must be set"
 type NatsSpec struct {
 	// +kubebuilder:validation:Optional
 	Managed *NatsManagedSpec `json:"managed,omitempty"`
+	// +kubebuilder:validation:Optional
+	External *NatsExternalSpec `json:"external,omitempty"`
 }
 
 type WadmManagedSpec struct {
@@ -42,9 +346,24 @@ type WadmManagedSpec struct {
 	Replicas int32 `json:"replicas,omitempty"`
 }
 
+// WadmExternalSpec describes a wadm the operator doesn't deploy but still drives.
+type WadmExternalSpec struct {
+	// Lattice the external wadm manages.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=default
+	Lattice string `json:"lattice,omitempty"`
+	// APIPrefix wadm serves its API on, as set by its `--api-prefix` flag.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=wadm.api
+	APIPrefix string `json:"apiPrefix,omitempty"`
+}
+
+// +kubebuilder:validation:XValidation:rule="!(has(self.managed) && has(self.external))",message="managed and external are mutually exclusive"
 type WadmSpec struct {
 	// +kubebuilder:validation:Optional
 	Managed *WadmManagedSpec `json:"managed,omitempty"`
+	// +kubebuilder:validation:Optional
+	External *WadmExternalSpec `json:"external,omitempty"`
 }
 
 type PolicySpec struct {
@@ -98,22 +417,129 @@ type ClusterSpec struct {
 	Addons *ClusterAddons `json:"addons"`
 }
 
+type NatsServerStatus struct {
+	// Name of the server, matching its pod name.
+	Name string `json:"name"`
+	// +kubebuilder:validation:Optional
+	ServerID string `json:"serverId,omitempty"`
+	// +kubebuilder:validation:Optional
+	Version string `json:"version,omitempty"`
+	// Healthy reports whether the server /healthz endpoint answered ok.
+	Healthy bool `json:"healthy"`
+	// +kubebuilder:validation:Optional
+	Message string `json:"message,omitempty"`
+}
+
+// NatsScaleStatus tracks a NATS cluster resize in progress.
+type NatsScaleStatus struct {
+	// From is the replica count the resize started at.
+	From int32 `json:"from"`
+	// To is the requested replica count.
+	To int32 `json:"to"`
+	// Phase of the resize: ScalingUp, RemovingPeer, WaitingForReady or Reloading.
+	Phase string `json:"phase"`
+	// +kubebuilder:validation:Optional
+	Message string `json:"message,omitempty"`
+}
+
 type NatsStatus struct {
 	Managed bool `json:"managed"`
+	// +kubebuilder:validation:Optional
+	Replicas int32 `json:"replicas,omitempty"`
+	// +kubebuilder:validation:Optional
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+	// +kubebuilder:validation:Optional
+	Servers []NatsServerStatus `json:"servers,omitempty"`
+	// Scale is set while the NATS cluster is being resized.
+	// +kubebuilder:validation:Optional
+	Scale *NatsScaleStatus `json:"scale,omitempty"`
+	// CertificateNotAfter is when the current server certificate expires.
+	// +kubebuilder:validation:Optional
+	CertificateNotAfter *metav1.Time `json:"certificateNotAfter,omitempty"`
+	// Credentials issued by the operator for the Cluster.
+	// +kubebuilder:validation:Optional
+	Credentials []NatsCredentialsStatus `json:"credentials,omitempty"`
+	// LeafNodes reports the connections of the leafnode remotes, as seen by the monitor /leafz endpoint.
+	// +kubebuilder:validation:Optional
+	LeafNodes []NatsLeafNodeStatus `json:"leafNodes,omitempty"`
+	// Exposure reports the addresses the servers are reachable at from outside Kubernetes.
+	// +kubebuilder:validation:Optional
+	Exposure *NatsExposureStatus `json:"exposure,omitempty"`
+	// Gateways reports the connections to the gateway remotes, as seen by the monitor /gatewayz endpoint.
+	// +kubebuilder:validation:Optional
+	Gateways []NatsGatewayStatus `json:"gateways,omitempty"`
+}
+
+// NatsGatewayStatus reports how a gateway remote is connected.
+type NatsGatewayStatus struct {
+	// Name of the remote gateway.
+	Name string `json:"name"`
+	// ConnectedServers counts the servers holding an outbound connection to the remote.
+	ConnectedServers int32 `json:"connectedServers"`
+}
+
+// NatsExposureStatus holds the advertised "host:port" addresses of an exposed Cluster.
+// Addresses are empty until the load balancer got one.
+type NatsExposureStatus struct {
+	// ClientAddress hosts and clients connect to.
+	// +kubebuilder:validation:Optional
+	ClientAddress string `json:"clientAddress,omitempty"`
+	// LeafNodeAddress leafnode remotes connect to.
+	// +kubebuilder:validation:Optional
+	LeafNodeAddress string `json:"leafNodeAddress,omitempty"`
+	// GatewayAddress other members of the supercluster connect to. Set when gateways are enabled.
+	// +kubebuilder:validation:Optional
+	GatewayAddress string `json:"gatewayAddress,omitempty"`
+}
+
+// NatsLeafNodeStatus reports how a leafnode remote is connected to its hub.
+type NatsLeafNodeStatus struct {
+	// Account of the remote.
+	Account string `json:"account"`
+	// ConnectedServers counts the servers holding a connection to the hub.
+	ConnectedServers int32 `json:"connectedServers"`
+	// Hubs are the names of the hub servers connected to.
+	// +kubebuilder:validation:Optional
+	Hubs []string `json:"hubs,omitempty"`
+}
+
+// NatsCredentialsStatus describes the user JWT held in a creds Secret.
+type NatsCredentialsStatus struct {
+	// Secret holding the creds file.
+	Secret string `json:"secret"`
+	// UserPublicKey is the nkey of the user.
+	UserPublicKey string `json:"userPublicKey"`
+	// Expires is when the current user JWT stops being accepted.
+	Expires metav1.Time `json:"expires"`
 }
 
 type WadmStatus struct {
 	Managed bool `json:"managed"`
+	// Reachable reports whether wadm answered the last API probe.
+	// +kubebuilder:validation:Optional
+	Reachable bool `json:"reachable,omitempty"`
+	// +kubebuilder:validation:Optional
+	Replicas int32 `json:"replicas,omitempty"`
+	// +kubebuilder:validation:Optional
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
 }
 
 // ClusterStatus defines the observed state of Cluster.
 type ClusterStatus struct {
 	condition.ConditionedStatus `json:",inline"`
 	ObservedGeneration          int64 `json:"observedGeneration,omitempty"`
+	// +kubebuilder:validation:Optional
+	Nats NatsStatus `json:"nats,omitempty"`
+	// +kubebuilder:validation:Optional
+	Wadm WadmStatus `json:"wadm,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="READY",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="NATS",type=integer,JSONPath=`.status.nats.readyReplicas`
+// +kubebuilder:printcolumn:name="WADM",type=integer,JSONPath=`.status.wadm.readyReplicas`
+// +kubebuilder:printcolumn:name="AGE",type=date,JSONPath=".metadata.creationTimestamp"
 
 // Cluster is the Schema for the clusters API.
 // This type is not used directly and may be implemented in the future.
@@ -129,15 +555,119 @@ func (c *Cluster) ResourceLabel() string {
 	return c.GetName() + "." + c.GetNamespace()
 }
 
+// NatsSeedSecret holds the account and user seeds the operator issues credentials with.
 func (c *Cluster) NatsSeedSecret() string {
 	return c.GetName() + "-nats-seed"
 }
 
+// NatsOperatorSeedSecret holds the operator seed, which signs the Cluster accounts.
+func (c *Cluster) NatsOperatorSeedSecret() string {
+	return c.GetName() + "-nats-operator-seed"
+}
+
 func (c *Cluster) NatsClientSecret() string {
 	return c.GetName() + "-nats-client"
 }
+
+// NatsSystemSecret holds the creds of the system account user the operator manages NATS with.
+func (c *Cluster) NatsSystemSecret() string {
+	return c.GetName() + "-nats-system"
+}
+
+// NatsAuthSecret holds the creds of the user answering auth callout requests.
+func (c *Cluster) NatsAuthSecret() string {
+	return c.GetName() + "-nats-auth"
+}
+
+// NatsExportConfigMap holds the Cluster operator and accounts packed as an nsc store.
+func (c *Cluster) NatsExportConfigMap() string {
+	return c.GetName() + "-nats-nsc"
+}
+
+// NatsRevocations lists the NATS users revoked from the Cluster account.
+func (c *Cluster) NatsRevocations() string {
+	return c.GetName() + "-nats-revocations"
+}
+
+// NatsCASecret holds the certificate authority issuing the managed NATS certificates.
+func (c *Cluster) NatsCASecret() string {
+	return c.GetName() + "-ca"
+}
+
+// NatsTLSSecret holds the certificate the managed NATS servers present.
+func (c *Cluster) NatsTLSSecret() string {
+	return c.GetName() + "-nats-tls"
+}
+
+// NatsServers returns the client URLs of the Cluster NATS deployment.
+func (c *Cluster) NatsServers() []string {
+	if c.Spec.Nats.External != nil {
+		return c.Spec.Nats.External.URLs
+	}
+	return []string{"nats://nats-" + c.GetName() + "." + c.GetNamespace() + ".svc:4222"}
+}
+
+// NatsURL returns the NATS servers in the comma separated form accepted by nats.Connect.
+func (c *Cluster) NatsURL() string {
+	return strings.Join(c.NatsServers(), ",")
+}
+
 func (c *Cluster) NatsHost() string {
-	return "nats-" + c.GetName() + "." + c.GetNamespace() + ".svc"
+	host, _ := c.natsEndpoint()
+	return host
+}
+
+func (c *Cluster) NatsPort() string {
+	_, port := c.natsEndpoint()
+	return port
+}
+
+// natsEndpoint splits the first NATS server URL into host and port.
+func (c *Cluster) natsEndpoint() (string, string) {
+	servers := c.NatsServers()
+	if len(servers) == 0 {
+		return "", "4222"
+	}
+
+	server := servers[0]
+	if !strings.Contains(server, "://") {
+		server = "nats://" + server
+	}
+
+	u, err := url.Parse(server)
+	if err != nil {
+		return server, "4222"
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "4222"
+	}
+	return u.Hostname(), port
+}
+
+// JetStreamDomain returns the JetStream domain wadm and hosts should use.
+func (c *Cluster) JetStreamDomain() string {
+	if c.Spec.Nats.External != nil && c.Spec.Nats.External.JetStreamDomain != "" {
+		return c.Spec.Nats.External.JetStreamDomain
+	}
+	return "default"
+}
+
+// WadmAPIPrefix returns the subject prefix of the wadm API.
+func (c *Cluster) WadmAPIPrefix() string {
+	if c.Spec.Wadm.External != nil && c.Spec.Wadm.External.APIPrefix != "" {
+		return c.Spec.Wadm.External.APIPrefix
+	}
+	return "wadm.api"
+}
+
+// NatsCA reports whether the Cluster client secret carries a CA bundle under "ca.crt".
+func (c *Cluster) NatsCA() bool {
+	if c.Spec.Nats.External != nil {
+		return c.Spec.Nats.External.CASecret != nil
+	}
+	return c.Spec.Nats.Managed != nil
 }
 
 // +kubebuilder:object:root=true
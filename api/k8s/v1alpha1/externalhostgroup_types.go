@@ -0,0 +1,121 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"go.wasmcloud.dev/operator/api/condition"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ExternalHostGroupSpec defines the desired state of ExternalHostGroup.
+type ExternalHostGroupSpec struct {
+	// HostLabels are set on the hosts through the bootstrap bundle. They are passed as environment
+	// variables, so characters of the keys other than letters, digits and underscores become underscores.
+	// Keys that map to the same variable and values with control characters are rejected.
+	// +kubebuilder:validation:Optional
+	HostLabels map[string]string `json:"hostLabels,omitempty"`
+	// Lattice the hosts join, "default" unless set.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9][A-Za-z0-9_-]*$`
+	// +kubebuilder:validation:XValidation:rule="!(self in ['wasmbus', 'wasmcloud', 'wadm'])",message="lattice name is reserved"
+	Lattice string `json:"lattice,omitempty"`
+	// CredentialsTTL is the lifetime of the credentials in the bootstrap bundle. Hosts outside Kubernetes
+	// can't pick up renewed credentials, so the bundle has to be downloaded again before
+	// status.credentials.expires. A new bundle is issued once two thirds of it have elapsed.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default="720h"
+	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('10m')",message="credentialsTTL must be at least 10m"
+	CredentialsTTL *metav1.Duration `json:"credentialsTTL,omitempty"`
+	// Cluster the hosts join. It has to run managed NATS exposed outside Kubernetes.
+	// +kubebuilder:validation:Required
+	Cluster corev1.ObjectReference `json:"cluster,omitempty"`
+}
+
+// ExternalHostStatus describes a host heard from on the lattice.
+type ExternalHostStatus struct {
+	// ID of the host.
+	ID string `json:"id"`
+	// +kubebuilder:validation:Optional
+	Version string `json:"version,omitempty"`
+	// FirstSeen is when the operator first heard from the host.
+	FirstSeen metav1.Time `json:"firstSeen"`
+	// LastSeen is when the host last sent a heartbeat.
+	LastSeen metav1.Time `json:"lastSeen"`
+}
+
+// ExternalHostGroupStatus defines the observed state of ExternalHostGroup.
+type ExternalHostGroupStatus struct {
+	condition.ConditionedStatus `json:",inline"`
+	ObservedGeneration          int64 `json:"observedGeneration,omitempty"`
+	// Credentials issued to the hosts through the bootstrap bundle.
+	// +kubebuilder:validation:Optional
+	Credentials *HostGroupCredentialsStatus `json:"credentials,omitempty"`
+	// BootstrapSecret holds the bootstrap bundle of the hosts.
+	// +kubebuilder:validation:Optional
+	BootstrapSecret string `json:"bootstrapSecret,omitempty"`
+	// Hosts that sent a heartbeat recently.
+	// +kubebuilder:validation:Optional
+	Hosts []ExternalHostStatus `json:"hosts,omitempty"`
+	// +kubebuilder:validation:Optional
+	ReadyHosts int32 `json:"readyHosts,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="READY",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="HOSTS",type=integer,JSONPath=`.status.readyHosts`
+// +kubebuilder:printcolumn:name="EXPIRES",type=date,JSONPath=`.status.credentials.expires`
+// +kubebuilder:printcolumn:name="AGE",type=date,JSONPath=".metadata.creationTimestamp"
+
+// ExternalHostGroup is the Schema for the externalhostgroups API.
+// It stands for hosts running outside Kubernetes, such as laptops, VMs or edge boxes.
+// The operator issues them credentials and a bootstrap bundle, and tracks them through
+// their lattice heartbeats.
+type ExternalHostGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ExternalHostGroupSpec   `json:"spec,omitempty"`
+	Status ExternalHostGroupStatus `json:"status,omitempty"`
+}
+
+// BootstrapSecret holds the creds, CA and settings the hosts start with.
+func (h *ExternalHostGroup) BootstrapSecret() string {
+	return h.GetName() + "-bootstrap"
+}
+
+// LatticeName returns the lattice the hosts join, "default" unless set.
+func (h *ExternalHostGroup) LatticeName() string {
+	if h.Spec.Lattice == "" {
+		return "default"
+	}
+	return h.Spec.Lattice
+}
+
+// +kubebuilder:object:root=true
+
+// ExternalHostGroupList contains a list of ExternalHostGroup.
+type ExternalHostGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ExternalHostGroup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ExternalHostGroup{}, &ExternalHostGroupList{})
+}
@@ -23,8 +23,9 @@ import (
 )
 
 type ContainerSpec struct {
-	Image                    string                        `json:"image,omitempty"`
-	Command                  []string                      `json:"command,omitempty"`
+	Image   string   `json:"image,omitempty"`
+	Command []string `json:"command,omitempty"`
+	// Args are appended to the arguments the operator passes.
 	Args                     []string                      `json:"args,omitempty"`
 	WorkingDir               string                        `json:"workingDir,omitempty"`
 	Env                      []corev1.EnvVar               `json:"env,omitempty"`
@@ -76,15 +77,36 @@ type HostGroupSpec struct {
 	// NOTE(lxf): remove this or hardcode to default
 	// +kubebuilder:validation:Optional
 	// +kube:validation:Default="default"
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9][A-Za-z0-9_-]*$`
+	// +kubebuilder:validation:XValidation:rule="!(self in ['wasmbus', 'wasmcloud', 'wadm'])",message="lattice name is reserved"
 	Lattice string `json:"lattice,omitempty"`
 	// +kubebuilder:validation:Required
 	Cluster corev1.ObjectReference `json:"cluster,omitempty"`
 }
 
+// HostGroupCredentialsStatus identifies the NATS user the HostGroup hosts connect as.
+type HostGroupCredentialsStatus struct {
+	// UserPublicKey is the nkey of the HostGroup user, as seen in NATS connection info.
+	UserPublicKey string `json:"userPublicKey"`
+	// AccountPublicKey is the account that signed the user JWT.
+	AccountPublicKey string `json:"accountPublicKey"`
+	// JWTID is the unique ID of the current user JWT.
+	JWTID string `json:"jwtId"`
+	// IssuedAt is when the current user JWT was signed.
+	IssuedAt metav1.Time `json:"issuedAt"`
+	// Expires is when the current user JWT stops being accepted. It is renewed ahead of time.
+	// +kubebuilder:validation:Optional
+	Expires *metav1.Time `json:"expires,omitempty"`
+}
+
 // HostGroupStatus defines the observed state of HostGroup.
 type HostGroupStatus struct {
 	condition.ConditionedStatus `json:",inline"`
 	ObservedGeneration          int64 `json:"observedGeneration,omitempty"`
+	// Credentials issued to the HostGroup. Unset when the Cluster uses external NATS
+	// and hosts share the Cluster credentials.
+	// +kubebuilder:validation:Optional
+	Credentials *HostGroupCredentialsStatus `json:"credentials,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -107,6 +129,14 @@ func (h *HostGroup) NatsClientSecret() string {
 	return h.GetName() + "-nats-client"
 }
 
+// LatticeName returns the lattice the hosts join, "default" unless set.
+func (h *HostGroup) LatticeName() string {
+	if h.Spec.Lattice == "" {
+		return "default"
+	}
+	return h.Spec.Lattice
+}
+
 // +kubebuilder:object:root=true
 
 // HostGroupList contains a list of HostGroup.
@@ -21,8 +21,9 @@ limitations under the License.
 package v1alpha1
 
 import (
-	"k8s.io/api/core/v1"
-	runtime "k8s.io/apimachinery/pkg/runtime"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -40,6 +41,21 @@ func (in *ApplicationStatus) DeepCopy() *ApplicationStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateIssuerRef) DeepCopyInto(out *CertificateIssuerRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateIssuerRef.
+func (in *CertificateIssuerRef) DeepCopy() *CertificateIssuerRef {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateIssuerRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Cluster) DeepCopyInto(out *Cluster) {
 	*out = *in
@@ -162,6 +178,8 @@ func (in *ClusterSpec) DeepCopy() *ClusterSpec {
 func (in *ClusterStatus) DeepCopyInto(out *ClusterStatus) {
 	*out = *in
 	in.ConditionedStatus.DeepCopyInto(&out.ConditionedStatus)
+	in.Nats.DeepCopyInto(&out.Nats)
+	out.Wadm = in.Wadm
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterStatus.
@@ -189,46 +207,46 @@ func (in *ContainerSpec) DeepCopyInto(out *ContainerSpec) {
 	}
 	if in.Env != nil {
 		in, out := &in.Env, &out.Env
-		*out = make([]v1.EnvVar, len(*in))
+		*out = make([]corev1.EnvVar, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 	if in.EnvFrom != nil {
 		in, out := &in.EnvFrom, &out.EnvFrom
-		*out = make([]v1.EnvFromSource, len(*in))
+		*out = make([]corev1.EnvFromSource, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 	if in.ImagePullSecrets != nil {
 		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
-		*out = make([]v1.LocalObjectReference, len(*in))
+		*out = make([]corev1.LocalObjectReference, len(*in))
 		copy(*out, *in)
 	}
 	if in.Resources != nil {
 		in, out := &in.Resources, &out.Resources
-		*out = new(v1.ResourceRequirements)
+		*out = new(corev1.ResourceRequirements)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.ContainerSecurityContext != nil {
 		in, out := &in.ContainerSecurityContext, &out.ContainerSecurityContext
-		*out = new(v1.SecurityContext)
+		*out = new(corev1.SecurityContext)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.ReadinessProbe != nil {
 		in, out := &in.ReadinessProbe, &out.ReadinessProbe
-		*out = new(v1.Probe)
+		*out = new(corev1.Probe)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.LivenessProbe != nil {
 		in, out := &in.LivenessProbe, &out.LivenessProbe
-		*out = new(v1.Probe)
+		*out = new(corev1.Probe)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.VolumeMounts != nil {
 		in, out := &in.VolumeMounts, &out.VolumeMounts
-		*out = make([]v1.VolumeMount, len(*in))
+		*out = make([]corev1.VolumeMount, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -245,6 +263,138 @@ func (in *ContainerSpec) DeepCopy() *ContainerSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalHostGroup) DeepCopyInto(out *ExternalHostGroup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalHostGroup.
+func (in *ExternalHostGroup) DeepCopy() *ExternalHostGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalHostGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ExternalHostGroup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalHostGroupList) DeepCopyInto(out *ExternalHostGroupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ExternalHostGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalHostGroupList.
+func (in *ExternalHostGroupList) DeepCopy() *ExternalHostGroupList {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalHostGroupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ExternalHostGroupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalHostGroupSpec) DeepCopyInto(out *ExternalHostGroupSpec) {
+	*out = *in
+	if in.HostLabels != nil {
+		in, out := &in.HostLabels, &out.HostLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.CredentialsTTL != nil {
+		in, out := &in.CredentialsTTL, &out.CredentialsTTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	out.Cluster = in.Cluster
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalHostGroupSpec.
+func (in *ExternalHostGroupSpec) DeepCopy() *ExternalHostGroupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalHostGroupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalHostGroupStatus) DeepCopyInto(out *ExternalHostGroupStatus) {
+	*out = *in
+	in.ConditionedStatus.DeepCopyInto(&out.ConditionedStatus)
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(HostGroupCredentialsStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]ExternalHostStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalHostGroupStatus.
+func (in *ExternalHostGroupStatus) DeepCopy() *ExternalHostGroupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalHostGroupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalHostStatus) DeepCopyInto(out *ExternalHostStatus) {
+	*out = *in
+	in.FirstSeen.DeepCopyInto(&out.FirstSeen)
+	in.LastSeen.DeepCopyInto(&out.LastSeen)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalHostStatus.
+func (in *ExternalHostStatus) DeepCopy() *ExternalHostStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalHostStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HostGroup) DeepCopyInto(out *HostGroup) {
 	*out = *in
@@ -272,6 +422,26 @@ func (in *HostGroup) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostGroupCredentialsStatus) DeepCopyInto(out *HostGroupCredentialsStatus) {
+	*out = *in
+	in.IssuedAt.DeepCopyInto(&out.IssuedAt)
+	if in.Expires != nil {
+		in, out := &in.Expires, &out.Expires
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostGroupCredentialsStatus.
+func (in *HostGroupCredentialsStatus) DeepCopy() *HostGroupCredentialsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HostGroupCredentialsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HostGroupList) DeepCopyInto(out *HostGroupList) {
 	*out = *in
@@ -333,6 +503,11 @@ func (in *HostGroupSpec) DeepCopy() *HostGroupSpec {
 func (in *HostGroupStatus) DeepCopyInto(out *HostGroupStatus) {
 	*out = *in
 	in.ConditionedStatus.DeepCopyInto(&out.ConditionedStatus)
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(HostGroupCredentialsStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostGroupStatus.
@@ -374,7 +549,7 @@ func (in *KubernetesSchedulingOptions) DeepCopyInto(out *KubernetesSchedulingOpt
 	*out = *in
 	if in.PodTemplateAdditions != nil {
 		in, out := &in.PodTemplateAdditions, &out.PodTemplateAdditions
-		*out = new(v1.PodSpec)
+		*out = new(corev1.PodSpec)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.Resources != nil {
@@ -394,11 +569,389 @@ func (in *KubernetesSchedulingOptions) DeepCopy() *KubernetesSchedulingOptions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NatsAccountLimits) DeepCopyInto(out *NatsAccountLimits) {
+	*out = *in
+	if in.Connections != nil {
+		in, out := &in.Connections, &out.Connections
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Subscriptions != nil {
+		in, out := &in.Subscriptions, &out.Subscriptions
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Payload != nil {
+		in, out := &in.Payload, &out.Payload
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.JetStream != nil {
+		in, out := &in.JetStream, &out.JetStream
+		*out = new(NatsJetStreamLimits)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NatsAccountLimits.
+func (in *NatsAccountLimits) DeepCopy() *NatsAccountLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(NatsAccountLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NatsAuthCalloutSpec) DeepCopyInto(out *NatsAuthCalloutSpec) {
+	*out = *in
+	if in.Audiences != nil {
+		in, out := &in.Audiences, &out.Audiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NatsAuthCalloutSpec.
+func (in *NatsAuthCalloutSpec) DeepCopy() *NatsAuthCalloutSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NatsAuthCalloutSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NatsCredentialsStatus) DeepCopyInto(out *NatsCredentialsStatus) {
+	*out = *in
+	in.Expires.DeepCopyInto(&out.Expires)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NatsCredentialsStatus.
+func (in *NatsCredentialsStatus) DeepCopy() *NatsCredentialsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NatsCredentialsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NatsExposeSpec) DeepCopyInto(out *NatsExposeSpec) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.TLSRoute != nil {
+		in, out := &in.TLSRoute, &out.TLSRoute
+		*out = new(NatsTLSRouteSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NatsExposeSpec.
+func (in *NatsExposeSpec) DeepCopy() *NatsExposeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NatsExposeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NatsExposureStatus) DeepCopyInto(out *NatsExposureStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NatsExposureStatus.
+func (in *NatsExposureStatus) DeepCopy() *NatsExposureStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NatsExposureStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NatsExternalSpec) DeepCopyInto(out *NatsExternalSpec) {
+	*out = *in
+	if in.URLs != nil {
+		in, out := &in.URLs, &out.URLs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.CredentialsSecret.DeepCopyInto(&out.CredentialsSecret)
+	if in.CASecret != nil {
+		in, out := &in.CASecret, &out.CASecret
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NatsExternalSpec.
+func (in *NatsExternalSpec) DeepCopy() *NatsExternalSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NatsExternalSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NatsGatewayRemote) DeepCopyInto(out *NatsGatewayRemote) {
+	*out = *in
+	if in.Cluster != nil {
+		in, out := &in.Cluster, &out.Cluster
+		*out = new(corev1.ObjectReference)
+		**out = **in
+	}
+	if in.URLs != nil {
+		in, out := &in.URLs, &out.URLs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CASecret != nil {
+		in, out := &in.CASecret, &out.CASecret
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NatsGatewayRemote.
+func (in *NatsGatewayRemote) DeepCopy() *NatsGatewayRemote {
+	if in == nil {
+		return nil
+	}
+	out := new(NatsGatewayRemote)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NatsGatewayStatus) DeepCopyInto(out *NatsGatewayStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NatsGatewayStatus.
+func (in *NatsGatewayStatus) DeepCopy() *NatsGatewayStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NatsGatewayStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NatsGatewaysSpec) DeepCopyInto(out *NatsGatewaysSpec) {
+	*out = *in
+	if in.Remotes != nil {
+		in, out := &in.Remotes, &out.Remotes
+		*out = make([]NatsGatewayRemote, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NatsGatewaysSpec.
+func (in *NatsGatewaysSpec) DeepCopy() *NatsGatewaysSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NatsGatewaysSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NatsJetStreamLimits) DeepCopyInto(out *NatsJetStreamLimits) {
+	*out = *in
+	if in.MemoryStorage != nil {
+		in, out := &in.MemoryStorage, &out.MemoryStorage
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.DiskStorage != nil {
+		in, out := &in.DiskStorage, &out.DiskStorage
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Streams != nil {
+		in, out := &in.Streams, &out.Streams
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Consumers != nil {
+		in, out := &in.Consumers, &out.Consumers
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NatsJetStreamLimits.
+func (in *NatsJetStreamLimits) DeepCopy() *NatsJetStreamLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(NatsJetStreamLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NatsKeysSpec) DeepCopyInto(out *NatsKeysSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NatsKeysSpec.
+func (in *NatsKeysSpec) DeepCopy() *NatsKeysSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NatsKeysSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NatsLeafNodeRemote) DeepCopyInto(out *NatsLeafNodeRemote) {
+	*out = *in
+	if in.URLs != nil {
+		in, out := &in.URLs, &out.URLs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CredentialsSecret != nil {
+		in, out := &in.CredentialsSecret, &out.CredentialsSecret
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(NatsLeafNodeTLS)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NatsLeafNodeRemote.
+func (in *NatsLeafNodeRemote) DeepCopy() *NatsLeafNodeRemote {
+	if in == nil {
+		return nil
+	}
+	out := new(NatsLeafNodeRemote)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NatsLeafNodeStatus) DeepCopyInto(out *NatsLeafNodeStatus) {
+	*out = *in
+	if in.Hubs != nil {
+		in, out := &in.Hubs, &out.Hubs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NatsLeafNodeStatus.
+func (in *NatsLeafNodeStatus) DeepCopy() *NatsLeafNodeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NatsLeafNodeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NatsLeafNodeTLS) DeepCopyInto(out *NatsLeafNodeTLS) {
+	*out = *in
+	if in.CASecret != nil {
+		in, out := &in.CASecret, &out.CASecret
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NatsLeafNodeTLS.
+func (in *NatsLeafNodeTLS) DeepCopy() *NatsLeafNodeTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(NatsLeafNodeTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NatsManagedSpec) DeepCopyInto(out *NatsManagedSpec) {
 	*out = *in
 	in.ReplicaSpec.DeepCopyInto(&out.ReplicaSpec)
 	in.ContainerSpec.DeepCopyInto(&out.ContainerSpec)
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(NatsStorageSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(NatsTLSSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CredentialsTTL != nil {
+		in, out := &in.CredentialsTTL, &out.CredentialsTTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.AuthCallout != nil {
+		in, out := &in.AuthCallout, &out.AuthCallout
+		*out = new(NatsAuthCalloutSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Keys != nil {
+		in, out := &in.Keys, &out.Keys
+		*out = new(NatsKeysSpec)
+		**out = **in
+	}
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = new(NatsAccountLimits)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LeafNodeRemotes != nil {
+		in, out := &in.LeafNodeRemotes, &out.LeafNodeRemotes
+		*out = make([]NatsLeafNodeRemote, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Expose != nil {
+		in, out := &in.Expose, &out.Expose
+		*out = new(NatsExposeSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Gateways != nil {
+		in, out := &in.Gateways, &out.Gateways
+		*out = new(NatsGatewaysSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NatsManagedSpec.
@@ -411,6 +964,36 @@ func (in *NatsManagedSpec) DeepCopy() *NatsManagedSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NatsScaleStatus) DeepCopyInto(out *NatsScaleStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NatsScaleStatus.
+func (in *NatsScaleStatus) DeepCopy() *NatsScaleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NatsScaleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NatsServerStatus) DeepCopyInto(out *NatsServerStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NatsServerStatus.
+func (in *NatsServerStatus) DeepCopy() *NatsServerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NatsServerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NatsSpec) DeepCopyInto(out *NatsSpec) {
 	*out = *in
@@ -419,6 +1002,11 @@ func (in *NatsSpec) DeepCopyInto(out *NatsSpec) {
 		*out = new(NatsManagedSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.External != nil {
+		in, out := &in.External, &out.External
+		*out = new(NatsExternalSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NatsSpec.
@@ -434,6 +1022,44 @@ func (in *NatsSpec) DeepCopy() *NatsSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NatsStatus) DeepCopyInto(out *NatsStatus) {
 	*out = *in
+	if in.Servers != nil {
+		in, out := &in.Servers, &out.Servers
+		*out = make([]NatsServerStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Scale != nil {
+		in, out := &in.Scale, &out.Scale
+		*out = new(NatsScaleStatus)
+		**out = **in
+	}
+	if in.CertificateNotAfter != nil {
+		in, out := &in.CertificateNotAfter, &out.CertificateNotAfter
+		*out = (*in).DeepCopy()
+	}
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = make([]NatsCredentialsStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LeafNodes != nil {
+		in, out := &in.LeafNodes, &out.LeafNodes
+		*out = make([]NatsLeafNodeStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Exposure != nil {
+		in, out := &in.Exposure, &out.Exposure
+		*out = new(NatsExposureStatus)
+		**out = **in
+	}
+	if in.Gateways != nil {
+		in, out := &in.Gateways, &out.Gateways
+		*out = make([]NatsGatewayStatus, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NatsStatus.
@@ -446,6 +1072,72 @@ func (in *NatsStatus) DeepCopy() *NatsStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NatsStorageSpec) DeepCopyInto(out *NatsStorageSpec) {
+	*out = *in
+	if in.StorageClassName != nil {
+		in, out := &in.StorageClassName, &out.StorageClassName
+		*out = new(string)
+		**out = **in
+	}
+	out.Size = in.Size.DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NatsStorageSpec.
+func (in *NatsStorageSpec) DeepCopy() *NatsStorageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NatsStorageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NatsTLSRouteSpec) DeepCopyInto(out *NatsTLSRouteSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NatsTLSRouteSpec.
+func (in *NatsTLSRouteSpec) DeepCopy() *NatsTLSRouteSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NatsTLSRouteSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NatsTLSSpec) DeepCopyInto(out *NatsTLSSpec) {
+	*out = *in
+	if in.IssuerRef != nil {
+		in, out := &in.IssuerRef, &out.IssuerRef
+		*out = new(CertificateIssuerRef)
+		**out = **in
+	}
+	if in.CADuration != nil {
+		in, out := &in.CADuration, &out.CADuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NatsTLSSpec.
+func (in *NatsTLSSpec) DeepCopy() *NatsTLSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NatsTLSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ObservabilityConfiguration) DeepCopyInto(out *ObservabilityConfiguration) {
 	*out = *in
@@ -511,7 +1203,7 @@ func (in *PolicySpec) DeepCopyInto(out *PolicySpec) {
 	*out = *in
 	if in.Rules != nil {
 		in, out := &in.Rules, &out.Rules
-		*out = make([]v1.ObjectReference, len(*in))
+		*out = make([]corev1.ObjectReference, len(*in))
 		copy(*out, *in)
 	}
 }
@@ -555,7 +1247,7 @@ func (in *ReplicaSpec) DeepCopyInto(out *ReplicaSpec) {
 	}
 	if in.Affinity != nil {
 		in, out := &in.Affinity, &out.Affinity
-		*out = new(v1.Affinity)
+		*out = new(corev1.Affinity)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.AutomountServiceAccountToken != nil {
@@ -572,26 +1264,26 @@ func (in *ReplicaSpec) DeepCopyInto(out *ReplicaSpec) {
 	}
 	if in.Tolerations != nil {
 		in, out := &in.Tolerations, &out.Tolerations
-		*out = make([]v1.Toleration, len(*in))
+		*out = make([]corev1.Toleration, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 	if in.TopologySpreadConstraints != nil {
 		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
-		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		*out = make([]corev1.TopologySpreadConstraint, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 	if in.SecurityContext != nil {
 		in, out := &in.SecurityContext, &out.SecurityContext
-		*out = new(v1.PodSecurityContext)
+		*out = new(corev1.PodSecurityContext)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.Volumes != nil {
 		in, out := &in.Volumes, &out.Volumes
-		*out = make([]v1.Volume, len(*in))
+		*out = make([]corev1.Volume, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -637,6 +1329,21 @@ func (in *SecretSpec) DeepCopy() *SecretSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WadmExternalSpec) DeepCopyInto(out *WadmExternalSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WadmExternalSpec.
+func (in *WadmExternalSpec) DeepCopy() *WadmExternalSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WadmExternalSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WadmManagedSpec) DeepCopyInto(out *WadmManagedSpec) {
 	*out = *in
@@ -662,6 +1369,11 @@ func (in *WadmSpec) DeepCopyInto(out *WadmSpec) {
 		*out = new(WadmManagedSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.External != nil {
+		in, out := &in.External, &out.External
+		*out = new(WadmExternalSpec)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WadmSpec.
@@ -694,7 +1406,7 @@ func (in *WasmCloudHostCertificates) DeepCopyInto(out *WasmCloudHostCertificates
 	*out = *in
 	if in.Authorities != nil {
 		in, out := &in.Authorities, &out.Authorities
-		*out = make([]v1.Volume, len(*in))
+		*out = make([]corev1.Volume, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -775,12 +1487,12 @@ func (in *WasmCloudHostConfigResources) DeepCopyInto(out *WasmCloudHostConfigRes
 	*out = *in
 	if in.Nats != nil {
 		in, out := &in.Nats, &out.Nats
-		*out = new(v1.ResourceRequirements)
+		*out = new(corev1.ResourceRequirements)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.Wasmcloud != nil {
 		in, out := &in.Wasmcloud, &out.Wasmcloud
-		*out = new(v1.ResourceRequirements)
+		*out = new(corev1.ResourceRequirements)
 		(*in).DeepCopyInto(*out)
 	}
 }
@@ -18,6 +18,7 @@ package v1beta1
 
 import (
 	"go.wasmcloud.dev/operator/api/condition"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
@@ -59,6 +60,14 @@ type ApplicationPolicy struct {
 type ApplicationSpec struct {
 	Components []ApplicationComponent `json:"components"`
 	Policies   []ApplicationPolicy    `json:"policies,omitempty"`
+
+	// Cluster the application is deployed to.
+	// When unset, the namespace binding or the operator default is used.
+	// Clusters in other namespaces have to allow the application namespace
+	// with the "k8s.wasmcloud.dev/allowed-namespaces" annotation.
+	// This field is not sent to wadm.
+	// +kubebuilder:validation:Optional
+	Cluster *corev1.ObjectReference `json:"cluster,omitempty"`
 }
 
 type ApplicationTraitStatus struct {
@@ -105,6 +114,11 @@ type ApplicationStatus struct {
 	// +optional
 	ObservedVersion string `json:"observedVersion,omitempty"`
 
+	// The Cluster the application was deployed to.
+	// Deletion cleans up there, even if the binding changed since.
+	// +optional
+	Cluster *corev1.ObjectReference `json:"cluster,omitempty"`
+
 	// The wadm status.
 	// +optional
 	Phase ApplicationPhase `json:"phase,omitempty"`
@@ -143,6 +157,16 @@ type ApplicationList struct {
 	Items           []Application `json:"items"`
 }
 
+const (
+	// ClusterAnnotation binds every Application in a Namespace to a Cluster.
+	// The value is either "name" or "namespace/name".
+	ClusterAnnotation = "k8s.wasmcloud.dev/cluster"
+	// ClusterAllowedNamespacesAnnotation on a Cluster lists the other namespaces whose
+	// Applications may name it in spec.cluster, comma separated, or "*" for all of them.
+	// Namespace annotations and the operator default are set by admins and aren't restricted.
+	ClusterAllowedNamespacesAnnotation = "k8s.wasmcloud.dev/allowed-namespaces"
+)
+
 func init() {
 	SchemeBuilder.Register(&Application{}, &ApplicationList{})
 }
@@ -19,13 +19,16 @@ package main
 import (
 	"crypto/tls"
 	"flag"
+	"fmt"
 	"os"
+	"strings"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -39,6 +42,8 @@ import (
 	coreoamv1beta1 "go.wasmcloud.dev/operator/api/oam/core/v1beta1"
 	k8scontroller "go.wasmcloud.dev/operator/internal/controller/k8s"
 	oamcontroller "go.wasmcloud.dev/operator/internal/controller/oam"
+	"go.wasmcloud.dev/operator/internal/envelope"
+	"go.wasmcloud.dev/operator/internal/natsconn"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -63,6 +68,8 @@ func main() {
 	var enableHTTP2 bool
 	var jsonLog bool
 	var lattice string
+	var defaultCluster string
+	var seedEncryptionSecret string
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -76,6 +83,12 @@ func main() {
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
 	flag.BoolVar(&jsonLog, "json-log", false, "Output logs in JSON format")
 	flag.StringVar(&lattice, "lattice", "default", "The wasmcloud lattice being managed")
+	flag.StringVar(&defaultCluster, "default-cluster", "",
+		"The Cluster (namespace/name) used by Applications without a cluster binding. "+
+			"Leave empty to require an explicit binding.")
+	flag.StringVar(&seedEncryptionSecret, "seed-encryption-secret", "",
+		"The Secret (namespace/name) holding the key NATS seeds are encrypted with, created when missing. "+
+			"Leave empty to store seeds unencrypted.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -157,10 +170,39 @@ func main() {
 		os.Exit(1)
 	}
 
+	var defaultClusterKey types.NamespacedName
+	if defaultCluster != "" {
+		defaultClusterKey, err = oamcontroller.ParseClusterReference(defaultCluster, "default")
+		if err != nil {
+			setupLog.Error(err, "invalid default cluster")
+			os.Exit(1)
+		}
+	} else {
+		setupLog.Info("no default cluster, policy and config servers are disabled")
+	}
+
+	var seedEncrypter envelope.KeyEncrypter
+	if seedEncryptionSecret != "" {
+		namespace, name, ok := strings.Cut(seedEncryptionSecret, "/")
+		if !ok || namespace == "" || name == "" {
+			setupLog.Error(fmt.Errorf("%q is not namespace/name", seedEncryptionSecret), "invalid seed encryption secret")
+			os.Exit(1)
+		}
+		seedEncrypter = envelope.NewSecretKeyEncrypter(mgr.GetClient(), types.NamespacedName{Namespace: namespace, Name: name})
+	}
+
+	connections := natsconn.NewManager(mgr.GetClient())
+	if err = mgr.Add(connections); err != nil {
+		setupLog.Error(err, "unable to set up nats connections")
+		os.Exit(1)
+	}
+
 	if err = (&oamcontroller.ApplicationReconciler{
-		Client:  mgr.GetClient(),
-		Scheme:  mgr.GetScheme(),
-		Lattice: lattice,
+		Client:         mgr.GetClient(),
+		Scheme:         mgr.GetScheme(),
+		Lattice:        lattice,
+		DefaultCluster: defaultClusterKey,
+		Connections:    connections,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Application")
 		os.Exit(1)
@@ -177,33 +219,50 @@ func main() {
 		Scheme:             mgr.GetScheme(),
 		ConfigMapNamespace: "wasmcloud-system",
 		ConfigMapName:      "policies",
+		Cluster:            defaultClusterKey,
+		Connections:        connections,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "PolicyReconciler")
 		os.Exit(1)
 	}
 
 	if err = (&k8scontroller.ConfigReconciler{
-		Client:  mgr.GetClient(),
-		Scheme:  mgr.GetScheme(),
-		Lattice: "default",
+		Client:      mgr.GetClient(),
+		Scheme:      mgr.GetScheme(),
+		Lattice:     "default",
+		Cluster:     defaultClusterKey,
+		Connections: connections,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ConfigReconciler")
 		os.Exit(1)
 	}
 	if err = (&k8scontroller.HostGroupReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		Connections:   connections,
+		SeedEncrypter: seedEncrypter,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "HostGroup")
 		os.Exit(1)
 	}
 	if err = (&k8scontroller.ClusterReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		Connections:   connections,
+		SeedEncrypter: seedEncrypter,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Cluster")
 		os.Exit(1)
 	}
+	if err = (&k8scontroller.ExternalHostGroupReconciler{
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		Connections:   connections,
+		SeedEncrypter: seedEncrypter,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ExternalHostGroup")
+		os.Exit(1)
+	}
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -10,6 +10,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 var prometheusTemplate = `
@@ -57,12 +58,27 @@ func (r *ClusterReconciler) reconcileAddons(ctx context.Context, cluster *k8sv1a
 }
 
 func (r *ClusterReconciler) reconcilePrometheus(ctx context.Context, cluster *k8sv1alpha1.Cluster) error {
+	conditionType := conditionAddonPrefix + "Prometheus"
+
 	if err := r.reconcilePrometheusConfig(ctx, cluster); err != nil {
-		return err
+		return recordCondition(&cluster.Status.ConditionedStatus, conditionType, err)
 	}
 
 	if err := r.reconcilePrometheusStatefulset(ctx, cluster); err != nil {
-		return err
+		return recordCondition(&cluster.Status.ConditionedStatus, conditionType, err)
+	}
+
+	var statefulset appsv1.StatefulSet
+	if err := r.Get(
+		ctx,
+		client.ObjectKey{Namespace: cluster.GetNamespace(), Name: "prometheus-" + cluster.GetName()},
+		&statefulset); err != nil {
+		return recordCondition(&cluster.Status.ConditionedStatus, conditionType, err)
+	}
+
+	// readiness is reported without failing the reconcile
+	if err := recordCondition(&cluster.Status.ConditionedStatus, conditionType, statefulSetReady(&statefulset)); err != nil {
+		log.FromContext(ctx).Info("prometheus is not ready", "reason", err.Error())
 	}
 
 	return nil
@@ -186,9 +202,8 @@ func (r *ClusterReconciler) reconcilePrometheusStatefulset(ctx context.Context,
 			"--web.enable-otlp-receiver",
 			"--enable-feature=native-histograms,auto-gomemlimit",
 		},
-		EnvFrom:      mergeEnvFromSource(cluster.Spec.Addons.Prometheus.EnvFrom),
-		Env:          mergeEnvVar(cluster.Spec.Addons.Prometheus.Env, defaultEnv),
-		VolumeMounts: mergeMounts(defaultMounts, cluster.Spec.Addons.Prometheus.VolumeMounts),
+		Env:          defaultEnv,
+		VolumeMounts: defaultMounts,
 		Ports: []corev1.ContainerPort{
 			{
 				Name:          "prometheus",
@@ -197,22 +212,14 @@ func (r *ClusterReconciler) reconcilePrometheusStatefulset(ctx context.Context,
 		},
 	}
 
-	volumes = append(volumes, cluster.Spec.Addons.Prometheus.Volumes...)
-
-	podTemplate := corev1.PodTemplateSpec{
-		ObjectMeta: metav1.ObjectMeta{
-			Labels: mergeLabels(wantLabels),
-		},
-		Spec: corev1.PodSpec{
-			EnableServiceLinks:            boolPtr(false),
-			AutomountServiceAccountToken:  cluster.Spec.Addons.Prometheus.AutomountServiceAccountToken,
-			TerminationGracePeriodSeconds: int64Ptr(0),
-			ServiceAccountName:            cluster.Spec.Addons.Prometheus.ServiceAccountName,
-			Containers:                    []corev1.Container{hostContainer},
-			Volumes:                       volumes,
-		},
+	checksum, err := objectChecksum(ctx, r.Client, client.ObjectKey{Namespace: cluster.GetNamespace(), Name: "prometheus-" + cluster.GetName()}, &corev1.ConfigMap{})
+	if err != nil {
+		return err
 	}
 
+	podTemplate := newPodTemplate(wantLabels, cluster.Spec.Addons.Prometheus.ReplicaSpec, cluster.Spec.Addons.Prometheus.ContainerSpec, hostContainer, volumes)
+	podTemplate.Annotations = map[string]string{checksumAnnotation: checksum}
+
 	spec := appsv1.StatefulSetSpec{
 		Selector: &metav1.LabelSelector{
 			MatchLabels: wantLabels,
@@ -233,7 +240,7 @@ func (r *ClusterReconciler) reconcilePrometheusStatefulset(ctx context.Context,
 		Spec: spec,
 	}
 
-	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, statefulset, func() error {
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, statefulset, func() error {
 		statefulset.Spec = spec
 		// labels might have been modified elsewhere, so merge them
 		statefulset.SetLabels(mergeLabels(statefulset.GetLabels(), cluster.Spec.Addons.Prometheus.Labels, defaultLabels))
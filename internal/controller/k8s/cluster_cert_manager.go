@@ -0,0 +1,118 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// cert-manager types are handled as unstructured objects, so the operator
+// doesn't depend on cert-manager unless a Cluster references an issuer.
+var certificateGVK = schema.GroupVersionKind{
+	Group:   "cert-manager.io",
+	Version: "v1",
+	Kind:    "Certificate",
+}
+
+// reconcileCertManagerCertificate requests a certificate for dnsNames from a cert-manager issuer,
+// stored in secretName. Returns the certificate expiry once cert-manager reports it ready.
+func (r *ClusterReconciler) reconcileCertManagerCertificate(
+	ctx context.Context,
+	cluster *k8sv1alpha1.Cluster,
+	issuer *k8sv1alpha1.CertificateIssuerRef,
+	secretName string,
+	commonName string,
+	dnsNames []string,
+) (time.Time, error) {
+	opts := natsCertificateOptions(cluster, false)
+
+	names := make([]interface{}, 0, len(dnsNames))
+	for _, name := range dnsNames {
+		names = append(names, name)
+	}
+
+	spec := map[string]interface{}{
+		"secretName": secretName,
+		"commonName": commonName,
+		"dnsNames":   names,
+		"duration":   opts.Lifetime.String(),
+		"usages":     []interface{}{"server auth", "client auth", "digital signature"},
+		"privateKey": map[string]interface{}{
+			"algorithm":      string(opts.KeyAlgorithm),
+			"encoding":       "PKCS8",
+			"rotationPolicy": "Always",
+		},
+		"issuerRef": map[string]interface{}{
+			"name":  issuer.Name,
+			"kind":  issuer.Kind,
+			"group": issuer.Group,
+		},
+	}
+
+	certificate := &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(certificateGVK)
+	certificate.SetNamespace(cluster.GetNamespace())
+	certificate.SetName(secretName)
+
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, certificate, func() error {
+		certificate.SetOwnerReferences([]metav1.OwnerReference{*metav1.NewControllerRef(cluster, cluster.GroupVersionKind())})
+		return unstructured.SetNestedMap(certificate.Object, spec, "spec")
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if !certificateReady(certificate) {
+		return time.Time{}, fmt.Errorf("waiting for certificate %s to be issued", certificate.GetName())
+	}
+
+	notAfter, _, _ := unstructured.NestedString(certificate.Object, "status", "notAfter")
+	expiry, err := time.Parse(time.RFC3339, notAfter)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("certificate %s: invalid notAfter %q", certificate.GetName(), notAfter)
+	}
+
+	return expiry, nil
+}
+
+// certificateReady reports whether the Ready condition of a cert-manager Certificate is True
+// for its current spec.
+func certificateReady(certificate *unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(certificate.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok || cond["type"] != "Ready" {
+			continue
+		}
+
+		if generation, ok, _ := unstructured.NestedInt64(cond, "observedGeneration"); ok && generation < certificate.GetGeneration() {
+			return false
+		}
+		return cond["status"] == string(corev1.ConditionTrue)
+	}
+
+	return false
+}
+
+// certManagerCA reads the CA cert-manager published next to an issued certificate.
+func (r *ClusterReconciler) certManagerCA(ctx context.Context, cluster *k8sv1alpha1.Cluster) ([]byte, error) {
+	var secret corev1.Secret
+	if err := r.Get(ctx, client.ObjectKey{Namespace: cluster.GetNamespace(), Name: cluster.NatsTLSSecret()}, &secret); err != nil {
+		return nil, err
+	}
+
+	ca, ok := secret.Data["ca.crt"]
+	if !ok || len(ca) == 0 {
+		return nil, fmt.Errorf("issuer didn't publish a CA in secret %s", secret.GetName())
+	}
+
+	return ca, nil
+}
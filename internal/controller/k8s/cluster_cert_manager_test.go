@@ -0,0 +1,58 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestCertificateReady(t *testing.T) {
+	cases := map[string]struct {
+		generation int64
+		conditions []interface{}
+		want       bool
+	}{
+		"NoStatus": {
+			want: false,
+		},
+		"Ready": {
+			generation: 1,
+			conditions: []interface{}{
+				map[string]interface{}{"type": "Issuing", "status": "False"},
+				map[string]interface{}{"type": "Ready", "status": "True", "observedGeneration": int64(1)},
+			},
+			want: true,
+		},
+		"NotReady": {
+			generation: 1,
+			conditions: []interface{}{
+				map[string]interface{}{"type": "Ready", "status": "False", "observedGeneration": int64(1)},
+			},
+			want: false,
+		},
+		"StaleGeneration": {
+			generation: 2,
+			conditions: []interface{}{
+				map[string]interface{}{"type": "Ready", "status": "True", "observedGeneration": int64(1)},
+			},
+			want: false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			certificate := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			certificate.SetGeneration(tc.generation)
+			if tc.conditions != nil {
+				if err := unstructured.SetNestedSlice(certificate.Object, tc.conditions, "status", "conditions"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if diff := cmp.Diff(tc.want, certificateReady(certificate)); diff != "" {
+				t.Errorf("certificateReady(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
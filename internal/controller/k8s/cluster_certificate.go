@@ -2,7 +2,11 @@ package k8s
 
 import (
 	"context"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
+	"slices"
+	"time"
 
 	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
 	"go.wasmcloud.dev/operator/internal/pki"
@@ -10,14 +14,67 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
-func (r *ClusterReconciler) loadCA(ctx context.Context, cluster *k8sv1alpha1.Cluster) (*pki.CertificateAuthority, error) {
+// caPreviousKey holds the CA being rotated out. It stays trusted until it expires,
+// so certificates it signed keep working until their own renewal.
+const caPreviousKey = "previous.crt"
+
+// clusterCA is the CA issuing new certificates, plus the one it replaced.
+type clusterCA struct {
+	*pki.CertificateAuthority
+	previous *x509.Certificate
+}
+
+// bundle returns the PEM certificates clients and servers should trust.
+func (ca *clusterCA) bundle() []byte {
+	certs := ca.CertificatePEM()
+	if ca.previous != nil {
+		certs = append(certs, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.previous.Raw})...)
+	}
+	return certs
+}
+
+// issued reports whether cert was signed by a trusted CA.
+func (ca *clusterCA) issued(cert *x509.Certificate) bool {
+	if cert.CheckSignatureFrom(ca.Certificate) == nil {
+		return true
+	}
+	return ca.previous != nil && cert.CheckSignatureFrom(ca.previous) == nil
+}
+
+// natsCertificateOptions returns the key algorithm and lifetime of the Cluster CA, or of the server certificates.
+func natsCertificateOptions(cluster *k8sv1alpha1.Cluster, authority bool) pki.Options {
+	opts := pki.Options{KeyAlgorithm: pki.ECDSA, Lifetime: pki.DefaultLifetime}
+	if authority {
+		opts.Lifetime = pki.DefaultCALifetime
+	}
+
+	if cluster.Spec.Nats.Managed == nil || cluster.Spec.Nats.Managed.TLS == nil {
+		return opts
+	}
+
+	tls := cluster.Spec.Nats.Managed.TLS
+	if tls.KeyAlgorithm != "" {
+		opts.KeyAlgorithm = pki.KeyAlgorithm(tls.KeyAlgorithm)
+	}
+	if authority && tls.CADuration != nil {
+		opts.Lifetime = tls.CADuration.Duration
+	}
+	if !authority && tls.Duration != nil {
+		opts.Lifetime = tls.Duration.Duration
+	}
+
+	return opts
+}
+
+func (r *ClusterReconciler) loadCA(ctx context.Context, cluster *k8sv1alpha1.Cluster) (*clusterCA, error) {
 	var err error
 	caSecret := &corev1.Secret{}
 	if err = r.Get(ctx, client.ObjectKey{
 		Namespace: cluster.Namespace,
-		Name:      cluster.Name + "-ca",
+		Name:      cluster.NatsCASecret(),
 	}, caSecret); err != nil {
 		return nil, err
 	}
@@ -32,93 +89,150 @@ func (r *ClusterReconciler) loadCA(ctx context.Context, cluster *k8sv1alpha1.Clu
 		return nil, fmt.Errorf("CA private key not found in secret %s", caSecret.Name)
 	}
 
-	return pki.LoadCertificateAuthority(caBytes, caKey)
-}
-
-func (r *ClusterReconciler) reconcileCertificate(ctx context.Context, cluster *k8sv1alpha1.Cluster, suffix string) error {
-	ca, err := r.loadCA(ctx, cluster)
+	ca, err := pki.LoadCertificateAuthority(caBytes, caKey)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	clientSecret := &corev1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace: cluster.Namespace,
-			Name:      cluster.Name + suffix,
-		},
-		Type: corev1.SecretTypeTLS,
+	loaded := &clusterCA{CertificateAuthority: ca}
+	if previous, ok := caSecret.Data[caPreviousKey]; ok {
+		if loaded.previous, err = pki.ParseCertificate(previous); err != nil {
+			return nil, err
+		}
 	}
 
-	if err = r.Get(ctx, client.ObjectKey{
-		Namespace: cluster.Namespace,
-		Name:      cluster.Name + suffix,
-	}, clientSecret); err == nil {
-		return nil
-	}
-	if client.IgnoreNotFound(err) != nil {
-		return err
-	}
+	return loaded, nil
+}
 
-	client, err := pki.NewClient(suffix)
+// reconcileCertificate keeps a certificate for commonName and dnsNames, signed by the Cluster CA, in secretName.
+// The certificate is reissued when its names or key algorithm change, it wasn't signed by a trusted CA,
+// or it is due for renewal. Returns the current certificate.
+func (r *ClusterReconciler) reconcileCertificate(
+	ctx context.Context,
+	cluster *k8sv1alpha1.Cluster,
+	secretName string,
+	commonName string,
+	dnsNames []string,
+) (*x509.Certificate, error) {
+	ca, err := r.loadCA(ctx, cluster)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
-	cert, err := ca.Sign(client.Certificate, client.KeyPair.PublicKey)
-	if err != nil {
-		return err
-	}
+	opts := natsCertificateOptions(cluster, false)
+	opts.DNSNames = dnsNames
 
-	clientSecret.Data = map[string][]byte{
-		"ca.crt":  ca.CertificatePEM(),
-		"tls.crt": cert,
-		"tls.key": client.PrivateKeyPEM(),
+	certSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       cluster.Namespace,
+			Name:            secretName,
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(cluster, cluster.GroupVersionKind())},
+		},
+		Type: corev1.SecretTypeTLS,
 	}
 
-	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, clientSecret, func() error {
+	var current *x509.Certificate
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, certSecret, func() error {
+		if certSecret.Data == nil {
+			certSecret.Data = make(map[string][]byte)
+		}
+		certSecret.Data["ca.crt"] = ca.bundle()
+
+		if cert, err := pki.ParseCertificate(certSecret.Data["tls.crt"]); err == nil && certificateCurrent(cert, ca, opts) {
+			current = cert
+			return nil
+		}
+
+		leaf, err := pki.NewClient(commonName, opts)
+		if err != nil {
+			return err
+		}
+
+		cert, err := ca.Sign(leaf.Certificate, leaf.KeyPair.PublicKey)
+		if err != nil {
+			return err
+		}
+
+		key, err := leaf.PrivateKeyPEM()
+		if err != nil {
+			return err
+		}
+
+		if current, err = pki.ParseCertificate(cert); err != nil {
+			return err
+		}
+
+		log.FromContext(ctx).Info("Issued certificate", "secret", secretName, "notAfter", current.NotAfter)
+		certSecret.Data["tls.crt"] = cert
+		certSecret.Data["tls.key"] = key
 		return nil
 	})
-	return err
+
+	return current, err
 }
 
+// reconcileCertificateAuthority creates the Cluster CA, and rotates it when due for renewal or
+// when the key algorithm changes. The outgoing CA is kept as trusted until it expires.
 func (r *ClusterReconciler) reconcileCertificateAuthority(ctx context.Context, cluster *k8sv1alpha1.Cluster) error {
-	var err error
+	opts := natsCertificateOptions(cluster, true)
+
 	caSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Namespace: cluster.Namespace,
-			Name:      cluster.Name + "-ca",
+			Namespace:       cluster.Namespace,
+			Name:            cluster.NatsCASecret(),
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(cluster, cluster.GroupVersionKind())},
 		},
 		Type: corev1.SecretTypeTLS,
 	}
 
-	if err = r.Get(ctx, client.ObjectKey{
-		Namespace: cluster.Namespace,
-		Name:      cluster.Name + "-ca",
-	}, caSecret); err == nil {
-		return nil
-	}
-	if client.IgnoreNotFound(err) != nil {
-		return err
-	}
-
-	ca, err := pki.NewCertificateAuthority(cluster.Name)
-	if err != nil {
-		return nil
-	}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, caSecret, func() error {
+		now := time.Now()
+
+		current, err := pki.LoadCertificateAuthority(caSecret.Data["ca.crt"], caSecret.Data["tls.key"])
+		if err == nil && !pki.RenewalDue(current.Certificate, now) && pki.Algorithm(current.KeyPair.PublicKey) == opts.KeyAlgorithm {
+			if previous, err := pki.ParseCertificate(caSecret.Data[caPreviousKey]); err == nil && now.After(previous.NotAfter) {
+				delete(caSecret.Data, caPreviousKey)
+			}
+			return nil
+		}
+
+		ca, err := pki.NewCertificateAuthority(cluster.Name, opts)
+		if err != nil {
+			return err
+		}
+
+		cert, err := ca.SelfSign()
+		if err != nil {
+			return err
+		}
+
+		key, err := ca.PrivateKeyPEM()
+		if err != nil {
+			return err
+		}
+
+		data := map[string][]byte{
+			"ca.crt":  cert,
+			"tls.crt": cert,
+			"tls.key": key,
+		}
+		if current != nil && now.Before(current.Certificate.NotAfter) {
+			log.FromContext(ctx).Info("Rotating cluster CA", "secret", caSecret.GetName(), "previousNotAfter", current.Certificate.NotAfter)
+			data[caPreviousKey] = current.CertificatePEM()
+		}
+		caSecret.Data = data
 
-	cert, err := ca.SelfSign()
-	if err != nil {
-		return err
-	}
-
-	caSecret.Data = map[string][]byte{
-		"ca.crt":  cert,
-		"tls.crt": cert,
-		"tls.key": ca.PrivateKeyPEM(),
-	}
-
-	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, caSecret, func() error {
 		return nil
 	})
+
 	return err
 }
+
+// certificateCurrent reports whether cert was issued by a trusted CA for exactly opts,
+// and isn't due for renewal.
+func certificateCurrent(cert *x509.Certificate, ca *clusterCA, opts pki.Options) bool {
+	return ca.issued(cert) &&
+		slices.Equal(cert.DNSNames, opts.DNSNames) &&
+		pki.Algorithm(cert.PublicKey) == opts.KeyAlgorithm &&
+		!pki.RenewalDue(cert, time.Now())
+}
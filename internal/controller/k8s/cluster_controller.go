@@ -18,28 +18,61 @@ package k8s
 
 import (
 	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
+	"go.wasmcloud.dev/operator/api/condition"
 	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	"go.wasmcloud.dev/operator/internal/envelope"
+	"go.wasmcloud.dev/operator/internal/natsconn"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 )
 
+const (
+	clusterRefreshInterval = 30 * time.Second
+
+	conditionNatsExposure    = "NatsExposure"
+	conditionNatsTLS         = "NatsTLS"
+	conditionNatsCredentials = "NatsCredentials"
+	conditionNatsConfig      = "NatsConfig"
+	conditionNatsStatefulSet = "NatsStatefulSet"
+	conditionNatsAccounts    = "NatsAccounts"
+	conditionNatsAuthCallout = "NatsAuthCallout"
+	conditionNatsHealthy     = "NatsHealthy"
+	conditionNatsLeafNodes   = "NatsLeafNodes"
+	conditionNatsGateways    = "NatsGateways"
+	conditionWadm            = "Wadm"
+	conditionAddonPrefix     = "Addon"
+)
+
 // ClusterReconciler reconciles a Cluster object
 type ClusterReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+	// Shared NATS connections, dropped when their Cluster goes away.
+	Connections *natsconn.Manager
+	// Used to reach the NATS monitor port. Defaults to a client with a short timeout.
+	HTTPClient *http.Client
+	// Encrypts the Cluster seeds at rest. Seeds are stored in plaintext when unset.
+	SeedEncrypter envelope.KeyEncrypter
+
+	// auth callout responders, by Cluster
+	authLock     sync.Mutex
+	authCallouts map[types.NamespacedName]*natsAuthCallout
 }
 
-// NOTE(lxf): Disabled as we are not using this type.
-// ClusterSpec & Status reconciliation updates configmaps.
-// kubebuilder:rbac:groups=k8s.wasmcloud.dev,resources=clusters,verbs=get;list;watch;create;update;patch;delete
-// kubebuilder:rbac:groups=k8s.wasmcloud.dev,resources=clusters/status,verbs=get;update;patch
-// kubebuilder:rbac:groups=k8s.wasmcloud.dev,resources=clusters/finalizers,verbs=update
+// +kubebuilder:rbac:groups=k8s.wasmcloud.dev,resources=clusters,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=k8s.wasmcloud.dev,resources=clusters/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=k8s.wasmcloud.dev,resources=clusters/finalizers,verbs=update
 
 // +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps,resources=statefulsets/status,verbs=get;update;patch
@@ -47,32 +80,55 @@ type ClusterReconciler struct {
 
 // +kubebuilder:rbac:groups=core,resources=secrets;configmaps;services,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=secrets/finalizers;configmaps/finalizers;services/finalizers,verbs=update
+// +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs=get;list;watch
+// +kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+// +kubebuilder:rbac:groups=k8s.wasmcloud.dev,resources=hostgroups,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=tlsroutes,verbs=get;list;watch;create;update;patch;delete
+
 func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 
 	var cluster k8sv1alpha1.Cluster
 	if err := r.Get(ctx, req.NamespacedName, &cluster); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			r.dropConnection(req.NamespacedName)
+		}
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
 	if !cluster.DeletionTimestamp.IsZero() {
 		// The object is being deleted
+		r.dropConnection(req.NamespacedName)
 		return ctrl.Result{}, nil
 	}
 
-	// if err := r.reconcileCertificateAuthority(ctx, &cluster); err != nil {
-	// 	logger.Error(err, "Failed to reconcile certificates")
-	// 	return ctrl.Result{}, err
-	// }
+	reconcileErr := r.reconcileSpec(ctx, &cluster)
+	if reconcileErr != nil {
+		logger.Error(reconcileErr, "Failed to reconcile cluster")
+	}
 
-	if err := r.reconcileNats(ctx, &cluster); err != nil {
-		logger.Error(err, "Failed to reconcile nats")
+	if err := r.reconcileStatus(ctx, &cluster, reconcileErr); err != nil {
+		logger.Error(err, "Failed to update cluster status")
 		return ctrl.Result{}, err
 	}
 
-	if err := r.reconcileWadm(ctx, &cluster); err != nil {
-		logger.Error(err, "Failed to reconcile wadm")
-		return ctrl.Result{}, err
+	if reconcileErr != nil {
+		return ctrl.Result{}, reconcileErr
+	}
+
+	return ctrl.Result{RequeueAfter: clusterRefreshInterval}, nil
+}
+
+func (r *ClusterReconciler) reconcileSpec(ctx context.Context, cluster *k8sv1alpha1.Cluster) error {
+	if err := r.reconcileNats(ctx, cluster); err != nil {
+		return err
+	}
+
+	if err := r.reconcileWadm(ctx, cluster); err != nil {
+		return err
 	}
 
 	// if err := r.reconcileHostGroups(ctx, &cluster); err != nil {
@@ -80,12 +136,86 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	// 	return ctrl.Result{}, err
 	// }
 
-	if err := r.reconcileAddons(ctx, &cluster); err != nil {
-		logger.Error(err, "Failed to reconcile addons")
-		return ctrl.Result{}, err
+	if err := r.reconcileAddons(ctx, cluster); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *ClusterReconciler) reconcileStatus(ctx context.Context, cluster *k8sv1alpha1.Cluster, reconcileErr error) error {
+	if reconcileErr != nil {
+		cluster.Status.SetConditions(condition.ReconcileError(reconcileErr))
+	} else {
+		cluster.Status.SetConditions(condition.ReconcileSuccess())
+		cluster.Status.ObservedGeneration = cluster.Generation
+	}
+
+	// conditions left over from a previous NATS or wadm mode, or from removed addons,
+	// would otherwise hold the Cluster unavailable
+	applicable := clusterConditions(cluster)
+	cluster.Status.Conditions = slices.DeleteFunc(cluster.Status.Conditions, func(cond condition.Condition) bool {
+		return cond.Type != condition.TypeReady && cond.Type != condition.TypeSynced &&
+			!slices.Contains(applicable, string(cond.Type))
+	})
+
+	ready := true
+	for _, cond := range cluster.Status.Conditions {
+		if cond.Type == condition.TypeReady {
+			continue
+		}
+		if cond.Status != corev1.ConditionTrue {
+			ready = false
+		}
+	}
+	if ready {
+		cluster.Status.SetConditions(condition.Available())
+	} else {
+		cluster.Status.SetConditions(condition.Unavailable())
+	}
+
+	return r.Status().Update(ctx, cluster)
+}
+
+// clusterConditions lists the condition types recorded for the current spec,
+// besides Ready and Synced.
+func clusterConditions(cluster *k8sv1alpha1.Cluster) []string {
+	conditions := []string{}
+
+	switch {
+	case cluster.Spec.Nats.External != nil:
+		conditions = append(conditions, conditionNatsCredentials, conditionNatsHealthy)
+	case cluster.Spec.Nats.Managed != nil:
+		conditions = append(conditions,
+			conditionNatsExposure,
+			conditionNatsTLS,
+			conditionNatsCredentials,
+			conditionNatsConfig,
+			conditionNatsStatefulSet,
+			conditionNatsAccounts,
+			conditionNatsAuthCallout,
+			conditionNatsHealthy,
+			conditionNatsLeafNodes,
+			conditionNatsGateways,
+		)
+	}
+
+	if cluster.Spec.Wadm.External != nil || cluster.Spec.Wadm.Managed != nil {
+		conditions = append(conditions, conditionWadm)
+	}
+
+	if addons := cluster.Spec.Addons; addons != nil && addons.Prometheus != nil {
+		conditions = append(conditions, conditionAddonPrefix+"Prometheus")
 	}
 
-	return ctrl.Result{}, nil
+	return conditions
+}
+
+func (r *ClusterReconciler) dropConnection(key types.NamespacedName) {
+	r.stopNatsAuthCallout(key)
+	if r.Connections != nil {
+		r.Connections.Remove(key)
+	}
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -95,6 +225,7 @@ func (r *ClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Named("k8s-cluster").
 		Owns(&corev1.Secret{}).
 		Owns(&corev1.ConfigMap{}).
+		Owns(&corev1.Service{}).
 		Owns(&appsv1.StatefulSet{}).
 		Owns(&appsv1.Deployment{}).
 		Complete(r)
@@ -18,16 +18,25 @@ package k8s
 
 import (
 	"context"
+	"errors"
+	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
-	"k8s.io/apimachinery/pkg/api/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"go.wasmcloud.dev/operator/api/condition"
 	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 var _ = Describe("Cluster Controller", func() {
@@ -45,7 +54,7 @@ var _ = Describe("Cluster Controller", func() {
 		BeforeEach(func() {
 			By("creating the custom resource for the Kind Cluster")
 			err := k8sClient.Get(ctx, typeNamespacedName, cluster)
-			if err != nil && errors.IsNotFound(err) {
+			if err != nil && apierrors.IsNotFound(err) {
 				resource := &k8sv1alpha1.Cluster{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      resourceName,
@@ -82,3 +91,108 @@ var _ = Describe("Cluster Controller", func() {
 		})
 	})
 })
+
+// newFakeClient returns an API client backed by objs, aware of the operator types.
+func newFakeClient(t *testing.T, objs ...client.Object) client.Client {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := k8sv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	return fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(&k8sv1alpha1.Cluster{}).
+		Build()
+}
+
+func TestClusterReconcileStatus(t *testing.T) {
+	failed := errors.New("failed")
+	managed := k8sv1alpha1.ClusterSpec{
+		Nats: k8sv1alpha1.NatsSpec{Managed: &k8sv1alpha1.NatsManagedSpec{Replicas: 1}},
+		Wadm: k8sv1alpha1.WadmSpec{Managed: &k8sv1alpha1.WadmManagedSpec{Replicas: 1}},
+	}
+	external := k8sv1alpha1.ClusterSpec{
+		Nats: k8sv1alpha1.NatsSpec{External: &k8sv1alpha1.NatsExternalSpec{URLs: []string{"nats://nats.example.com:4222"}}},
+	}
+
+	cases := map[string]struct {
+		spec       k8sv1alpha1.ClusterSpec
+		conditions []condition.Condition
+		wantReady  corev1.ConditionStatus
+		wantTypes  []condition.ConditionType
+	}{
+		"Ready": {
+			spec: external,
+			conditions: []condition.Condition{
+				condition.ReadyCondition(conditionNatsCredentials),
+				condition.ReadyCondition(conditionNatsHealthy),
+			},
+			wantReady: corev1.ConditionTrue,
+			wantTypes: []condition.ConditionType{conditionNatsCredentials, conditionNatsHealthy},
+		},
+		"ApplicableFailure": {
+			spec: managed,
+			conditions: []condition.Condition{
+				condition.ReadyCondition(conditionNatsConfig),
+				condition.ErrorCondition(conditionNatsStatefulSet, failed),
+			},
+			wantReady: corev1.ConditionFalse,
+			wantTypes: []condition.ConditionType{conditionNatsConfig, conditionNatsStatefulSet},
+		},
+		"SwitchedToExternal": {
+			spec: external,
+			conditions: []condition.Condition{
+				condition.ReadyCondition(conditionNatsCredentials),
+				condition.ErrorCondition(conditionNatsStatefulSet, failed),
+				condition.ErrorCondition(conditionNatsGateways, failed),
+				condition.ErrorCondition(conditionWadm, failed),
+			},
+			wantReady: corev1.ConditionTrue,
+			wantTypes: []condition.ConditionType{conditionNatsCredentials},
+		},
+		"AddonRemoved": {
+			spec: managed,
+			conditions: []condition.Condition{
+				condition.ReadyCondition(conditionWadm),
+				condition.ErrorCondition(conditionAddonPrefix+"Prometheus", failed),
+			},
+			wantReady: corev1.ConditionTrue,
+			wantTypes: []condition.ConditionType{conditionWadm},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cluster := &k8sv1alpha1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "wasmcloud"},
+				Spec:       tc.spec,
+			}
+			r := &ClusterReconciler{Client: newFakeClient(t, cluster)}
+			cluster.Status.SetConditions(tc.conditions...)
+
+			if err := r.reconcileStatus(context.Background(), cluster, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tc.wantReady, cluster.Status.GetCondition(condition.TypeReady).Status); diff != "" {
+				t.Errorf("ready: -want, +got:\n%s", diff)
+			}
+
+			got := []condition.ConditionType{}
+			for _, cond := range cluster.Status.Conditions {
+				if cond.Type != condition.TypeReady && cond.Type != condition.TypeSynced {
+					got = append(got, cond.Type)
+				}
+			}
+			if diff := cmp.Diff(tc.wantTypes, got); diff != "" {
+				t.Errorf("conditions: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
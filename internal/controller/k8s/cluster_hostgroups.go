@@ -8,6 +8,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
@@ -113,14 +114,27 @@ func (r *ClusterReconciler) reconcileHostGroupDeployment(ctx context.Context, cl
 		},
 		{
 			Name:  "WASMCLOUD_NATS_HOST",
-			Value: "nats-" + cluster.GetName(),
+			Value: cluster.NatsHost(),
 		},
 		{
 			Name:  "WASMCLOUD_NATS_PORT",
-			Value: "4222",
+			Value: cluster.NatsPort(),
 		},
 	}
 
+	if cluster.NatsCA() {
+		defaultEnv = append(defaultEnv,
+			corev1.EnvVar{
+				Name:  "WASMCLOUD_CTL_TLS_CA_FILE",
+				Value: "/creds/ca.crt",
+			},
+			corev1.EnvVar{
+				Name:  "WASMCLOUD_RPC_TLS_CA_FILE",
+				Value: "/creds/ca.crt",
+			},
+		)
+	}
+
 	volumes := []corev1.Volume{
 		{
 			Name: "wasmcloud-share",
@@ -149,20 +163,11 @@ func (r *ClusterReconciler) reconcileHostGroupDeployment(ctx context.Context, cl
 		},
 	}
 
-	image := hostGroup.Image
-	if image == "" {
-		image = "ghcr.io/wasmcloud/wasmcloud:canary"
-	}
-
 	hostContainer := corev1.Container{
 		Name:         "host",
-		Image:        image,
-		Command:      hostGroup.Command,
-		Args:         hostGroup.Args,
-		WorkingDir:   hostGroup.WorkingDir,
-		EnvFrom:      mergeEnvFromSource(hostGroup.EnvFrom),
-		Env:          mergeEnvVar(hostGroup.Env, defaultEnv),
-		VolumeMounts: mergeMounts(defaultMounts, hostGroup.VolumeMounts),
+		Image:        "ghcr.io/wasmcloud/wasmcloud:canary",
+		Env:          defaultEnv,
+		VolumeMounts: defaultMounts,
 		LivenessProbe: &corev1.Probe{
 			PeriodSeconds: 3,
 			ProbeHandler: corev1.ProbeHandler{
@@ -180,25 +185,15 @@ func (r *ClusterReconciler) reconcileHostGroupDeployment(ctx context.Context, cl
 		},
 	}
 
-	if hostGroup.Resources != nil {
-		hostContainer.Resources = *hostGroup.Resources
+	// hosts read the creds once at connect, so renewed user JWTs roll the pods like a new CA does.
+	// Renewal leaves a third of the TTL for the rollout.
+	checksum, err := objectChecksum(ctx, r.Client, client.ObjectKey{Namespace: cluster.GetNamespace(), Name: cluster.NatsClientSecret()}, &corev1.Secret{}, "ca.crt", "user.jwt")
+	if err != nil {
+		return err
 	}
 
-	volumes = append(volumes, hostGroup.Volumes...)
-
-	podTemplate := corev1.PodTemplateSpec{
-		ObjectMeta: metav1.ObjectMeta{
-			Labels: mergeLabels(hostGroup.Labels, wantLabels),
-		},
-		Spec: corev1.PodSpec{
-			EnableServiceLinks:            boolPtr(false),
-			AutomountServiceAccountToken:  hostGroup.AutomountServiceAccountToken,
-			TerminationGracePeriodSeconds: int64Ptr(0),
-			ServiceAccountName:            hostGroup.ServiceAccountName,
-			Containers:                    []corev1.Container{hostContainer},
-			Volumes:                       volumes,
-		},
-	}
+	podTemplate := newPodTemplate(wantLabels, hostGroup.ReplicaSpec, hostGroup.ContainerSpec, hostContainer, volumes)
+	podTemplate.Annotations = map[string]string{checksumAnnotation: checksum}
 
 	spec := appsv1.DeploymentSpec{
 		Selector: &metav1.LabelSelector{
@@ -217,7 +212,7 @@ func (r *ClusterReconciler) reconcileHostGroupDeployment(ctx context.Context, cl
 		Spec: spec,
 	}
 
-	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, deployment, func() error {
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, deployment, func() error {
 		deployment.Spec = spec
 		// labels might have been modified elsewhere, so merge them
 		deployment.SetLabels(mergeLabels(deployment.GetLabels(), hostGroup.Labels, defaultLabels))
@@ -1,10 +1,13 @@
 package k8s
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
-	"strings"
-	"text/template"
+	"path"
+	"slices"
+	"time"
 
 	"github.com/nats-io/jwt/v2"
 	"github.com/nats-io/nkeys"
@@ -12,85 +15,214 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
-var natsConfigTemplate = `
-{
-  "port": 4222,
-  "http_port": 8222,
-  "lame_duck_duration": "30s",
-  "lame_duck_grace_period": "10s",
-  "operator": "{{.OperatorJWT}}",
-  "system_account": "{{.SystemPub}}",
-  "resolver": {
-    "type": "memory",
-  },
-  "resolver_preload": {
-    "{{.SystemPub}}": "{{.SystemJWT}}",
-    "{{.AccountPub}}": "{{.AccountJWT}}",
-  },
-  "accounts":{
-    "AUTH": {
-  		"users": [ { "nkey": "{{.AuthPub}}" } ]
-	}
-  },
-  "authorization": {
-	"auth_callout": {
-		"issuer": "{{.AccountPub}}",
-		"auth_users": [ "{{.AuthPub}}" ],
-		"account": "AUTH",
-	},
-  },
-  "jetstream": {
-    "domain": "default",
-    "store_dir": "/data"
-  },
-  "leafnodes": {
-    "no_advertise": true,
-    "port": 7422
-  },
-  "cluster": {
-    "name": "{{ .Name }}",
-	"port": 6222,
-	"no_advertise": true,
-	"routes": [
-	 {{- range $idx, $route := .Routes }} {{ if ne $idx 0 }},{{ end }}"{{ $route }}" {{- end }}
-	]
+const (
+	natsLameDuckDuration    = 30 * time.Second
+	natsLameDuckGracePeriod = 10 * time.Second
+
+	natsPidFile        = "/var/run/nats/nats.pid"
+	natsTLSDir         = "/etc/nats-tls"
+	natsReloaderImage  = "natsio/nats-server-config-reloader:0.16.0"
+	natsShutdownBuffer = 10 * time.Second
+
+	natsDefaultCredentialsTTL = 24 * time.Hour
+)
+
+// natsRestartConfig holds the server settings NATS can't hot reload.
+// Its checksum lands on the pod template, so only these roll the servers;
+// everything else in the config is picked up by the reloader sidecar.
+type natsRestartConfig struct {
+	ClusterName     string
+	Ports           []int32
+	JetStreamDomain string
+	StoreDir        string
+	ResolverDir     string
+	LeafNodeRemotes []k8sv1alpha1.NatsLeafNodeRemote
+	// leafnode listener changes can't be reloaded either
+	LeafNodeAdvertise string
+	HandshakeFirst    bool
+	// nor can gateways, including the CAs they are verified with
+	Gateways         *k8sv1alpha1.NatsGatewaysSpec
+	GatewayAdvertise string
+	GatewaysCA       string
+	// overrides may touch any setting, including ones NATS can't reload
+	ConfigOverrides *runtime.RawExtension
+}
+
+func newNatsRestartConfig(cluster *k8sv1alpha1.Cluster) natsRestartConfig {
+	config := newNatsServerConfig(cluster)
+
+	var ports []int32
+	for _, port := range config.ports() {
+		ports = append(ports, port.ContainerPort)
+	}
+	slices.Sort(ports)
+
+	return natsRestartConfig{
+		ClusterName:       config.Cluster.Name,
+		Ports:             ports,
+		JetStreamDomain:   config.JetStream.Domain,
+		StoreDir:          config.JetStream.StoreDir,
+		ResolverDir:       config.Resolver.Dir,
+		LeafNodeRemotes:   cluster.Spec.Nats.Managed.LeafNodeRemotes,
+		LeafNodeAdvertise: natsLeafNodeAdvertise(cluster),
+		HandshakeFirst:    natsHandshakeFirst(cluster),
+		Gateways:          cluster.Spec.Nats.Managed.Gateways,
+		GatewayAdvertise:  natsGatewayAdvertise(cluster),
+		ConfigOverrides:   cluster.Spec.Nats.Managed.Config,
+	}
 }
-`
 
 func (r *ClusterReconciler) reconcileNats(ctx context.Context, cluster *k8sv1alpha1.Cluster) error {
+	logger := log.FromContext(ctx)
+
+	if cluster.Spec.Nats.External != nil {
+		return r.reconcileNatsExternal(ctx, cluster)
+	}
 
-	// if err := r.reconcileCertificate(ctx, cluster, "nats-client"); err != nil {
-	// 	return err
-	// }
+	if cluster.Spec.Nats.Managed == nil {
+		return fmt.Errorf("nats: one of managed or external must be set")
+	}
 
-	if err := r.reconcileNatsCredentials(ctx, cluster); err != nil {
+	if err := recordCondition(&cluster.Status.ConditionedStatus, conditionNatsExposure, r.reconcileNatsExposure(ctx, cluster)); err != nil {
 		return err
 	}
 
-	if err := r.reconcileNatsServerConfig(ctx, cluster); err != nil {
+	if err := recordCondition(&cluster.Status.ConditionedStatus, conditionNatsTLS, r.reconcileNatsTLS(ctx, cluster)); err != nil {
 		return err
 	}
 
-	if err := r.reconcileNatsClientConfig(ctx, cluster); err != nil {
+	if err := recordCondition(&cluster.Status.ConditionedStatus, conditionNatsCredentials, r.reconcileNatsCredentials(ctx, cluster)); err != nil {
 		return err
 	}
 
-	if err := r.reconcileNatsStatefulset(ctx, cluster); err != nil {
+	if err := recordCondition(&cluster.Status.ConditionedStatus, conditionNatsConfig, r.reconcileNatsConfig(ctx, cluster)); err != nil {
+		return err
+	}
+
+	if err := recordCondition(&cluster.Status.ConditionedStatus, conditionNatsStatefulSet, r.reconcileNatsDeployment(ctx, cluster)); err != nil {
+		return err
+	}
+
+	if err := recordCondition(&cluster.Status.ConditionedStatus, conditionNatsAccounts, r.reconcileNatsAccounts(ctx, cluster)); err != nil {
+		return err
+	}
+
+	if err := recordCondition(&cluster.Status.ConditionedStatus, conditionNatsAuthCallout, r.reconcileNatsAuthCallout(ctx, cluster)); err != nil {
+		return err
+	}
+
+	// health is observed rather than reconciled, so it doesn't fail the reconcile
+	probes := r.probeNatsServers(ctx, cluster)
+	if err := r.reconcileNatsHealth(ctx, cluster, probes); err != nil {
+		logger.Info("NATS is not healthy", "reason", err.Error())
+	}
+
+	if err := r.reconcileNatsLeafNodes(ctx, cluster, probes); err != nil {
+		logger.Info("NATS leafnodes are not connected", "reason", err.Error())
+	}
+
+	if err := r.reconcileNatsGateways(ctx, cluster, probes); err != nil {
+		logger.Info("NATS gateways are not connected", "reason", err.Error())
+	}
+
+	return nil
+}
+
+// reconcileNatsTLS issues the certificate shared by the client, route and leafnode listeners,
+// either from the Cluster CA or through a cert-manager issuer.
+func (r *ClusterReconciler) reconcileNatsTLS(ctx context.Context, cluster *k8sv1alpha1.Cluster) error {
+	if issuer := natsIssuer(cluster); issuer != nil {
+		notAfter, err := r.reconcileCertManagerCertificate(ctx, cluster, issuer, cluster.NatsTLSSecret(), "nats-"+cluster.GetName(), natsServerNames(cluster))
+		if err != nil {
+			return err
+		}
+		cluster.Status.Nats.CertificateNotAfter = &metav1.Time{Time: notAfter}
+		return nil
+	}
+
+	if err := r.reconcileCertificateAuthority(ctx, cluster); err != nil {
 		return err
 	}
 
-	if err := r.reconcileNatsServices(ctx, cluster); err != nil {
+	cert, err := r.reconcileCertificate(ctx, cluster, cluster.NatsTLSSecret(), "nats-"+cluster.GetName(), natsServerNames(cluster))
+	if err != nil {
 		return err
 	}
+	cluster.Status.Nats.CertificateNotAfter = &metav1.Time{Time: cert.NotAfter}
 
 	return nil
 }
 
+func natsIssuer(cluster *k8sv1alpha1.Cluster) *k8sv1alpha1.CertificateIssuerRef {
+	if cluster.Spec.Nats.Managed == nil || cluster.Spec.Nats.Managed.TLS == nil {
+		return nil
+	}
+	return cluster.Spec.Nats.Managed.TLS.IssuerRef
+}
+
+// natsTrustBundle returns the CA certificates clients verify the managed servers with.
+func (r *ClusterReconciler) natsTrustBundle(ctx context.Context, cluster *k8sv1alpha1.Cluster) ([]byte, error) {
+	if natsIssuer(cluster) != nil {
+		return r.certManagerCA(ctx, cluster)
+	}
+
+	ca, err := r.loadCA(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	return ca.bundle(), nil
+}
+
+// natsServerNames lists the names clients and peers reach the NATS servers by.
+// Pods are covered by a wildcard on the headless service, so scaling doesn't reissue the certificate.
+func natsServerNames(cluster *k8sv1alpha1.Cluster) []string {
+	var names []string
+	for _, service := range []string{"nats-" + cluster.GetName(), "natsd-" + cluster.GetName()} {
+		names = append(names,
+			service,
+			service+"."+cluster.GetNamespace(),
+			service+"."+cluster.GetNamespace()+".svc",
+			service+"."+cluster.GetNamespace()+".svc.cluster.local",
+		)
+	}
+
+	headless := "natsd-" + cluster.GetName()
+	names = append(names,
+		"*."+headless,
+		"*."+headless+"."+cluster.GetNamespace(),
+		"*."+headless+"."+cluster.GetNamespace()+".svc",
+		"*."+headless+"."+cluster.GetNamespace()+".svc.cluster.local",
+	)
+
+	return append(names, natsExposedNames(cluster)...)
+}
+
+func (r *ClusterReconciler) reconcileNatsConfig(ctx context.Context, cluster *k8sv1alpha1.Cluster) error {
+	if err := r.reconcileNatsServerConfig(ctx, cluster); err != nil {
+		return err
+	}
+
+	if err := r.reconcileNatsExport(ctx, cluster); err != nil {
+		return err
+	}
+
+	return r.reconcileNatsClientConfig(ctx, cluster)
+}
+
+func (r *ClusterReconciler) reconcileNatsDeployment(ctx context.Context, cluster *k8sv1alpha1.Cluster) error {
+	if err := r.reconcileNatsStatefulset(ctx, cluster); err != nil {
+		return err
+	}
+
+	return r.reconcileNatsServices(ctx, cluster)
+}
+
 func (r *ClusterReconciler) reconcileNatsServices(ctx context.Context, cluster *k8sv1alpha1.Cluster) error {
 	wantLabels := map[string]string{
 		"cluster": cluster.GetName(),
@@ -100,36 +232,13 @@ func (r *ClusterReconciler) reconcileNatsServices(ctx context.Context, cluster *
 		"cluster": cluster.GetName(),
 	}
 
+	ports := newNatsServerConfig(cluster).ports()
+
 	headlessSpec := corev1.ServiceSpec{
 		ClusterIP:                "None",
 		Selector:                 wantLabels,
 		PublishNotReadyAddresses: true,
-		Ports: []corev1.ServicePort{
-			{
-				Name:       "nats",
-				Protocol:   corev1.ProtocolTCP,
-				Port:       4222,
-				TargetPort: intstr.FromInt(4222),
-			},
-			{
-				Name:       "leafnodes",
-				Protocol:   corev1.ProtocolTCP,
-				Port:       7422,
-				TargetPort: intstr.FromInt(7422),
-			},
-			{
-				Name:       "cluster",
-				Protocol:   corev1.ProtocolTCP,
-				Port:       6222,
-				TargetPort: intstr.FromInt(6222),
-			},
-			{
-				Name:       "monitor",
-				Protocol:   corev1.ProtocolTCP,
-				Port:       8222,
-				TargetPort: intstr.FromInt(8222),
-			},
-		},
+		Ports:                    natsServicePorts(ports),
 	}
 
 	// service for discovery
@@ -156,20 +265,7 @@ func (r *ClusterReconciler) reconcileNatsServices(ctx context.Context, cluster *
 
 	userSpec := corev1.ServiceSpec{
 		Selector: wantLabels,
-		Ports: []corev1.ServicePort{
-			{
-				Name:       "nats",
-				Protocol:   corev1.ProtocolTCP,
-				Port:       4222,
-				TargetPort: intstr.FromInt(4222),
-			},
-			{
-				Name:       "leafnodes",
-				Protocol:   corev1.ProtocolTCP,
-				Port:       7422,
-				TargetPort: intstr.FromInt(7422),
-			},
-		},
+		Ports:    natsServicePorts(ports, "nats", "leafnodes"),
 	}
 
 	// service for hosts / clients
@@ -197,191 +293,261 @@ func (r *ClusterReconciler) reconcileNatsServices(ctx context.Context, cluster *
 	return nil
 }
 
+// natsSeedKinds lists the nkeys kept for a Cluster. The operator seed lives in its own Secret.
+// Missing seeds are generated, existing ones are only replaced by supplied seeds.
+var natsSeedKinds = []struct {
+	name   string
+	prefix nkeys.PrefixByte
+}{
+	{name: "operator", prefix: nkeys.PrefixByteOperator},
+	{name: "system", prefix: nkeys.PrefixByteAccount},
+	{name: "account", prefix: nkeys.PrefixByteAccount},
+	{name: "user", prefix: nkeys.PrefixByteUser},
+	{name: "auth", prefix: nkeys.PrefixByteUser},
+	{name: "system-user", prefix: nkeys.PrefixByteUser},
+	{name: "callout", prefix: nkeys.PrefixByteAccount},
+	{name: "sentinel", prefix: nkeys.PrefixByteUser},
+}
+
 func (r *ClusterReconciler) reconcileNatsCredentials(ctx context.Context, cluster *k8sv1alpha1.Cluster) error {
-	var creds corev1.Secret
-	if err := r.Client.Get(
-		ctx,
-		client.ObjectKey{Namespace: cluster.GetNamespace(), Name: cluster.NatsSeedSecret()},
-		&creds); err != nil {
-		if client.IgnoreNotFound(err) != nil {
-			return err
-		}
-	} else {
-		// secret already exists
-		return nil
-	}
+	store := r.seedStore()
 
-	operator, err := nkeys.CreateOperator()
+	seeds, err := store.clientSeeds(ctx, cluster)
 	if err != nil {
 		return err
 	}
-	operatorSeed, err := operator.Seed()
+
+	operatorSeeds, err := store.operatorSeeds(ctx, cluster)
 	if err != nil {
 		return err
 	}
 
-	system, err := nkeys.CreateAccount()
-	if err != nil {
-		return err
+	// older Clusters kept the operator seed with the others
+	if raw, ok := seeds["operator"]; ok {
+		if _, ok := operatorSeeds["operator"]; !ok {
+			operatorSeeds["operator"] = raw
+		}
+		delete(seeds, "operator")
 	}
-	systemSeed, err := system.Seed()
+
+	supplied, err := r.loadNatsSuppliedKeys(ctx, cluster)
 	if err != nil {
 		return err
 	}
 
-	account, err := nkeys.CreateAccount()
-	if err != nil {
-		return err
+	for _, seed := range natsSeedKinds {
+		target := seeds
+		if seed.name == "operator" {
+			target = operatorSeeds
+		}
+
+		if raw, ok := supplied[seed.name]; ok {
+			if err := checkSeed(raw, seed.prefix); err != nil {
+				return fmt.Errorf("supplied %s seed: %w", seed.name, err)
+			}
+			target[seed.name] = raw
+			continue
+		}
+
+		if _, ok := target[seed.name]; ok {
+			continue
+		}
+
+		kp, err := nkeys.CreatePair(seed.prefix)
+		if err != nil {
+			return err
+		}
+		raw, err := kp.Seed()
+		if err != nil {
+			return err
+		}
+		target[seed.name] = raw
 	}
-	accountSeed, err := account.Seed()
-	if err != nil {
-		return err
+
+	// the supplied operator JWT is kept next to the operator seed, where the server config reads it
+	if operatorJWT, ok := supplied[natsOperatorJWTKey]; ok {
+		operatorSeeds[natsOperatorJWTKey] = operatorJWT
+	} else {
+		delete(operatorSeeds, natsOperatorJWTKey)
 	}
 
-	user, err := nkeys.CreateUser()
-	if err != nil {
+	// save the operator seed before it is removed from the shared Secret
+	if err := store.save(ctx, cluster, cluster.NatsOperatorSeedSecret(), operatorSeeds); err != nil {
 		return err
 	}
-	userSeed, err := user.Seed()
+
+	return store.save(ctx, cluster, cluster.NatsSeedSecret(), seeds)
+}
+
+func (r *ClusterReconciler) seedStore() natsSeedStore {
+	return natsSeedStore{client: r.Client, encrypter: r.SeedEncrypter}
+}
+
+func (r *ClusterReconciler) reconcileNatsClientConfig(ctx context.Context, cluster *k8sv1alpha1.Cluster) error {
+	creds, err := r.seedStore().clientSeeds(ctx, cluster)
 	if err != nil {
 		return err
 	}
 
-	auth, err := nkeys.CreateUser()
+	ca, err := r.natsTrustBundle(ctx, cluster)
 	if err != nil {
 		return err
 	}
-	authSeed, err := auth.Seed()
+
+	accountKp, err := creds.keyPair("account")
 	if err != nil {
 		return err
 	}
 
-	secretData := map[string][]byte{
-		"operator": operatorSeed,
-		"system":   systemSeed,
-		"account":  accountSeed,
-		"user":     userSeed,
-		"auth":     authSeed,
-	}
-
-	creds.ObjectMeta = metav1.ObjectMeta{
-		Name:            cluster.NatsSeedSecret(),
-		Namespace:       cluster.GetNamespace(),
-		OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(cluster, cluster.GroupVersionKind())},
+	userKp, err := creds.keyPair("user")
+	if err != nil {
+		return err
 	}
-	creds.Data = secretData
 
-	return r.Create(ctx, &creds)
-}
-
-func (r *ClusterReconciler) reconcileNatsClientConfig(ctx context.Context, cluster *k8sv1alpha1.Cluster) error {
-	var creds corev1.Secret
-	if err := r.Client.Get(
-		ctx,
-		client.ObjectKey{Namespace: cluster.GetNamespace(), Name: cluster.NatsSeedSecret()},
-		&creds); err != nil {
+	clientStatus, err := r.reconcileNatsUserSecret(ctx, cluster, cluster.NatsClientSecret(), "client", userKp, accountKp, ca)
+	if err != nil {
 		return err
 	}
-	rawAccount, ok := creds.Data["account"]
-	if !ok {
-		return fmt.Errorf("missing account seed")
-	}
-	accountKp, err := nkeys.FromSeed(rawAccount)
+
+	systemKp, err := creds.keyPair("system")
 	if err != nil {
 		return err
 	}
 
-	rawUser, ok := creds.Data["user"]
-	if !ok {
-		return fmt.Errorf("missing user seed")
-	}
-	userKp, err := nkeys.FromSeed(rawUser)
+	systemUserKp, err := creds.keyPair("system-user")
 	if err != nil {
 		return err
 	}
 
-	user, err := newUser("client", userKp, accountKp)
+	systemStatus, err := r.reconcileNatsUserSecret(ctx, cluster, cluster.NatsSystemSecret(), "system", systemUserKp, systemKp, ca)
 	if err != nil {
 		return err
 	}
 
-	userJWT, err := user.Encode(accountKp)
+	cluster.Status.Nats.Credentials = []k8sv1alpha1.NatsCredentialsStatus{clientStatus, systemStatus}
+
+	if cluster.Spec.Nats.Managed.AuthCallout == nil {
+		return nil
+	}
+
+	calloutKp, err := creds.keyPair("callout")
 	if err != nil {
 		return err
 	}
 
-	userSeed, err := userKp.Seed()
+	authKp, err := creds.keyPair("auth")
 	if err != nil {
 		return err
 	}
 
-	userCreds, err := jwt.FormatUserConfig(userJWT, userSeed)
+	authStatus, err := r.reconcileNatsUserSecret(ctx, cluster, cluster.NatsAuthSecret(), "auth", authKp, calloutKp, ca)
 	if err != nil {
 		return err
 	}
 
-	userSecretData := map[string][]byte{
-		"user.jwt": userCreds,
-	}
+	cluster.Status.Nats.Credentials = append(cluster.Status.Nats.Credentials, authStatus)
+
+	return nil
+}
+
+// reconcileNatsUserSecret keeps a creds file for userKp, signed by accountKp, under "user.jwt"
+// next to the CA clients verify the servers with, under "ca.crt".
+// The user JWT is only re-signed when it is due for renewal, so connections using it aren't recycled
+// on every reconcile. Returns the status of the creds.
+func (r *ClusterReconciler) reconcileNatsUserSecret(
+	ctx context.Context,
+	cluster *k8sv1alpha1.Cluster,
+	secretName string,
+	name string,
+	userKp nkeys.KeyPair,
+	accountKp nkeys.KeyPair,
+	ca []byte,
+) (k8sv1alpha1.NatsCredentialsStatus, error) {
+	status := k8sv1alpha1.NatsCredentialsStatus{Secret: secretName}
 
 	userSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:            cluster.NatsClientSecret(),
+			Name:            secretName,
 			Namespace:       cluster.GetNamespace(),
 			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(cluster, cluster.GroupVersionKind())},
 		},
 	}
 
-	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, userSecret, func() error {
-		userSecret.Data = userSecretData
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, userSecret, func() error {
+		if userSecret.Data == nil {
+			userSecret.Data = make(map[string][]byte)
+		}
+		userSecret.Data["ca.crt"] = ca
+
+		var previous string
+		if creds, ok := userSecret.Data["user.jwt"]; ok {
+			previous, _ = jwt.ParseDecoratedJWT(creds)
+		}
+
+		user, err := newUser(name, userKp, accountKp)
+		if err != nil {
+			return err
+		}
+
+		userJWT, err := encodeExpiringJWT(previous, user, accountKp, natsCredentialsTTL(cluster), time.Now())
+		if err != nil {
+			return err
+		}
+
+		signed, err := jwt.DecodeUserClaims(userJWT)
+		if err != nil {
+			return err
+		}
+		status.UserPublicKey = signed.Subject
+		status.Expires = metav1.NewTime(time.Unix(signed.Expires, 0))
+
+		userSeed, err := userKp.Seed()
+		if err != nil {
+			return err
+		}
+
+		userCreds, err := jwt.FormatUserConfig(userJWT, userSeed)
+		if err != nil {
+			return err
+		}
+
+		userSecret.Data["user.jwt"] = userCreds
 		return nil
-	}); err != nil {
-		return err
-	}
+	})
 
-	return nil
+	return status, err
+}
+
+// natsCredentialsTTL returns the lifetime of the user JWTs the operator issues.
+func natsCredentialsTTL(cluster *k8sv1alpha1.Cluster) time.Duration {
+	if cluster.Spec.Nats.Managed == nil || cluster.Spec.Nats.Managed.CredentialsTTL == nil {
+		return natsDefaultCredentialsTTL
+	}
+	return cluster.Spec.Nats.Managed.CredentialsTTL.Duration
 }
 
 func (r *ClusterReconciler) reconcileNatsServerConfig(ctx context.Context, cluster *k8sv1alpha1.Cluster) error {
-	var creds corev1.Secret
-	if err := r.Client.Get(
-		ctx,
-		client.ObjectKey{Namespace: cluster.GetNamespace(), Name: cluster.NatsSeedSecret()},
-		&creds); err != nil {
+	operatorSeeds, err := r.seedStore().operatorSeeds(ctx, cluster)
+	if err != nil {
 		return err
 	}
-	rawOperator, ok := creds.Data["operator"]
-	if !ok {
-		return fmt.Errorf("missing operator seed")
-	}
-	operatorKp, err := nkeys.FromSeed(rawOperator)
+
+	creds, err := r.seedStore().clientSeeds(ctx, cluster)
 	if err != nil {
 		return err
 	}
 
-	rawSystem, ok := creds.Data["system"]
-	if !ok {
-		return fmt.Errorf("missing system seed")
-	}
-	sysKp, err := nkeys.FromSeed(rawSystem)
+	operatorKp, err := operatorSeeds.keyPair("operator")
 	if err != nil {
 		return err
 	}
 
-	rawAccount, ok := creds.Data["account"]
-	if !ok {
-		return fmt.Errorf("missing account seed")
-	}
-	accountKp, err := nkeys.FromSeed(rawAccount)
+	sysKp, err := creds.keyPair("system")
 	if err != nil {
 		return err
 	}
 
-	rawAuth, ok := creds.Data["auth"]
-	if !ok {
-		return fmt.Errorf("missing auth seed")
-	}
-	authKp, err := nkeys.FromSeed(rawAuth)
+	accountKp, err := creds.keyPair("account")
 	if err != nil {
 		return err
 	}
@@ -391,42 +557,58 @@ func (r *ClusterReconciler) reconcileNatsServerConfig(ctx context.Context, clust
 		return err
 	}
 
-	operator, err := newOperator(operatorKp, sysKp)
+	account, err := newAccount("wasmcloud", accountKp)
 	if err != nil {
 		return err
 	}
+	applyNatsAccountLimits(account, cluster.Spec.Nats.Managed.Limits)
 
-	account, err := newAccount("wasmcloud", accountKp)
+	revocations, err := pruneNatsRevocations(ctx, r.Client, cluster, time.Now())
 	if err != nil {
 		return err
 	}
-	// enable jetstream
-	account.Limits.JetStreamLimits.MemoryStorage = -1
-	account.Limits.JetStreamLimits.DiskStorage = -1
+	for user, revocation := range revocations {
+		account.RevokeAt(user, revocation.At)
+	}
 
-	operatorJWT, err := operator.Encode(operatorKp)
-	if err != nil {
+	// previously signed JWTs are reused when their claims didn't change,
+	// otherwise every reconcile would rewrite the config and trigger a reload
+	var previous corev1.ConfigMap
+	if err := r.Get(
+		ctx,
+		client.ObjectKey{Namespace: cluster.GetNamespace(), Name: "nats-" + cluster.GetName()},
+		&previous); err != nil && client.IgnoreNotFound(err) != nil {
 		return err
 	}
 
-	sysJWT, err := sysAccount.Encode(operatorKp)
+	operatorJWT, err := natsOperatorJWT(operatorSeeds[natsOperatorJWTKey], previous.Data["operator.jwt"], operatorKp, sysKp)
 	if err != nil {
 		return err
 	}
 
-	accountJWT, err := account.Encode(operatorKp)
+	sysJWT, err := encodeJWT(previous.Data["system.jwt"], sysAccount, operatorKp)
 	if err != nil {
 		return err
 	}
 
-	tmpl, err := template.New("nats-server.conf").Parse(natsConfigTemplate)
+	accountJWT, err := encodeJWT(previous.Data["account.jwt"], account, operatorKp)
 	if err != nil {
 		return err
 	}
 
-	routes := []string{}
-	for i := 0; i < int(cluster.Spec.Nats.Managed.Replicas); i++ {
-		routes = append(routes, fmt.Sprintf("nats://nats-%s-%d.natsd-%s:6222", cluster.GetName(), i, cluster.GetName()))
+	cmData := map[string]string{
+		"operator.jwt": operatorJWT,
+		"system.jwt":   sysJWT,
+		"account.jwt":  accountJWT,
+	}
+
+	if cluster.Spec.Nats.Managed.AuthCallout != nil {
+		calloutJWT, sentinelJWT, err := natsCalloutJWTs(creds, &previous, operatorKp, accountKp)
+		if err != nil {
+			return err
+		}
+		cmData["callout.jwt"] = calloutJWT
+		cmData["sentinel.jwt"] = sentinelJWT
 	}
 
 	sysPub, err := sysKp.PublicKey()
@@ -439,47 +621,36 @@ func (r *ClusterReconciler) reconcileNatsServerConfig(ctx context.Context, clust
 		return err
 	}
 
-	authPub, err := authKp.PublicKey()
+	config := newNatsServerConfig(cluster)
+	routeReplicas, err := r.natsRouteReplicas(ctx, cluster)
 	if err != nil {
 		return err
 	}
+	config.Cluster.Routes = natsRoutes(cluster, routeReplicas)
+	config.Operator = operatorJWT
+	config.SystemAccount = sysPub
+	config.ResolverPreload = map[string]string{sysPub: sysJWT}
+	config.DefaultSentinel = cmData["sentinel.jwt"]
+	config.LeafNodes.Remotes = natsLeafNodeRemotes(cluster.Spec.Nats.Managed.LeafNodeRemotes, map[string]string{
+		natsLeafNodeAccountCluster: accountPub,
+		natsLeafNodeAccountSystem:  sysPub,
+	})
 
-	data := struct {
-		Name   string
-		Routes []string
-
-		OperatorJWT string
-
-		SystemJWT string
-		SystemPub string
-
-		AccountJWT string
-		AccountPub string
-
-		AuthPub string
-	}{
-		Name:   cluster.GetName(),
-		Routes: routes,
-
-		OperatorJWT: operatorJWT,
-
-		SystemJWT: sysJWT,
-		SystemPub: sysPub,
-
-		AccountJWT: accountJWT,
-		AccountPub: accountPub,
-
-		AuthPub: authPub,
+	if config.Gateway != nil {
+		remotes, gatewaysCA, err := r.natsGatewayRemotes(ctx, cluster, cmData)
+		if err != nil {
+			return err
+		}
+		config.Gateway.Gateways = remotes
+		cmData[natsGatewaysCAKey] = string(gatewaysCA)
 	}
 
-	var b strings.Builder
-	if err := tmpl.Execute(&b, data); err != nil {
+	rendered, err := config.render(cluster.Spec.Nats.Managed.Config)
+	if err != nil {
 		return err
 	}
 
-	cmData := map[string]string{
-		"nats.conf": b.String(),
-	}
+	cmData["nats.conf"] = rendered
 
 	cm := &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
@@ -530,63 +701,145 @@ func (r *ClusterReconciler) reconcileNatsStatefulset(ctx context.Context, cluste
 			},
 		},
 	}
+	volumes = append(volumes, corev1.Volume{
+		Name: "tls",
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: cluster.NatsTLSSecret(),
+			},
+		},
+	})
+	volumes = append(volumes, corev1.Volume{
+		Name: "pid",
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	})
+
 	defaultMounts := []corev1.VolumeMount{
 		{
 			Name:      "config",
 			MountPath: "/config",
 		},
+		{
+			Name:      natsDataVolume,
+			MountPath: "/data",
+		},
+		{
+			Name:      "tls",
+			MountPath: natsTLSDir,
+			ReadOnly:  true,
+		},
+		{
+			Name:      "pid",
+			MountPath: path.Dir(natsPidFile),
+		},
+	}
+
+	if leafNodes := natsLeafNodesVolume(cluster); leafNodes != nil {
+		volumes = append(volumes, *leafNodes)
+		defaultMounts = append(defaultMounts, corev1.VolumeMount{
+			Name:      leafNodes.Name,
+			MountPath: natsLeafNodesDir,
+			ReadOnly:  true,
+		})
+	}
+
+	claims := natsVolumeClaimTemplates(cluster)
+	if len(claims) == 0 {
+		volumes = append(volumes, corev1.Volume{
+			Name: natsDataVolume,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		})
+	}
+
+	if recreating, err := r.reconcileNatsStorage(ctx, cluster, claims); err != nil || recreating {
+		return err
 	}
-	image := "nats:2.10.22-alpine"
+
+	replicas, err := r.reconcileNatsScale(ctx, cluster)
+	if err != nil {
+		return err
+	}
+
+	image := "nats:2.11.6-alpine"
 	hostContainer := corev1.Container{
 		Name:         "nats",
 		Image:        image,
-		Args:         []string{"--config", "/config/nats.conf", "--pid", "/tmp/nats.pid", "--name", "$(SERVER_NAME)"},
-		EnvFrom:      mergeEnvFromSource(cluster.Spec.Nats.Managed.EnvFrom),
-		Env:          mergeEnvVar(cluster.Spec.Nats.Managed.Env, defaultEnv),
-		VolumeMounts: mergeMounts(defaultMounts, cluster.Spec.Nats.Managed.VolumeMounts),
-		Ports: []corev1.ContainerPort{
-			{
-				Name:          "nats",
-				ContainerPort: 4222,
+		Args:         []string{"--config", "/config/nats.conf", "--pid", natsPidFile, "--name", "$(SERVER_NAME)"},
+		Env:          defaultEnv,
+		VolumeMounts: defaultMounts,
+		Lifecycle: &corev1.Lifecycle{
+			// drain clients to the other servers before stopping
+			PreStop: &corev1.LifecycleHandler{
+				Exec: &corev1.ExecAction{
+					Command: []string{"nats-server", "--signal", "ldm=" + natsPidFile},
+				},
 			},
+		},
+		Ports: newNatsServerConfig(cluster).ports(),
+	}
+
+	reloaderContainer := corev1.Container{
+		Name:  "reloader",
+		Image: natsReloaderImage,
+		// certificates are watched too, so renewals are picked up without a restart
+		Args: []string{
+			"-pid", natsPidFile,
+			"-config", "/config/nats.conf",
+			"-config", path.Join(natsTLSDir, "tls.crt"),
+			"-config", path.Join(natsTLSDir, "tls.key"),
+			"-config", path.Join(natsTLSDir, "ca.crt"),
+		},
+		VolumeMounts: []corev1.VolumeMount{
 			{
-				Name:          "leafnodes",
-				ContainerPort: 6222,
+				Name:      "config",
+				MountPath: "/config",
 			},
 			{
-				Name:          "cluster",
-				ContainerPort: 7222,
+				Name:      "tls",
+				MountPath: natsTLSDir,
+				ReadOnly:  true,
 			},
 			{
-				Name:          "monitor",
-				ContainerPort: 8222,
+				Name:      "pid",
+				MountPath: path.Dir(natsPidFile),
 			},
 		},
 	}
 
-	volumes = append(volumes, cluster.Spec.Nats.Managed.Volumes...)
+	restartConfig := newNatsRestartConfig(cluster)
+	if restartConfig.Gateways != nil {
+		var config corev1.ConfigMap
+		if err := r.Get(ctx, client.ObjectKey{Namespace: cluster.GetNamespace(), Name: "nats-" + cluster.GetName()}, &config); err != nil {
+			return err
+		}
+		restartConfig.GatewaysCA = config.Data[natsGatewaysCAKey]
+	}
 
-	podTemplate := corev1.PodTemplateSpec{
-		ObjectMeta: metav1.ObjectMeta{
-			Labels: mergeLabels(wantLabels),
-		},
-		Spec: corev1.PodSpec{
-			EnableServiceLinks:            boolPtr(false),
-			AutomountServiceAccountToken:  cluster.Spec.Nats.Managed.AutomountServiceAccountToken,
-			TerminationGracePeriodSeconds: int64Ptr(0),
-			ServiceAccountName:            cluster.Spec.Nats.Managed.ServiceAccountName,
-			Containers:                    []corev1.Container{hostContainer},
-			Volumes:                       volumes,
-		},
+	checksum, err := contentChecksum(restartConfig)
+	if err != nil {
+		return err
 	}
 
+	podTemplate := newPodTemplate(wantLabels, cluster.Spec.Nats.Managed.ReplicaSpec, cluster.Spec.Nats.Managed.ContainerSpec, hostContainer, volumes)
+	podTemplate.Annotations = map[string]string{checksumAnnotation: checksum}
+	// the reloader signals the server through its pid file
+	podTemplate.Spec.ShareProcessNamespace = boolPtr(true)
+	podTemplate.Spec.Containers = append(podTemplate.Spec.Containers, reloaderContainer)
+	podTemplate.Spec.TerminationGracePeriodSeconds = int64Ptr(int64((natsLameDuckDuration + natsShutdownBuffer).Seconds()))
+
 	spec := appsv1.StatefulSetSpec{
 		Selector: &metav1.LabelSelector{
 			MatchLabels: wantLabels,
 		},
-		Replicas:    &cluster.Spec.Nats.Managed.Replicas,
-		Template:    podTemplate,
-		ServiceName: "natsd-" + cluster.GetName(),
+		Replicas:                             &replicas,
+		Template:                             podTemplate,
+		ServiceName:                          "natsd-" + cluster.GetName(),
+		VolumeClaimTemplates:                 claims,
+		PersistentVolumeClaimRetentionPolicy: natsRetentionPolicy(cluster),
 	}
 
 	statefulset := &appsv1.StatefulSet{
@@ -599,7 +852,11 @@ func (r *ClusterReconciler) reconcileNatsStatefulset(ctx context.Context, cluste
 		Spec: spec,
 	}
 
-	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, statefulset, func() error {
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, statefulset, func() error {
+		if statefulset.GetResourceVersion() != "" {
+			// immutable, changes go through reconcileNatsStorage
+			spec.VolumeClaimTemplates = statefulset.Spec.VolumeClaimTemplates
+		}
 		statefulset.Spec = spec
 		// labels might have been modified elsewhere, so merge them
 		statefulset.SetLabels(mergeLabels(statefulset.GetLabels(), cluster.Spec.Nats.Managed.Labels, defaultLabels))
@@ -609,6 +866,88 @@ func (r *ClusterReconciler) reconcileNatsStatefulset(ctx context.Context, cluste
 	return err
 }
 
+// encodeJWT signs claims with kp, unless previous is a JWT from the same issuer with identical claims.
+func encodeJWT(previous string, claims jwt.Claims, kp nkeys.KeyPair) (string, error) {
+	if previous != "" {
+		if prev, err := jwt.Decode(previous); err == nil && sameClaims(prev, claims, kp) {
+			return previous, nil
+		}
+	}
+	return claims.Encode(kp)
+}
+
+// encodeExpiringJWT signs claims with kp to expire ttl after now, unless previous is a JWT from the
+// same issuer with identical claims that was issued for ttl and isn't due for renewal yet.
+// JWTs are renewed once two thirds of their lifetime have elapsed.
+func encodeExpiringJWT(previous string, claims jwt.Claims, kp nkeys.KeyPair, ttl time.Duration, now time.Time) (string, error) {
+	c := claims.Claims()
+	if previous != "" {
+		if prev, err := jwt.Decode(previous); err == nil {
+			pc := prev.Claims()
+			lifetime := time.Duration(pc.Expires-pc.IssuedAt) * time.Second
+			renewAt := time.Unix(pc.IssuedAt, 0).Add(lifetime * 2 / 3)
+
+			// IssuedAt is stamped at signing time, so allow some slack when comparing lifetimes
+			sameTTL := (lifetime - ttl).Abs() < time.Minute
+
+			c.Expires = pc.Expires
+			if pc.Expires > 0 && sameTTL && now.Before(renewAt) && sameClaims(prev, claims, kp) {
+				return previous, nil
+			}
+		}
+	}
+
+	c.Expires = now.Add(ttl).Unix()
+	return claims.Encode(kp)
+}
+
+// sameClaims compares claims ignoring the fields set at signing time.
+func sameClaims(signed jwt.Claims, claims jwt.Claims, kp nkeys.KeyPair) bool {
+	issuer, err := kp.PublicKey()
+	if err != nil {
+		return false
+	}
+
+	a, b := signed.Claims(), claims.Claims()
+	if a.Issuer != issuer ||
+		a.Subject != b.Subject ||
+		a.Name != b.Name ||
+		a.Audience != b.Audience ||
+		a.Expires != b.Expires ||
+		a.NotBefore != b.NotBefore {
+		return false
+	}
+
+	signedPayload, err := claimsPayload(signed)
+	if err != nil {
+		return false
+	}
+	payload, err := claimsPayload(claims)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(signedPayload, payload)
+}
+
+// claimsPayload returns the JSON payload of claims without the type and version,
+// which are only filled in at signing time.
+func claimsPayload(claims jwt.Claims) ([]byte, error) {
+	raw, err := json.Marshal(claims.Payload())
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	delete(fields, "type")
+	delete(fields, "version")
+
+	return json.Marshal(fields)
+}
+
 func newOperator(kp nkeys.KeyPair, sysKp nkeys.KeyPair) (*jwt.OperatorClaims, error) {
 	kpPub, err := kp.PublicKey()
 	if err != nil {
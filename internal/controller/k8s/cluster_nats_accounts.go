@@ -0,0 +1,89 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/jwt/v2"
+	"github.com/nats-io/nats.go"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// natsResolverDir is where the full resolver keeps account JWTs, next to the JetStream data.
+	natsResolverDir = "/data/jwt"
+
+	// servers without the account don't answer lookups, so keep the wait short
+	natsClaimsLookupTimeout = 2 * time.Second
+)
+
+// natsAccountKeys lists the server ConfigMap entries holding account JWTs served by the resolver.
+// The system account is also preloaded, as servers need it before anything can be pushed.
+var natsAccountKeys = []string{"system.jwt", "account.jwt", "callout.jwt"}
+
+// reconcileNatsAccounts pushes account JWTs to the resolver over the system account.
+// Servers share pushed JWTs through the cluster, so accounts are added or updated without a restart.
+func (r *ClusterReconciler) reconcileNatsAccounts(ctx context.Context, cluster *k8sv1alpha1.Cluster) error {
+	if r.Connections == nil {
+		return nil
+	}
+
+	var cm corev1.ConfigMap
+	if err := r.Get(
+		ctx,
+		client.ObjectKey{Namespace: cluster.GetNamespace(), Name: "nats-" + cluster.GetName()},
+		&cm); err != nil {
+		return err
+	}
+
+	nc, err := r.natsSystemConn(ctx, cluster)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range natsAccountKeys {
+		accountJWT, ok := cm.Data[key]
+		if !ok {
+			continue
+		}
+
+		if err := natsPushAccount(ctx, nc, accountJWT); err != nil {
+			return fmt.Errorf("pushing %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// natsPushAccount sends accountJWT to the resolver, unless the servers already serve it.
+func natsPushAccount(ctx context.Context, nc *nats.Conn, accountJWT string) error {
+	claims, err := jwt.DecodeAccountClaims(accountJWT)
+	if err != nil {
+		return err
+	}
+
+	if current, err := natsLookupAccount(ctx, nc, claims.Subject); err == nil && current == accountJWT {
+		return nil
+	}
+
+	log.FromContext(ctx).Info("Pushing NATS account", "account", claims.Name, "publicKey", claims.Subject)
+
+	return natsAPIRequest(ctx, nc, "$SYS.REQ.CLAIMS.UPDATE", []byte(accountJWT), nil)
+}
+
+// natsLookupAccount returns the account JWT the resolver currently serves.
+func natsLookupAccount(ctx context.Context, nc *nats.Conn, account string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, natsClaimsLookupTimeout)
+	defer cancel()
+
+	msg, err := nc.RequestWithContext(ctx, fmt.Sprintf("$SYS.REQ.ACCOUNT.%s.CLAIMS.LOOKUP", account), nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(msg.Data), nil
+}
@@ -0,0 +1,355 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nats-io/jwt/v2"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nkeys"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	"go.wasmcloud.dev/operator/internal/natsconn"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// resolverServer is an embedded NATS server running the full resolver, trusting operatorKp.
+type resolverServer struct {
+	server     *server.Server
+	operatorKp nkeys.KeyPair
+	systemKp   nkeys.KeyPair
+	systemJWT  string
+}
+
+func newResolverServer(t *testing.T) *resolverServer {
+	t.Helper()
+
+	operatorKp, err := nkeys.CreateOperator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	systemKp, err := nkeys.CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	systemPub, err := systemKp.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	operator, err := newOperator(operatorKp, systemKp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	operatorJWT, err := operator.Encode(operatorKp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	system, err := newSystemAccount(systemKp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	systemJWT, err := system.Encode(operatorKp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	config := fmt.Sprintf(`listen: "127.0.0.1:-1"
+operator: %q
+system_account: %q
+resolver: {
+  type: full
+  dir: %q
+}
+resolver_preload: {
+  %s: %q
+}
+`, operatorJWT, systemPub, filepath.Join(dir, "jwt"), systemPub, systemJWT)
+
+	configFile := filepath.Join(dir, "nats.conf")
+	if err := os.WriteFile(configFile, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := server.ProcessConfigFile(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.NoLog, opts.NoSigs = true, true
+
+	s, err := server.NewServer(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Start()
+	t.Cleanup(s.Shutdown)
+	if !s.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server didn't start")
+	}
+
+	return &resolverServer{server: s, operatorKp: operatorKp, systemKp: systemKp, systemJWT: systemJWT}
+}
+
+// systemCreds returns creds of a system account user.
+func (s *resolverServer) systemCreds(t *testing.T) []byte {
+	t.Helper()
+
+	userKp, err := nkeys.CreateUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	userPub, err := userKp.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	userSeed, err := userKp.Seed()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userJWT, err := jwt.NewUserClaims(userPub).Encode(s.systemKp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds, err := jwt.FormatUserConfig(userJWT, userSeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return creds
+}
+
+// Dial connects to the embedded server whatever the address, standing in for the Cluster Service.
+func (s *resolverServer) Dial(network string, _ string) (net.Conn, error) {
+	return net.Dial(network, s.server.Addr().String())
+}
+
+// testAccountJWT returns the JWT of an account allowing conns connections, signed by operatorKp.
+func testAccountJWT(t *testing.T, operatorKp nkeys.KeyPair, accountKp nkeys.KeyPair, conns int64) string {
+	t.Helper()
+
+	claims, err := newAccount("wasmcloud", accountKp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims.Limits.Conn = conns
+
+	accountJWT, err := claims.Encode(operatorKp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return accountJWT
+}
+
+func TestNatsPushAccount(t *testing.T) {
+	untrustedKp, err := nkeys.CreateOperator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		// the account JWTs pushed in order, by the operator the server trusts unless untrusted
+		pushes    []int64
+		untrusted bool
+		// updates sent to the resolver
+		wantUpdates int
+		// limit of the JWT the resolver serves afterwards, 0 when it serves none
+		wantConns int64
+		wantErr   string
+	}{
+		"New": {
+			pushes:      []int64{-1},
+			wantUpdates: 1,
+			wantConns:   -1,
+		},
+		"Unchanged": {
+			pushes:      []int64{-1, -1},
+			wantUpdates: 1,
+			wantConns:   -1,
+		},
+		"Changed": {
+			pushes:      []int64{-1, 10},
+			wantUpdates: 2,
+			wantConns:   10,
+		},
+		"Rejected": {
+			pushes:      []int64{-1},
+			untrusted:   true,
+			wantUpdates: 1,
+			wantErr:     "$SYS.REQ.CLAIMS.UPDATE",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newResolverServer(t)
+
+			credsFile := filepath.Join(t.TempDir(), "system.creds")
+			if err := os.WriteFile(credsFile, s.systemCreds(t), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			nc, err := nats.Connect(s.server.ClientURL(), nats.UserCredentials(credsFile))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(nc.Close)
+
+			updates, err := nc.SubscribeSync("$SYS.REQ.CLAIMS.UPDATE")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			accountKp, err := nkeys.CreateAccount()
+			if err != nil {
+				t.Fatal(err)
+			}
+			accountPub, err := accountKp.PublicKey()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			signer := s.operatorKp
+			if tc.untrusted {
+				signer = untrustedKp
+			}
+
+			var wantJWT string
+			for i, conns := range tc.pushes {
+				accountJWT := testAccountJWT(t, signer, accountKp, conns)
+				if i > 0 && conns == tc.pushes[i-1] {
+					// the same claims, as reconciles render them again
+					accountJWT = wantJWT
+				}
+
+				err = natsPushAccount(ctx, nc, accountJWT)
+				if err != nil {
+					break
+				}
+				wantJWT = accountJWT
+			}
+
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tc.wantErr)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := nc.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			pending, _, err := updates.Pending()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pending != tc.wantUpdates {
+				t.Errorf("got %d updates, want %d", pending, tc.wantUpdates)
+			}
+
+			// the full resolver answers lookups of unknown accounts with nothing
+			served, err := natsLookupAccount(ctx, nc, accountPub)
+			if tc.wantConns == 0 {
+				if err == nil && served != "" {
+					t.Errorf("the resolver should not serve the account, got %s", served)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(wantJWT, served); diff != "" {
+				t.Errorf("served jwt: -want, +got:\n%s", diff)
+			}
+			claims, err := jwt.DecodeAccountClaims(served)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.Limits.Conn != tc.wantConns {
+				t.Errorf("served connection limit %d, want %d", claims.Limits.Conn, tc.wantConns)
+			}
+		})
+	}
+}
+
+func TestReconcileNatsAccounts(t *testing.T) {
+	ctx := context.Background()
+	s := newResolverServer(t)
+
+	accountKp, err := nkeys.CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountPub, err := accountKp.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountJWT := testAccountJWT(t, s.operatorKp, accountKp, -1)
+
+	cluster := testNatsCluster(k8sv1alpha1.NatsManagedSpec{Replicas: 1})
+	objs := []client.Object{
+		cluster,
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: cluster.GetNamespace(), Name: cluster.NatsSystemSecret()},
+			Data:       map[string][]byte{"user.jwt": s.systemCreds(t)},
+		},
+		// the callout account is only rendered with auth callout
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: cluster.GetNamespace(), Name: "nats-" + cluster.GetName()},
+			Data: map[string]string{
+				"system.jwt":  s.systemJWT,
+				"account.jwt": accountJWT,
+			},
+		},
+	}
+
+	apiClient := newFakeClient(t, objs...)
+	connections := natsconn.NewManager(apiClient, nats.SetCustomDialer(s))
+	t.Cleanup(func() { connections.Remove(client.ObjectKeyFromObject(cluster)) })
+	r := &ClusterReconciler{Client: apiClient, Connections: connections}
+
+	nc, err := r.natsSystemConn(ctx, cluster)
+	if err != nil {
+		t.Fatal(err)
+	}
+	updates, err := nc.SubscribeSync("$SYS.REQ.CLAIMS.UPDATE")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		if err := r.reconcileNatsAccounts(ctx, cluster); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := nc.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	// the preloaded system account is served already, the Cluster account is pushed once
+	pending, _, err := updates.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending != 1 {
+		t.Errorf("got %d updates, want 1", pending)
+	}
+
+	served, err := natsLookupAccount(ctx, nc, accountPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(accountJWT, served); diff != "" {
+		t.Errorf("served jwt: -want, +got:\n%s", diff)
+	}
+}
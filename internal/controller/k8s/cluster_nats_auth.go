@@ -0,0 +1,390 @@
+package k8s
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/jwt/v2"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nkeys"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// servers send authorization requests for connecting clients here
+	natsAuthSubject = "$SYS.REQ.USER.AUTH"
+
+	// servers give up on a callout after 2s by default
+	natsAuthTimeout = 2 * time.Second
+
+	// natsLatticeAnnotation names the lattice a ServiceAccount connects to through auth callout.
+	natsLatticeAnnotation = "k8s.wasmcloud.dev/lattice"
+
+	serviceAccountUsernamePrefix = "system:serviceaccount:"
+)
+
+// natsCalloutJWTs returns the JWT of the callout account, which hands authentication of its users
+// to the auth user and may issue users into the Cluster account, and the JWT of the sentinel user.
+// Clients connecting without creds are given the sentinel, which can't do anything by itself
+// but sends them through the callout.
+func natsCalloutJWTs(seeds natsSeeds, previous *corev1.ConfigMap, operatorKp nkeys.KeyPair, accountKp nkeys.KeyPair) (string, string, error) {
+	calloutKp, err := seeds.keyPair("callout")
+	if err != nil {
+		return "", "", err
+	}
+
+	authKp, err := seeds.keyPair("auth")
+	if err != nil {
+		return "", "", err
+	}
+
+	sentinelKp, err := seeds.keyPair("sentinel")
+	if err != nil {
+		return "", "", err
+	}
+
+	authPub, err := authKp.PublicKey()
+	if err != nil {
+		return "", "", err
+	}
+
+	accountPub, err := accountKp.PublicKey()
+	if err != nil {
+		return "", "", err
+	}
+
+	callout, err := newAccount("callout", calloutKp)
+	if err != nil {
+		return "", "", err
+	}
+	callout.Authorization.AuthUsers.Add(authPub)
+	callout.Authorization.AllowedAccounts.Add(accountPub)
+
+	calloutJWT, err := encodeJWT(previous.Data["callout.jwt"], callout, operatorKp)
+	if err != nil {
+		return "", "", err
+	}
+
+	sentinel, err := newUser("sentinel", sentinelKp, calloutKp)
+	if err != nil {
+		return "", "", err
+	}
+	sentinel.BearerToken = true
+	sentinel.Pub.Deny.Add(">")
+	sentinel.Sub.Deny.Add(">")
+
+	// the sentinel grants nothing, and servers can't reload a new one, so it doesn't expire
+	sentinelJWT, err := encodeJWT(previous.Data["sentinel.jwt"], sentinel, calloutKp)
+	if err != nil {
+		return "", "", err
+	}
+
+	return calloutJWT, sentinelJWT, nil
+}
+
+// natsAuthCallout answers the authorization requests of a Cluster on the connection of its auth user.
+type natsAuthCallout struct {
+	client  client.Client
+	cluster types.NamespacedName
+	conn    *nats.Conn
+	sub     *nats.Subscription
+
+	config atomic.Pointer[natsAuthConfig]
+}
+
+// natsAuthConfig holds the Cluster settings a callout issues users with.
+type natsAuthConfig struct {
+	calloutKp nkeys.KeyPair
+	// public key of the account users are issued into
+	account   string
+	audiences []string
+	ttl       time.Duration
+	// subject prefix of the wadm API of the Cluster
+	wadmAPIPrefix string
+}
+
+// reconcileNatsAuthCallout keeps a responder subscribed to authorization requests while the
+// Cluster enables auth callout. Subscriptions survive reconnects, but not a new connection.
+func (r *ClusterReconciler) reconcileNatsAuthCallout(ctx context.Context, cluster *k8sv1alpha1.Cluster) error {
+	key := client.ObjectKeyFromObject(cluster)
+	spec := cluster.Spec.Nats.Managed.AuthCallout
+	if spec == nil {
+		r.stopNatsAuthCallout(key)
+		return nil
+	}
+
+	if r.Connections == nil {
+		return fmt.Errorf("no nats connections available")
+	}
+
+	seeds, err := r.seedStore().clientSeeds(ctx, cluster)
+	if err != nil {
+		return err
+	}
+
+	calloutKp, err := seeds.keyPair("callout")
+	if err != nil {
+		return err
+	}
+
+	accountKp, err := seeds.keyPair("account")
+	if err != nil {
+		return err
+	}
+
+	accountPub, err := accountKp.PublicKey()
+	if err != nil {
+		return err
+	}
+
+	config := &natsAuthConfig{
+		calloutKp:     calloutKp,
+		account:       accountPub,
+		audiences:     spec.Audiences,
+		ttl:           natsCredentialsTTL(cluster),
+		wadmAPIPrefix: cluster.WadmAPIPrefix(),
+	}
+
+	nc, err := r.Connections.AuthConn(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	r.authLock.Lock()
+	defer r.authLock.Unlock()
+
+	if callout, ok := r.authCallouts[key]; ok {
+		if callout.conn == nc && callout.sub.IsValid() {
+			callout.config.Store(config)
+			return nil
+		}
+		// the connection was replaced, its subscriptions are gone
+		_ = callout.sub.Unsubscribe()
+		delete(r.authCallouts, key)
+	}
+
+	callout := &natsAuthCallout{client: r.Client, cluster: key, conn: nc}
+	callout.config.Store(config)
+
+	sub, err := nc.Subscribe(natsAuthSubject, callout.respond)
+	if err != nil {
+		return err
+	}
+	callout.sub = sub
+
+	if r.authCallouts == nil {
+		r.authCallouts = make(map[types.NamespacedName]*natsAuthCallout)
+	}
+	r.authCallouts[key] = callout
+
+	log.FromContext(ctx).Info("Answering NATS auth callout requests")
+
+	return nil
+}
+
+// stopNatsAuthCallout stops answering authorization requests for the Cluster.
+func (r *ClusterReconciler) stopNatsAuthCallout(key types.NamespacedName) {
+	r.authLock.Lock()
+	defer r.authLock.Unlock()
+
+	if callout, ok := r.authCallouts[key]; ok {
+		_ = callout.sub.Unsubscribe()
+		delete(r.authCallouts, key)
+	}
+}
+
+// respond answers an authorization request with a user JWT, or with an error
+// when the client isn't allowed in. Reasons are only logged, clients just get rejected.
+func (c *natsAuthCallout) respond(msg *nats.Msg) {
+	logger := log.Log.WithName("nats-auth").WithValues("cluster", c.cluster)
+	config := c.config.Load()
+
+	ctx, cancel := context.WithTimeout(context.Background(), natsAuthTimeout)
+	defer cancel()
+
+	request, err := jwt.DecodeAuthorizationRequestClaims(string(msg.Data))
+	if err != nil {
+		logger.Error(err, "Invalid authorization request")
+		return
+	}
+
+	response := jwt.NewAuthorizationResponseClaims(request.UserNkey)
+	response.Audience = request.Server.ID
+
+	userJWT, err := c.authorize(ctx, config, request, time.Now())
+	if err != nil {
+		logger.Info("Rejected NATS connection", "reason", err.Error(), "host", request.ClientInformation.Host)
+		response.Error = "not authorized"
+	} else {
+		response.Jwt = userJWT
+	}
+
+	raw, err := response.Encode(config.calloutKp)
+	if err != nil {
+		logger.Error(err, "Signing authorization response")
+		return
+	}
+
+	if err := msg.Respond([]byte(raw)); err != nil {
+		logger.Error(err, "Sending authorization response")
+	}
+}
+
+// authorize validates the ServiceAccount token the client presented and returns a user JWT
+// for the key the server generated for the connection.
+func (c *natsAuthCallout) authorize(ctx context.Context, config *natsAuthConfig, request *jwt.AuthorizationRequestClaims, now time.Time) (string, error) {
+	token := request.ConnectOptions.Token
+	if token == "" {
+		return "", errors.New("no token presented")
+	}
+
+	review := &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{
+			Token:     token,
+			Audiences: config.audiences,
+		},
+	}
+	if err := c.client.Create(ctx, review); err != nil {
+		return "", err
+	}
+	if !review.Status.Authenticated {
+		return "", fmt.Errorf("token rejected: %s", review.Status.Error)
+	}
+
+	namespace, name, ok := serviceAccountName(review.Status.User.Username)
+	if !ok {
+		return "", fmt.Errorf("%s is not a ServiceAccount", review.Status.User.Username)
+	}
+
+	var serviceAccount corev1.ServiceAccount
+	if err := c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &serviceAccount); err != nil {
+		return "", err
+	}
+
+	lattice, err := c.serviceAccountLattice(ctx, &serviceAccount)
+	if err != nil {
+		return "", err
+	}
+
+	claims := jwt.NewUserClaims(request.UserNkey)
+	claims.Name = namespace + "/" + name
+	claims.Audience = config.account
+	claims.Tags.Add("serviceaccount:" + namespace + "/" + name)
+	claims.Permissions = serviceAccountPermissions(lattice, config.wadmAPIPrefix, serviceAccountInboxPrefix(namespace, name))
+
+	// connections don't outlive the token they were opened with
+	expires := now.Add(config.ttl)
+	if tokenExpires, ok := tokenExpiry(token); ok && tokenExpires.Before(expires) {
+		expires = tokenExpires
+	}
+	claims.Expires = expires.Unix()
+
+	return claims.Encode(config.calloutKp)
+}
+
+// serviceAccountLattice returns the lattice a ServiceAccount may join: the one named by its
+// annotation, otherwise the lattice named after its namespace. Either way a HostGroup of the
+// Cluster must serve the lattice in the ServiceAccount namespace.
+func (c *natsAuthCallout) serviceAccountLattice(ctx context.Context, serviceAccount *corev1.ServiceAccount) (string, error) {
+	lattice := serviceAccount.GetNamespace()
+	if annotated, ok := serviceAccount.Annotations[natsLatticeAnnotation]; ok {
+		lattice = annotated
+	}
+
+	if err := checkLatticeName(lattice); err != nil {
+		return "", err
+	}
+
+	var hostGroups k8sv1alpha1.HostGroupList
+	if err := c.client.List(ctx, &hostGroups, client.InNamespace(serviceAccount.GetNamespace())); err != nil {
+		return "", err
+	}
+
+	served := slices.ContainsFunc(hostGroups.Items, func(hostGroup k8sv1alpha1.HostGroup) bool {
+		return hostGroup.LatticeName() == lattice &&
+			hostGroup.Spec.Cluster.Namespace == c.cluster.Namespace &&
+			hostGroup.Spec.Cluster.Name == c.cluster.Name
+	})
+	if !served {
+		return "", fmt.Errorf("lattice %q is not served in namespace %s", lattice, serviceAccount.GetNamespace())
+	}
+
+	return lattice, nil
+}
+
+// serviceAccountPermissions lets workloads make wRPC calls on their lattice and drive it through
+// the wadm API under wadmAPIPrefix. Control interface, events and the lattice KV buckets are left
+// to hosts and wadm. Replies come in on inboxPrefix only, so users can't read each other's.
+func serviceAccountPermissions(lattice string, wadmAPIPrefix string, inboxPrefix string) jwt.Permissions {
+	pub := []string{
+		lattice + ".>",
+		wadmAPIPrefix + "." + lattice + ".>",
+	}
+
+	sub := []string{
+		lattice + ".>",
+		inboxPrefix + ".>",
+	}
+
+	return jwt.Permissions{
+		Pub:  jwt.Permission{Allow: pub},
+		Sub:  jwt.Permission{Allow: sub},
+		Resp: &jwt.ResponsePermission{MaxMsgs: 1},
+	}
+}
+
+// serviceAccountInboxPrefix returns the inbox prefix a ServiceAccount must connect with,
+// e.g. with nats.CustomInboxPrefix. Namespaces and names never contain "_", which keeps
+// prefixes apart, and dots of the name are replaced to keep the prefix a single token.
+func serviceAccountInboxPrefix(namespace string, name string) string {
+	return "_INBOX_" + namespace + "_" + strings.ReplaceAll(name, ".", "_")
+}
+
+// serviceAccountName splits a "system:serviceaccount:<namespace>:<name>" username.
+func serviceAccountName(username string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(username, serviceAccountUsernamePrefix)
+	if !ok {
+		return "", "", false
+	}
+
+	namespace, name, ok := strings.Cut(rest, ":")
+	if !ok || namespace == "" || name == "" || strings.Contains(name, ":") {
+		return "", "", false
+	}
+
+	return namespace, name, true
+}
+
+// tokenExpiry reads the expiry of a token the TokenReview already verified.
+func tokenExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var claims struct {
+		Expires int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(raw, &claims); err != nil || claims.Expires == 0 {
+		return time.Time{}, false
+	}
+
+	return time.Unix(claims.Expires, 0), true
+}
@@ -0,0 +1,297 @@
+package k8s
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nats-io/jwt/v2"
+	"github.com/nats-io/nkeys"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestServiceAccountName(t *testing.T) {
+	cases := map[string]struct {
+		username  string
+		namespace string
+		name      string
+		ok        bool
+	}{
+		"ServiceAccount": {
+			username:  "system:serviceaccount:team-a:builder",
+			namespace: "team-a",
+			name:      "builder",
+			ok:        true,
+		},
+		"User": {
+			username: "jane@example.com",
+		},
+		"MissingName": {
+			username: "system:serviceaccount:team-a",
+		},
+		"ExtraSegments": {
+			username: "system:serviceaccount:team-a:builder:extra",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			namespace, saName, ok := serviceAccountName(tc.username)
+			got := []interface{}{namespace, saName, ok}
+			want := []interface{}{tc.namespace, tc.name, tc.ok}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("serviceAccountName(%q): -want, +got:\n%s", tc.username, diff)
+			}
+		})
+	}
+}
+
+func TestNatsCalloutJWTs(t *testing.T) {
+	seeds := natsSeeds{}
+	keys := map[string]nkeys.KeyPair{}
+	for _, seed := range natsSeedKinds {
+		kp, err := nkeys.CreatePair(seed.prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, err := kp.Seed()
+		if err != nil {
+			t.Fatal(err)
+		}
+		seeds[seed.name] = raw
+		keys[seed.name] = kp
+	}
+
+	calloutJWT, sentinelJWT, err := natsCalloutJWTs(seeds, &corev1.ConfigMap{}, keys["operator"], keys["account"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	callout, err := jwt.DecodeAccountClaims(calloutJWT)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authPub, _ := keys["auth"].PublicKey()
+	accountPub, _ := keys["account"].PublicKey()
+	if diff := cmp.Diff(jwt.StringList{authPub}, callout.Authorization.AuthUsers); diff != "" {
+		t.Errorf("auth users: -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff(jwt.StringList{accountPub}, callout.Authorization.AllowedAccounts); diff != "" {
+		t.Errorf("allowed accounts: -want, +got:\n%s", diff)
+	}
+
+	sentinel, err := jwt.DecodeUserClaims(sentinelJWT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sentinel.BearerToken {
+		t.Errorf("sentinel should be a bearer token")
+	}
+	if diff := cmp.Diff(callout.Subject, sentinel.Issuer); diff != "" {
+		t.Errorf("sentinel issuer: -want, +got:\n%s", diff)
+	}
+	if !sentinel.Pub.Deny.Contains(">") || !sentinel.Sub.Deny.Contains(">") {
+		t.Errorf("sentinel should not be allowed anything: %+v", sentinel.Permissions)
+	}
+}
+
+func TestServiceAccountLattice(t *testing.T) {
+	cluster := types.NamespacedName{Namespace: "wasmcloud-system", Name: "wasmcloud"}
+	hostGroup := func(namespace string, lattice string) *k8sv1alpha1.HostGroup {
+		return &k8sv1alpha1.HostGroup{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "hosts-" + lattice},
+			Spec: k8sv1alpha1.HostGroupSpec{
+				Lattice: lattice,
+				Cluster: corev1.ObjectReference{Namespace: cluster.Namespace, Name: cluster.Name},
+			},
+		}
+	}
+
+	cases := map[string]struct {
+		namespace   string
+		annotation  string
+		hostGroups  []client.Object
+		wantLattice string
+		wantErr     bool
+	}{
+		"Namespace": {
+			namespace:   "team-a",
+			hostGroups:  []client.Object{hostGroup("team-a", "team-a")},
+			wantLattice: "team-a",
+		},
+		"NamespaceNotServed": {
+			namespace: "default",
+			wantErr:   true,
+		},
+		"NamespaceServedElsewhere": {
+			namespace:  "team-a",
+			hostGroups: []client.Object{hostGroup("team-b", "team-a")},
+			wantErr:    true,
+		},
+		"ReservedNamespace": {
+			namespace:  "wasmbus",
+			hostGroups: []client.Object{hostGroup("wasmbus", "wasmbus")},
+			wantErr:    true,
+		},
+		"Annotation": {
+			namespace:   "team-a",
+			annotation:  "shared",
+			hostGroups:  []client.Object{hostGroup("team-a", "shared")},
+			wantLattice: "shared",
+		},
+		"AnnotationNotServed": {
+			namespace:  "team-a",
+			annotation: "shared",
+			hostGroups: []client.Object{hostGroup("team-a", "team-a")},
+			wantErr:    true,
+		},
+		"ReservedAnnotation": {
+			namespace:  "team-a",
+			annotation: "wadm",
+			hostGroups: []client.Object{hostGroup("team-a", "wadm")},
+			wantErr:    true,
+		},
+		"WildcardAnnotation": {
+			namespace:  "team-a",
+			annotation: "*",
+			wantErr:    true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			serviceAccount := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: tc.namespace, Name: "builder"}}
+			if tc.annotation != "" {
+				serviceAccount.Annotations = map[string]string{natsLatticeAnnotation: tc.annotation}
+			}
+
+			callout := &natsAuthCallout{client: newFakeClient(t, tc.hostGroups...), cluster: cluster}
+			lattice, err := callout.serviceAccountLattice(context.Background(), serviceAccount)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got lattice %q", lattice)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.wantLattice, lattice); diff != "" {
+				t.Errorf("lattice: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestServiceAccountPermissions(t *testing.T) {
+	permissions := serviceAccountPermissions("team-a", "edge.wadm", serviceAccountInboxPrefix("team-a", "api.v2"))
+
+	cases := map[string]struct {
+		subject string
+		pub     bool
+		sub     bool
+	}{
+		"WRPC": {
+			subject: "team-a.wasi-http.default.wrpc.0.0.1.wasi:http/incoming-handler.handle",
+			pub:     true,
+			sub:     true,
+		},
+		"WadmAPI": {
+			subject: "edge.wadm.team-a.model.list",
+			pub:     true,
+		},
+		"OwnInbox": {
+			subject: "_INBOX_team-a_api_v2.abc.1",
+			sub:     true,
+		},
+		"DefaultWadmAPI": {
+			subject: "wadm.api.team-a.model.list",
+		},
+		"OtherLattice": {
+			subject: "team-b.wasi-http.default.wrpc.0.0.1.wasi:http/incoming-handler.handle",
+		},
+		"ControlInterface": {
+			subject: "wasmbus.ctl.v1.team-a.host.ping",
+		},
+		"Events": {
+			subject: "wasmbus.evt.team-a.host_heartbeat",
+		},
+		"LegacyRPC": {
+			subject: "wasmbus.rpc.team-a.component.default",
+		},
+		"LatticeData": {
+			subject: "$KV.LATTICEDATA_team-a.CLAIMS_component",
+		},
+		"ConfigData": {
+			subject: "$KV.CONFIGDATA_team-a.config",
+		},
+		"SharedInbox": {
+			subject: "_INBOX.abc.1",
+		},
+		"OtherInbox": {
+			subject: "_INBOX_team-a_api.abc.1",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := subjectAllowed(permissions.Pub, tc.subject); got != tc.pub {
+				t.Errorf("publish on %s: got %v, want %v", tc.subject, got, tc.pub)
+			}
+			if got := subjectAllowed(permissions.Sub, tc.subject); got != tc.sub {
+				t.Errorf("subscribe on %s: got %v, want %v", tc.subject, got, tc.sub)
+			}
+		})
+	}
+}
+
+func TestServiceAccountInboxPrefix(t *testing.T) {
+	cases := map[string]struct {
+		namespace string
+		name      string
+		want      string
+	}{
+		"Plain": {
+			namespace: "team-a",
+			name:      "api",
+			want:      "_INBOX_team-a_api",
+		},
+		"DottedName": {
+			namespace: "team-a",
+			name:      "api.v2",
+			want:      "_INBOX_team-a_api_v2",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, serviceAccountInboxPrefix(tc.namespace, tc.name)); diff != "" {
+				t.Errorf("inbox prefix: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+// subjectAllowed reports whether a subject matches one of the allowed subjects, wildcards included.
+func subjectAllowed(permission jwt.Permission, subject string) bool {
+	return slices.ContainsFunc(permission.Allow, func(allowed string) bool {
+		want := strings.Split(allowed, ".")
+		got := strings.Split(subject, ".")
+		for i, token := range want {
+			if token == ">" {
+				return len(got) > i
+			}
+			if i >= len(got) || (token != "*" && token != got[i]) {
+				return false
+			}
+		}
+		return len(want) == len(got)
+	})
+}
@@ -0,0 +1,349 @@
+package k8s
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Listeners of the managed servers. Container and Service ports are derived from the server config,
+// which is built with these.
+const (
+	natsClientPort   = 4222
+	natsClusterPort  = 6222
+	natsGatewayPort  = 7222
+	natsLeafNodePort = 7422
+	natsMonitorPort  = 8222
+)
+
+// natsManagedConfigKeys are the settings of the server config the operator owns, lowercased and
+// dotted by nesting, along with the aliases NATS accepts for them. Overrides can't set them,
+// nor replace the objects holding them. Authentication is included: every client authenticates
+// through the operator trust chain, other users or accounts would bypass it.
+var natsManagedConfigKeys = []string{
+	"port", "listen", "net", "host", "http_port", "http", "https_port", "https", "monitor_port",
+	"tls", "client_advertise", "server_name",
+	"lame_duck_duration", "lame_duck_grace_period",
+	"operator", "operators", "system_account", "system", "resolver", "resolver_preload", "default_sentinel",
+	"trusted", "trusted_keys", "accounts", "authorization", "no_auth_user",
+	"jetstream.domain", "jetstream.store_dir", "jetstream.store", "jetstream.sd",
+	"leafnodes.port", "leafnodes.listen", "leafnodes.host", "leafnodes.net", "leafnodes.tls", "leafnodes.remotes",
+	"leafnodes.advertise", "leafnodes.no_advertise", "leafnodes.authorization", "leaf",
+	"gateway.name", "gateway.port", "gateway.listen", "gateway.host", "gateway.net", "gateway.tls", "gateway.gateways",
+	"gateway.advertise", "gateway.authorization",
+	"cluster.name", "cluster.port", "cluster.listen", "cluster.host", "cluster.net", "cluster.tls", "cluster.routes",
+	"cluster.no_advertise", "cluster.authorization",
+}
+
+// natsServerConfig models the server config. It is rendered as JSON, which NATS reads as its
+// config format, after merging the Cluster overrides on top.
+type natsServerConfig struct {
+	Port                int                 `json:"port"`
+	HTTPPort            int                 `json:"http_port"`
+	TLS                 natsTLSConfig       `json:"tls"`
+	LameDuckDuration    string              `json:"lame_duck_duration"`
+	LameDuckGracePeriod string              `json:"lame_duck_grace_period"`
+	ClientAdvertise     string              `json:"client_advertise,omitempty"`
+	Operator            string              `json:"operator"`
+	SystemAccount       string              `json:"system_account"`
+	Resolver            natsResolverConfig  `json:"resolver"`
+	ResolverPreload     map[string]string   `json:"resolver_preload"`
+	DefaultSentinel     string              `json:"default_sentinel,omitempty"`
+	JetStream           natsJetStreamConfig `json:"jetstream"`
+	LeafNodes           natsLeafNodesConfig `json:"leafnodes"`
+	Gateway             *natsGateway        `json:"gateway,omitempty"`
+	Cluster             natsClusterConfig   `json:"cluster"`
+}
+
+type natsTLSConfig struct {
+	// "auto" for clients, true for leafnodes
+	HandshakeFirst interface{} `json:"handshake_first,omitempty"`
+	CertFile       string      `json:"cert_file"`
+	KeyFile        string      `json:"key_file"`
+	CAFile         string      `json:"ca_file"`
+	// peers must present a certificate signed by the CA. Clients and leafnodes authenticate with JWTs instead.
+	Verify bool `json:"verify,omitempty"`
+}
+
+type natsResolverConfig struct {
+	Type        string `json:"type"`
+	Dir         string `json:"dir"`
+	AllowDelete bool   `json:"allow_delete"`
+	Interval    string `json:"interval"`
+	Timeout     string `json:"timeout"`
+}
+
+type natsJetStreamConfig struct {
+	Domain   string `json:"domain"`
+	StoreDir string `json:"store_dir"`
+}
+
+type natsLeafNodesConfig struct {
+	Advertise   string        `json:"advertise,omitempty"`
+	NoAdvertise bool          `json:"no_advertise,omitempty"`
+	Port        int           `json:"port"`
+	Remotes     []natsRemote  `json:"remotes,omitempty"`
+	TLS         natsTLSConfig `json:"tls"`
+}
+
+type natsClusterConfig struct {
+	Name        string        `json:"name"`
+	Port        int           `json:"port"`
+	NoAdvertise bool          `json:"no_advertise"`
+	TLS         natsTLSConfig `json:"tls"`
+	Routes      []string      `json:"routes"`
+}
+
+// natsRoutes lists the route URLs of the first replicas servers.
+func natsRoutes(cluster *k8sv1alpha1.Cluster, replicas int32) []string {
+	routes := []string{}
+	for i := 0; i < int(replicas); i++ {
+		routes = append(routes,
+			fmt.Sprintf("nats://nats-%s-%d.natsd-%s:%d", cluster.GetName(), i, cluster.GetName(), natsClusterPort))
+	}
+	return routes
+}
+
+// newNatsServerConfig builds the server config of a Cluster, short of the trust chain and the
+// leafnode and gateway remotes, which need the Cluster keys and other objects.
+func newNatsServerConfig(cluster *k8sv1alpha1.Cluster) *natsServerConfig {
+	tls := natsTLSConfig{
+		CertFile: path.Join(natsTLSDir, "tls.crt"),
+		KeyFile:  path.Join(natsTLSDir, "tls.key"),
+		CAFile:   path.Join(natsTLSDir, "ca.crt"),
+	}
+
+	config := &natsServerConfig{
+		Port:                natsClientPort,
+		HTTPPort:            natsMonitorPort,
+		TLS:                 tls,
+		LameDuckDuration:    natsLameDuckDuration.String(),
+		LameDuckGracePeriod: natsLameDuckGracePeriod.String(),
+		ClientAdvertise:     natsClientAdvertise(cluster),
+		Resolver: natsResolverConfig{
+			Type:        "full",
+			Dir:         natsResolverDir,
+			AllowDelete: true,
+			Interval:    "2m",
+			Timeout:     "5s",
+		},
+		JetStream: natsJetStreamConfig{
+			Domain:   cluster.JetStreamDomain(),
+			StoreDir: "/data",
+		},
+		LeafNodes: natsLeafNodesConfig{
+			Advertise: natsLeafNodeAdvertise(cluster),
+			Port:      natsLeafNodePort,
+			TLS:       tls,
+		},
+		Cluster: natsClusterConfig{
+			Name:        cluster.GetName(),
+			Port:        natsClusterPort,
+			NoAdvertise: true,
+			TLS:         tls,
+			Routes:      []string{},
+		},
+	}
+	config.LeafNodes.NoAdvertise = config.LeafNodes.Advertise == ""
+	config.Cluster.TLS.Verify = true
+
+	if natsHandshakeFirst(cluster) {
+		config.TLS.HandshakeFirst = "auto"
+		config.LeafNodes.TLS.HandshakeFirst = true
+	}
+
+	config.Cluster.Routes = natsRoutes(cluster, cluster.Spec.Nats.Managed.Replicas)
+
+	if cluster.Spec.Nats.Managed.Gateways != nil {
+		gatewayTLS := tls
+		gatewayTLS.CAFile = path.Join("/config", natsGatewaysCAKey)
+		gatewayTLS.Verify = true
+		config.Gateway = &natsGateway{
+			Name:      cluster.GetName(),
+			Port:      natsGatewayPort,
+			Advertise: natsGatewayAdvertise(cluster),
+			TLS:       gatewayTLS,
+			Gateways:  []natsGatewayRemote{},
+		}
+	}
+
+	return config
+}
+
+// ports lists the listeners of the config, named like the container and Service ports.
+func (c *natsServerConfig) ports() []corev1.ContainerPort {
+	ports := []corev1.ContainerPort{
+		{Name: "nats", ContainerPort: int32(c.Port)},
+		{Name: "leafnodes", ContainerPort: int32(c.LeafNodes.Port)},
+		{Name: "cluster", ContainerPort: int32(c.Cluster.Port)},
+	}
+	if c.Gateway != nil {
+		ports = append(ports, corev1.ContainerPort{Name: "gateways", ContainerPort: int32(c.Gateway.Port)})
+	}
+	return append(ports, corev1.ContainerPort{Name: "monitor", ContainerPort: int32(c.HTTPPort)})
+}
+
+// natsServicePorts maps container ports to Service ports, keeping the named ones when names are given.
+func natsServicePorts(ports []corev1.ContainerPort, names ...string) []corev1.ServicePort {
+	servicePorts := []corev1.ServicePort{}
+	for _, port := range ports {
+		if len(names) > 0 && !slices.Contains(names, port.Name) {
+			continue
+		}
+		servicePorts = append(servicePorts, corev1.ServicePort{
+			Name:       port.Name,
+			Protocol:   corev1.ProtocolTCP,
+			Port:       port.ContainerPort,
+			TargetPort: intstr.FromInt32(port.ContainerPort),
+		})
+	}
+	return servicePorts
+}
+
+func (c *natsServerConfig) validate() error {
+	seen := map[int32]string{}
+	for _, port := range c.ports() {
+		if port.ContainerPort < 1 || port.ContainerPort > 65535 {
+			return fmt.Errorf("%s port %d out of range", port.Name, port.ContainerPort)
+		}
+		if other, ok := seen[port.ContainerPort]; ok {
+			return fmt.Errorf("%s and %s listen on the same port %d", other, port.Name, port.ContainerPort)
+		}
+		seen[port.ContainerPort] = port.Name
+	}
+
+	if c.Operator == "" || c.SystemAccount == "" {
+		return errors.New("operator and system account are required")
+	}
+
+	if c.Gateway != nil && c.Gateway.Name != c.Cluster.Name {
+		return fmt.Errorf("gateway %s must be named after the cluster %s", c.Gateway.Name, c.Cluster.Name)
+	}
+
+	return nil
+}
+
+// render validates the config and merges overrides on top: objects are merged key by key,
+// null removes a setting and other values replace the rendered ones.
+func (c *natsServerConfig) render(overrides *runtime.RawExtension) (string, error) {
+	if err := c.validate(); err != nil {
+		return "", err
+	}
+
+	raw, err := marshalNatsConfig(c)
+	if err != nil {
+		return "", err
+	}
+
+	if overrides != nil && len(overrides.Raw) > 0 {
+		var doc, patch map[string]interface{}
+		if err := unmarshalNatsConfig(raw, &doc); err != nil {
+			return "", err
+		}
+		if err := unmarshalNatsConfig(overrides.Raw, &patch); err != nil {
+			return "", fmt.Errorf("config overrides must be an object: %w", err)
+		}
+
+		if err := checkNatsConfigOverrides(patch, ""); err != nil {
+			return "", err
+		}
+		mergeNatsConfig(doc, patch)
+
+		if raw, err = marshalNatsConfig(doc); err != nil {
+			return "", err
+		}
+
+		// overrides may only add settings, the modelled ones must keep their types
+		var merged natsServerConfig
+		if err := json.Unmarshal(raw, &merged); err != nil {
+			return "", fmt.Errorf("config overrides: %w", err)
+		}
+	}
+
+	var b bytes.Buffer
+	if err := json.Indent(&b, raw, "", "  "); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// marshalNatsConfig encodes without escaping HTML characters, which may appear in URLs.
+func marshalNatsConfig(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSpace(b.Bytes()), nil
+}
+
+// unmarshalNatsConfig keeps numbers as they were written, so sizes don't turn into floats.
+func unmarshalNatsConfig(raw []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// checkNatsConfigOverrides rejects overrides of the settings the operator owns.
+// NATS reads keys case-insensitively, so they are compared lowercased.
+func checkNatsConfigOverrides(patch map[string]interface{}, prefix string) error {
+	for key, value := range patch {
+		name := prefix + strings.ToLower(key)
+		if slices.Contains(natsManagedConfigKeys, name) {
+			return fmt.Errorf("config overrides: %s is managed by the operator", name)
+		}
+
+		if nested, ok := value.(map[string]interface{}); ok {
+			if err := checkNatsConfigOverrides(nested, name+"."); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// replacing or removing an object would drop the managed settings it holds
+		for _, managed := range natsManagedConfigKeys {
+			if strings.HasPrefix(managed, name+".") {
+				return fmt.Errorf("config overrides: %s holds settings managed by the operator, set its keys instead", name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// mergeNatsConfig merges patch into doc, matching keys case-insensitively like NATS does.
+func mergeNatsConfig(doc map[string]interface{}, patch map[string]interface{}) {
+	for key, value := range patch {
+		for existing := range doc {
+			if strings.EqualFold(existing, key) {
+				key = existing
+				break
+			}
+		}
+
+		if value == nil {
+			delete(doc, key)
+			continue
+		}
+
+		nested, isObject := value.(map[string]interface{})
+		current, hasObject := doc[key].(map[string]interface{})
+		if isObject && hasObject {
+			mergeNatsConfig(current, nested)
+			continue
+		}
+
+		doc[key] = value
+	}
+}
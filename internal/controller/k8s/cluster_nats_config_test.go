@@ -0,0 +1,262 @@
+package k8s
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestNatsServerConfigPorts(t *testing.T) {
+	cases := map[string]struct {
+		managed k8sv1alpha1.NatsManagedSpec
+		want    []corev1.ContainerPort
+	}{
+		"Default": {
+			managed: k8sv1alpha1.NatsManagedSpec{Replicas: 3},
+			want: []corev1.ContainerPort{
+				{Name: "nats", ContainerPort: 4222},
+				{Name: "leafnodes", ContainerPort: 7422},
+				{Name: "cluster", ContainerPort: 6222},
+				{Name: "monitor", ContainerPort: 8222},
+			},
+		},
+		"Gateways": {
+			managed: k8sv1alpha1.NatsManagedSpec{Replicas: 3, Gateways: &k8sv1alpha1.NatsGatewaysSpec{}},
+			want: []corev1.ContainerPort{
+				{Name: "nats", ContainerPort: 4222},
+				{Name: "leafnodes", ContainerPort: 7422},
+				{Name: "cluster", ContainerPort: 6222},
+				{Name: "gateways", ContainerPort: 7222},
+				{Name: "monitor", ContainerPort: 8222},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			config := newNatsServerConfig(testNatsCluster(tc.managed))
+			if diff := cmp.Diff(tc.want, config.ports()); diff != "" {
+				t.Errorf("ports: -want, +got:\n%s", diff)
+			}
+
+			// the listeners of the rendered config are the ones exposed
+			config.Operator, config.SystemAccount = "OPERATOR", "ASYSTEM"
+			rendered, err := config.render(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got natsServerConfig
+			if err := json.Unmarshal([]byte(rendered), &got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got.ports()); diff != "" {
+				t.Errorf("rendered ports: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNatsServerConfigRender(t *testing.T) {
+	cases := map[string]struct {
+		overrides string
+		want      map[string]interface{}
+		wantErr   bool
+	}{
+		"Settings": {
+			overrides: `{"max_payload": 8388608, "write_deadline": "10s", "ping_interval": "20s"}`,
+			want: map[string]interface{}{
+				"max_payload":    json.Number("8388608"),
+				"write_deadline": "10s",
+				"ping_interval":  "20s",
+			},
+		},
+		"NestedMerge": {
+			overrides: `{"Cluster": {"pool_size": 5}, "jetstream": {"max_file_store": "10G"}}`,
+			want: map[string]interface{}{
+				"cluster.pool_size":        json.Number("5"),
+				"cluster.name":             "wasmcloud",
+				"jetstream.max_file_store": "10G",
+				"jetstream.store_dir":      "/data",
+			},
+		},
+		"Remove": {
+			overrides: `{"jetstream": {"max_file_store": null}, "debug": null}`,
+			want: map[string]interface{}{
+				"jetstream.max_file_store": nil,
+				"jetstream.store_dir":      "/data",
+			},
+		},
+		"ManagedSetting": {
+			overrides: `{"port": 4333}`,
+			wantErr:   true,
+		},
+		"ManagedNestedSettingAnyCase": {
+			overrides: `{"Cluster": {"Routes": []}}`,
+			wantErr:   true,
+		},
+		"ManagedObjectReplaced": {
+			overrides: `{"leafnodes": "off"}`,
+			wantErr:   true,
+		},
+		"ManagedObjectRemoved": {
+			overrides: `{"jetstream": null}`,
+			wantErr:   true,
+		},
+		"ManagedAlias": {
+			overrides: `{"jetstream": {"store": "/tmp"}}`,
+			wantErr:   true,
+		},
+		"ListenerAlias": {
+			overrides: `{"net": "127.0.0.1"}`,
+			wantErr:   true,
+		},
+		"ServerName": {
+			overrides: `{"server_name": "other"}`,
+			wantErr:   true,
+		},
+		"Accounts": {
+			overrides: `{"accounts": {"OPEN": {"users": [{"user": "anyone", "password": "anyone"}]}}}`,
+			wantErr:   true,
+		},
+		"Authorization": {
+			overrides: `{"authorization": {"user": "admin", "password": "admin"}}`,
+			wantErr:   true,
+		},
+		"NoAuthUser": {
+			overrides: `{"no_auth_user": "anyone"}`,
+			wantErr:   true,
+		},
+		"RouteAuthorization": {
+			overrides: `{"cluster": {"authorization": {"user": "route", "password": "route"}}}`,
+			wantErr:   true,
+		},
+		"LeafNodeAuthorization": {
+			overrides: `{"LeafNodes": {"Authorization": {"user": "leaf", "password": "leaf"}}}`,
+			wantErr:   true,
+		},
+		"TrustedKeys": {
+			overrides: `{"trusted_keys": ["OOTHER"]}`,
+			wantErr:   true,
+		},
+		"NotAnObject": {
+			overrides: `["max_payload"]`,
+			wantErr:   true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			config := newNatsServerConfig(testNatsCluster(k8sv1alpha1.NatsManagedSpec{Replicas: 1}))
+			config.Operator, config.SystemAccount = "OPERATOR", "ASYSTEM"
+
+			rendered, err := config.render(&runtime.RawExtension{Raw: []byte(tc.overrides)})
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got:\n%s", rendered)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var doc map[string]interface{}
+			if err := unmarshalNatsConfig([]byte(rendered), &doc); err != nil {
+				t.Fatal(err)
+			}
+
+			got := map[string]interface{}{}
+			for key := range tc.want {
+				got[key] = lookupNatsConfig(doc, key)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("settings: -want, +got:\n%s", diff)
+			}
+			if doc["operator"] != "OPERATOR" {
+				t.Errorf("overrides dropped the rendered settings:\n%s", rendered)
+			}
+		})
+	}
+}
+
+func TestNatsServerConfigTLS(t *testing.T) {
+	serverTLS := func(ca string, verify bool) map[string]interface{} {
+		block := map[string]interface{}{
+			"cert_file": "/etc/nats-tls/tls.crt",
+			"key_file":  "/etc/nats-tls/tls.key",
+			"ca_file":   ca,
+		}
+		if verify {
+			block["verify"] = true
+		}
+		return block
+	}
+
+	config := newNatsServerConfig(testNatsCluster(k8sv1alpha1.NatsManagedSpec{
+		Replicas: 3,
+		Gateways: &k8sv1alpha1.NatsGatewaysSpec{},
+	}))
+	config.Operator, config.SystemAccount = "OPERATOR", "ASYSTEM"
+
+	// overrides can't weaken TLS
+	rendered, err := config.render(&runtime.RawExtension{Raw: []byte(`{"cluster": {"tls": {"verify": false}}}`)})
+	if err == nil {
+		t.Errorf("tls overrides should be rejected, got:\n%s", rendered)
+	}
+
+	rendered, err = config.render(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]interface{}
+	if err := unmarshalNatsConfig([]byte(rendered), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	// clients and leafnodes authenticate with JWTs, servers of the Cluster and remote gateways
+	// with certificates signed by the CA
+	want := map[string]interface{}{
+		"tls":           serverTLS("/etc/nats-tls/ca.crt", false),
+		"leafnodes.tls": serverTLS("/etc/nats-tls/ca.crt", false),
+		"cluster.tls":   serverTLS("/etc/nats-tls/ca.crt", true),
+		"gateway.tls":   serverTLS("/config/gateways-ca.crt", true),
+	}
+	got := map[string]interface{}{}
+	for key := range want {
+		got[key] = lookupNatsConfig(doc, key)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("tls: -want, +got:\n%s", diff)
+	}
+}
+
+func TestNatsServerConfigValidate(t *testing.T) {
+	config := newNatsServerConfig(testNatsCluster(k8sv1alpha1.NatsManagedSpec{Replicas: 1}))
+	config.Operator, config.SystemAccount = "OPERATOR", "ASYSTEM"
+	if err := config.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	config.LeafNodes.Port = config.Cluster.Port
+	if err := config.validate(); err == nil {
+		t.Error("listeners sharing a port should be rejected")
+	}
+}
+
+// lookupNatsConfig returns the value of a dotted key, nil when missing.
+func lookupNatsConfig(doc map[string]interface{}, key string) interface{} {
+	var current interface{} = doc
+	for _, part := range strings.Split(key, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = object[part]
+	}
+	return current
+}
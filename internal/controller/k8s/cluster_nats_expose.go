@@ -0,0 +1,303 @@
+package k8s
+
+import (
+	"context"
+	"net"
+	"slices"
+	"strconv"
+
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const natsExposeTLSRoute = "TLSRoute"
+
+// Gateway API types are handled as unstructured objects, like cert-manager ones,
+// so the operator doesn't depend on the Gateway API unless a Cluster uses it.
+var tlsRouteGVK = schema.GroupVersionKind{
+	Group:   "gateway.networking.k8s.io",
+	Version: "v1alpha2",
+	Kind:    "TLSRoute",
+}
+
+// natsTLSRoutes maps the TLSRoutes of a Cluster to the port they route to.
+func natsTLSRoutes(cluster *k8sv1alpha1.Cluster) map[string]int32 {
+	return map[string]int32{
+		"nats-" + cluster.GetName() + "-client":    natsClientPort,
+		"nats-" + cluster.GetName() + "-leafnodes": natsLeafNodePort,
+	}
+}
+
+func natsExternalService(cluster *k8sv1alpha1.Cluster) string {
+	return "nats-" + cluster.GetName() + "-external"
+}
+
+// reconcileNatsExposure publishes the client, leafnode and gateway ports outside Kubernetes and records
+// the addresses they are reachable at. It runs before the certificate and config are built,
+// which both use the addresses.
+func (r *ClusterReconciler) reconcileNatsExposure(ctx context.Context, cluster *k8sv1alpha1.Cluster) error {
+	expose := cluster.Spec.Nats.Managed.Expose
+
+	if expose == nil || expose.Type == natsExposeTLSRoute {
+		if err := r.deleteNatsExposure(ctx, cluster, &corev1.Service{}, natsExternalService(cluster)); err != nil {
+			return err
+		}
+	}
+
+	if expose == nil || expose.Type != natsExposeTLSRoute {
+		for name := range natsTLSRoutes(cluster) {
+			route := &unstructured.Unstructured{}
+			route.SetGroupVersionKind(tlsRouteGVK)
+			if err := r.deleteNatsExposure(ctx, cluster, route, name); err != nil {
+				return err
+			}
+		}
+	}
+
+	if expose == nil {
+		cluster.Status.Nats.Exposure = nil
+		return nil
+	}
+
+	if expose.Type == natsExposeTLSRoute {
+		if err := r.reconcileNatsTLSRoutes(ctx, cluster, expose); err != nil {
+			return err
+		}
+		cluster.Status.Nats.Exposure = natsTLSRouteExposure(expose)
+		return nil
+	}
+
+	service, err := r.reconcileNatsExternalService(ctx, cluster, expose)
+	if err != nil {
+		return err
+	}
+	cluster.Status.Nats.Exposure = natsServiceExposure(expose, service)
+
+	return nil
+}
+
+// deleteNatsExposure removes what a previous exposure type created.
+// Missing Gateway API types mean there's nothing to remove.
+func (r *ClusterReconciler) deleteNatsExposure(ctx context.Context, cluster *k8sv1alpha1.Cluster, obj client.Object, name string) error {
+	obj.SetNamespace(cluster.GetNamespace())
+	obj.SetName(name)
+
+	err := r.Delete(ctx, obj)
+	if client.IgnoreNotFound(err) == nil || meta.IsNoMatchError(err) {
+		return nil
+	}
+	return err
+}
+
+func (r *ClusterReconciler) reconcileNatsExternalService(ctx context.Context, cluster *k8sv1alpha1.Cluster, expose *k8sv1alpha1.NatsExposeSpec) (*corev1.Service, error) {
+	defaultLabels := map[string]string{
+		"cluster": cluster.GetName(),
+	}
+
+	// gateways are only listed when enabled
+	spec := corev1.ServiceSpec{
+		Type:     corev1.ServiceType(expose.Type),
+		Selector: map[string]string{"cluster": cluster.GetName()},
+		Ports:    natsServicePorts(newNatsServerConfig(cluster).ports(), "nats", "leafnodes", "gateways"),
+	}
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            natsExternalService(cluster),
+			Namespace:       cluster.GetNamespace(),
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(cluster, cluster.GroupVersionKind())},
+		},
+	}
+
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, service, func() error {
+		// keep allocated node ports, so they don't change on every update
+		for i, port := range spec.Ports {
+			if existing := servicePort(service, port.Name); existing != nil {
+				spec.Ports[i].NodePort = existing.NodePort
+			}
+		}
+		spec.ClusterIP = service.Spec.ClusterIP
+		spec.ClusterIPs = service.Spec.ClusterIPs
+		service.Spec = spec
+		service.SetLabels(mergeLabels(service.GetLabels(), cluster.Spec.Nats.Managed.Labels, defaultLabels))
+		service.SetAnnotations(mergeLabels(service.GetAnnotations(), expose.Annotations))
+		return nil
+	})
+
+	return service, err
+}
+
+func servicePort(service *corev1.Service, name string) *corev1.ServicePort {
+	for i := range service.Spec.Ports {
+		if service.Spec.Ports[i].Name == name {
+			return &service.Spec.Ports[i]
+		}
+	}
+	return nil
+}
+
+func (r *ClusterReconciler) reconcileNatsTLSRoutes(ctx context.Context, cluster *k8sv1alpha1.Cluster, expose *k8sv1alpha1.NatsExposeSpec) error {
+	parent := map[string]interface{}{
+		"name": expose.TLSRoute.GatewayName,
+	}
+	if expose.TLSRoute.GatewayNamespace != "" {
+		parent["namespace"] = expose.TLSRoute.GatewayNamespace
+	}
+	if expose.TLSRoute.SectionName != "" {
+		parent["sectionName"] = expose.TLSRoute.SectionName
+	}
+
+	for name, port := range natsTLSRoutes(cluster) {
+		host := expose.Host
+		if port == natsLeafNodePort {
+			host = expose.LeafNodeHost
+		}
+
+		spec := map[string]interface{}{
+			"parentRefs": []interface{}{parent},
+			"hostnames":  []interface{}{host},
+			"rules": []interface{}{
+				map[string]interface{}{
+					"backendRefs": []interface{}{
+						map[string]interface{}{
+							"name": "nats-" + cluster.GetName(),
+							"port": int64(port),
+						},
+					},
+				},
+			},
+		}
+
+		route := &unstructured.Unstructured{}
+		route.SetGroupVersionKind(tlsRouteGVK)
+		route.SetNamespace(cluster.GetNamespace())
+		route.SetName(name)
+
+		if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, route, func() error {
+			route.SetOwnerReferences([]metav1.OwnerReference{*metav1.NewControllerRef(cluster, cluster.GroupVersionKind())})
+			return unstructured.SetNestedMap(route.Object, spec, "spec")
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// natsServiceExposure returns the addresses of an external Service. Addresses stay empty until
+// the load balancer has one, or node ports are allocated. Gateways share the client host.
+func natsServiceExposure(expose *k8sv1alpha1.NatsExposeSpec, service *corev1.Service) *k8sv1alpha1.NatsExposureStatus {
+	host := expose.Host
+	if host == "" {
+		for _, ingress := range service.Status.LoadBalancer.Ingress {
+			host = ingress.Hostname
+			if host == "" {
+				host = ingress.IP
+			}
+			if host != "" {
+				break
+			}
+		}
+	}
+	leafNodeHost := expose.LeafNodeHost
+	if leafNodeHost == "" {
+		leafNodeHost = host
+	}
+
+	status := &k8sv1alpha1.NatsExposureStatus{}
+	if host == "" {
+		return status
+	}
+
+	clientPort, leafNodePort, gatewayPort := int32(natsClientPort), int32(natsLeafNodePort), int32(0)
+	if port := servicePort(service, "gateways"); port != nil {
+		gatewayPort = port.Port
+	}
+	if service.Spec.Type == corev1.ServiceTypeNodePort {
+		clientPort, leafNodePort, gatewayPort = 0, 0, 0
+		if port := servicePort(service, "nats"); port != nil {
+			clientPort = port.NodePort
+		}
+		if port := servicePort(service, "leafnodes"); port != nil {
+			leafNodePort = port.NodePort
+		}
+		if port := servicePort(service, "gateways"); port != nil {
+			gatewayPort = port.NodePort
+		}
+	}
+
+	if clientPort != 0 {
+		status.ClientAddress = net.JoinHostPort(host, strconv.Itoa(int(clientPort)))
+	}
+	if leafNodePort != 0 {
+		status.LeafNodeAddress = net.JoinHostPort(leafNodeHost, strconv.Itoa(int(leafNodePort)))
+	}
+	if gatewayPort != 0 {
+		status.GatewayAddress = net.JoinHostPort(host, strconv.Itoa(int(gatewayPort)))
+	}
+
+	return status
+}
+
+func natsTLSRouteExposure(expose *k8sv1alpha1.NatsExposeSpec) *k8sv1alpha1.NatsExposureStatus {
+	port := strconv.Itoa(int(expose.TLSRoute.Port))
+	return &k8sv1alpha1.NatsExposureStatus{
+		ClientAddress:   net.JoinHostPort(expose.Host, port),
+		LeafNodeAddress: net.JoinHostPort(expose.LeafNodeHost, port),
+	}
+}
+
+// natsExposedNames lists the names outside clients reach the servers by, for the server certificate.
+// IP addresses aren't names, and are left out.
+func natsExposedNames(cluster *k8sv1alpha1.Cluster) []string {
+	expose := cluster.Spec.Nats.Managed.Expose
+	if expose == nil {
+		return nil
+	}
+
+	candidates := []string{expose.Host, expose.LeafNodeHost}
+	if exposure := cluster.Status.Nats.Exposure; exposure != nil {
+		for _, address := range []string{exposure.ClientAddress, exposure.LeafNodeAddress, exposure.GatewayAddress} {
+			if host, _, err := net.SplitHostPort(address); err == nil {
+				candidates = append(candidates, host)
+			}
+		}
+	}
+
+	var names []string
+	for _, name := range candidates {
+		if name == "" || net.ParseIP(name) != nil || slices.Contains(names, name) {
+			continue
+		}
+		names = append(names, name)
+	}
+
+	return names
+}
+
+func natsClientAdvertise(cluster *k8sv1alpha1.Cluster) string {
+	if cluster.Status.Nats.Exposure == nil {
+		return ""
+	}
+	return cluster.Status.Nats.Exposure.ClientAddress
+}
+
+func natsLeafNodeAdvertise(cluster *k8sv1alpha1.Cluster) string {
+	if cluster.Status.Nats.Exposure == nil {
+		return ""
+	}
+	return cluster.Status.Nats.Exposure.LeafNodeAddress
+}
+
+// natsHandshakeFirst reports whether servers sit behind a TLS passthrough route, which needs the
+// TLS handshake to pick a backend. Clients may still connect without it, leafnodes may not.
+func natsHandshakeFirst(cluster *k8sv1alpha1.Cluster) bool {
+	expose := cluster.Spec.Nats.Managed.Expose
+	return expose != nil && expose.Type == natsExposeTLSRoute
+}
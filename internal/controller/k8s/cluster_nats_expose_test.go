@@ -0,0 +1,122 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestNatsServiceExposure(t *testing.T) {
+	loadBalancer := func(ingress ...corev1.LoadBalancerIngress) *corev1.Service {
+		service := &corev1.Service{Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer}}
+		service.Status.LoadBalancer.Ingress = ingress
+		return service
+	}
+
+	nodePort := &corev1.Service{
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeNodePort,
+			Ports: []corev1.ServicePort{
+				{Name: "nats", Port: 4222, NodePort: 30422},
+				{Name: "leafnodes", Port: 7422, NodePort: 30742},
+			},
+		},
+	}
+
+	gateways := &corev1.Service{
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeNodePort,
+			Ports: []corev1.ServicePort{
+				{Name: "nats", Port: 4222, NodePort: 30422},
+				{Name: "leafnodes", Port: 7422, NodePort: 30742},
+				{Name: "gateways", Port: 7222, NodePort: 30722},
+			},
+		},
+	}
+
+	cases := map[string]struct {
+		expose  k8sv1alpha1.NatsExposeSpec
+		service *corev1.Service
+		want    *k8sv1alpha1.NatsExposureStatus
+	}{
+		"Pending": {
+			expose:  k8sv1alpha1.NatsExposeSpec{Type: "LoadBalancer"},
+			service: loadBalancer(),
+			want:    &k8sv1alpha1.NatsExposureStatus{},
+		},
+		"LoadBalancerHostname": {
+			expose:  k8sv1alpha1.NatsExposeSpec{Type: "LoadBalancer"},
+			service: loadBalancer(corev1.LoadBalancerIngress{Hostname: "lb.example.com", IP: "192.0.2.1"}),
+			want: &k8sv1alpha1.NatsExposureStatus{
+				ClientAddress:   "lb.example.com:4222",
+				LeafNodeAddress: "lb.example.com:7422",
+			},
+		},
+		"LoadBalancerIP": {
+			expose:  k8sv1alpha1.NatsExposeSpec{Type: "LoadBalancer"},
+			service: loadBalancer(corev1.LoadBalancerIngress{IP: "2001:db8::1"}),
+			want: &k8sv1alpha1.NatsExposureStatus{
+				ClientAddress:   "[2001:db8::1]:4222",
+				LeafNodeAddress: "[2001:db8::1]:7422",
+			},
+		},
+		"Host": {
+			expose:  k8sv1alpha1.NatsExposeSpec{Type: "LoadBalancer", Host: "nats.example.com", LeafNodeHost: "leaf.example.com"},
+			service: loadBalancer(corev1.LoadBalancerIngress{Hostname: "lb.example.com"}),
+			want: &k8sv1alpha1.NatsExposureStatus{
+				ClientAddress:   "nats.example.com:4222",
+				LeafNodeAddress: "leaf.example.com:7422",
+			},
+		},
+		"NodePort": {
+			expose:  k8sv1alpha1.NatsExposeSpec{Type: "NodePort", Host: "edge.example.com"},
+			service: nodePort,
+			want: &k8sv1alpha1.NatsExposureStatus{
+				ClientAddress:   "edge.example.com:30422",
+				LeafNodeAddress: "edge.example.com:30742",
+			},
+		},
+		"Gateways": {
+			expose:  k8sv1alpha1.NatsExposeSpec{Type: "NodePort", Host: "edge.example.com"},
+			service: gateways,
+			want: &k8sv1alpha1.NatsExposureStatus{
+				ClientAddress:   "edge.example.com:30422",
+				LeafNodeAddress: "edge.example.com:30742",
+				GatewayAddress:  "edge.example.com:30722",
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := natsServiceExposure(&tc.expose, tc.service)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("exposure: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNatsExposedNames(t *testing.T) {
+	cluster := &k8sv1alpha1.Cluster{}
+	cluster.Spec.Nats.Managed = &k8sv1alpha1.NatsManagedSpec{
+		Expose: &k8sv1alpha1.NatsExposeSpec{Type: "LoadBalancer", LeafNodeHost: "leaf.example.com"},
+	}
+	cluster.Status.Nats.Exposure = &k8sv1alpha1.NatsExposureStatus{
+		ClientAddress:   "lb.example.com:4222",
+		LeafNodeAddress: "leaf.example.com:7422",
+	}
+
+	want := []string{"leaf.example.com", "lb.example.com"}
+	if diff := cmp.Diff(want, natsExposedNames(cluster)); diff != "" {
+		t.Errorf("names: -want, +got:\n%s", diff)
+	}
+
+	cluster.Status.Nats.Exposure.ClientAddress = "192.0.2.1:4222"
+	want = []string{"leaf.example.com"}
+	if diff := cmp.Diff(want, natsExposedNames(cluster)); diff != "" {
+		t.Errorf("names with an IP: -want, +got:\n%s", diff)
+	}
+}
@@ -0,0 +1,100 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// reconcileNatsExternal wires a Cluster to a NATS deployment managed elsewhere.
+// The user provided credentials are copied into the Cluster client secret,
+// so wadm, hosts and the operator connect the same way as with managed NATS.
+func (r *ClusterReconciler) reconcileNatsExternal(ctx context.Context, cluster *k8sv1alpha1.Cluster) error {
+	logger := log.FromContext(ctx)
+
+	if err := recordCondition(&cluster.Status.ConditionedStatus, conditionNatsCredentials, r.reconcileNatsExternalCredentials(ctx, cluster)); err != nil {
+		return err
+	}
+
+	cluster.Status.Nats = k8sv1alpha1.NatsStatus{Managed: false}
+
+	// health is observed rather than reconciled, so it doesn't fail the reconcile
+	if err := recordCondition(&cluster.Status.ConditionedStatus, conditionNatsHealthy, r.checkNatsExternal(ctx, cluster)); err != nil {
+		logger.Info("NATS is not reachable", "reason", err.Error())
+	}
+
+	return nil
+}
+
+func (r *ClusterReconciler) reconcileNatsExternalCredentials(ctx context.Context, cluster *k8sv1alpha1.Cluster) error {
+	external := cluster.Spec.Nats.External
+
+	creds, err := r.secretKey(ctx, cluster.GetNamespace(), external.CredentialsSecret)
+	if err != nil {
+		return err
+	}
+
+	secretData := map[string][]byte{
+		"user.jwt": creds,
+	}
+
+	if external.CASecret != nil {
+		ca, err := r.secretKey(ctx, cluster.GetNamespace(), *external.CASecret)
+		if err != nil {
+			return err
+		}
+		secretData["ca.crt"] = ca
+	}
+
+	userSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            cluster.NatsClientSecret(),
+			Namespace:       cluster.GetNamespace(),
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(cluster, cluster.GroupVersionKind())},
+		},
+	}
+
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, userSecret, func() error {
+		userSecret.Data = secretData
+		return nil
+	})
+
+	return err
+}
+
+func (r *ClusterReconciler) checkNatsExternal(ctx context.Context, cluster *k8sv1alpha1.Cluster) error {
+	if r.Connections == nil {
+		return nil
+	}
+
+	nc, err := r.Connections.Conn(ctx, client.ObjectKeyFromObject(cluster))
+	if err != nil {
+		return err
+	}
+
+	if !nc.IsConnected() {
+		return fmt.Errorf("not connected to %s", cluster.NatsURL())
+	}
+
+	return nil
+}
+
+func (r *ClusterReconciler) secretKey(ctx context.Context, namespace string, selector corev1.SecretKeySelector) ([]byte, error) {
+	var secret corev1.Secret
+	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: selector.Name}, &secret); err != nil {
+		return nil, err
+	}
+
+	data, ok := secret.Data[selector.Key]
+	if !ok {
+		return nil, fmt.Errorf("secret %s/%s has no key %q", namespace, selector.Name, selector.Key)
+	}
+
+	return data, nil
+}
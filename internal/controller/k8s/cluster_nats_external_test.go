@@ -0,0 +1,158 @@
+package k8s
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func testExternalNatsCluster(caSecret *corev1.SecretKeySelector) *k8sv1alpha1.Cluster {
+	cluster := &k8sv1alpha1.Cluster{
+		TypeMeta:   metav1.TypeMeta{APIVersion: k8sv1alpha1.GroupVersion.String(), Kind: "Cluster"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "wasmcloud", UID: "cluster"},
+	}
+	cluster.Spec.Nats.External = &k8sv1alpha1.NatsExternalSpec{
+		URLs: []string{"tls://nats.example.com:4443"},
+		CredentialsSecret: corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "nats-user"},
+			Key:                  "user.creds",
+		},
+		CASecret: caSecret,
+	}
+	return cluster
+}
+
+func TestReconcileNatsExternal(t *testing.T) {
+	creds := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nats-user"},
+		Data:       map[string][]byte{"user.creds": []byte("external creds")},
+	}
+	ca := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nats-ca"},
+		Data:       map[string][]byte{"ca.pem": []byte("external ca")},
+	}
+	caSelector := func(name string, key string) *corev1.SecretKeySelector {
+		return &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: name}, Key: key}
+	}
+
+	cases := map[string]struct {
+		caSecret *corev1.SecretKeySelector
+		objs     []client.Object
+		// data of the client secret left from a previous reconcile
+		previous map[string][]byte
+		// nil when no client secret should be written
+		want    map[string][]byte
+		wantErr string
+	}{
+		"Creds": {
+			objs: []client.Object{creds},
+			want: map[string][]byte{"user.jwt": []byte("external creds")},
+		},
+		"CredsAndCA": {
+			caSecret: caSelector("nats-ca", "ca.pem"),
+			objs:     []client.Object{creds, ca},
+			want: map[string][]byte{
+				"user.jwt": []byte("external creds"),
+				"ca.crt":   []byte("external ca"),
+			},
+		},
+		"Updated": {
+			objs: []client.Object{creds},
+			// the CA was dropped from the spec since
+			previous: map[string][]byte{
+				"user.jwt": []byte("old creds"),
+				"ca.crt":   []byte("old ca"),
+			},
+			want: map[string][]byte{"user.jwt": []byte("external creds")},
+		},
+		"MissingCredsSecret": {
+			wantErr: `secrets "nats-user" not found`,
+		},
+		"MissingCredsKey": {
+			objs: []client.Object{&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nats-user"},
+				Data:       map[string][]byte{"creds": []byte("external creds")},
+			}},
+			wantErr: `secret default/nats-user has no key "user.creds"`,
+		},
+		"MissingCASecret": {
+			caSecret: caSelector("nats-ca", "ca.pem"),
+			objs:     []client.Object{creds},
+			wantErr:  `secrets "nats-ca" not found`,
+		},
+		"MissingCAKey": {
+			caSecret: caSelector("nats-ca", "ca.crt"),
+			objs:     []client.Object{creds, ca},
+			wantErr:  `secret default/nats-ca has no key "ca.crt"`,
+		},
+		"MissingKeyKeepsPrevious": {
+			caSecret: caSelector("nats-ca", "ca.crt"),
+			objs:     []client.Object{creds, ca},
+			previous: map[string][]byte{"user.jwt": []byte("old creds")},
+			// connections keep working with what they have
+			want:    map[string][]byte{"user.jwt": []byte("old creds")},
+			wantErr: `secret default/nats-ca has no key "ca.crt"`,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			cluster := testExternalNatsCluster(tc.caSecret)
+
+			objs := append([]client.Object{cluster}, tc.objs...)
+			if tc.previous != nil {
+				objs = append(objs, &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: cluster.NatsClientSecret()},
+					Data:       tc.previous,
+				})
+			}
+			r := &ClusterReconciler{Client: newFakeClient(t, objs...)}
+
+			err := r.reconcileNatsExternal(ctx, cluster)
+			credentials := cluster.Status.GetCondition(conditionNatsCredentials)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tc.wantErr)
+				}
+				if credentials.Status != corev1.ConditionFalse || !strings.Contains(credentials.Message, tc.wantErr) {
+					t.Errorf("credentials condition should report the error, got %+v", credentials)
+				}
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if credentials.Status != corev1.ConditionTrue {
+					t.Errorf("credentials condition should be ready, got %+v", credentials)
+				}
+				if cluster.Status.Nats.Managed {
+					t.Error("external NATS should not be reported as managed")
+				}
+			}
+
+			var secret corev1.Secret
+			getErr := r.Get(ctx, client.ObjectKey{Namespace: "default", Name: cluster.NatsClientSecret()}, &secret)
+			if tc.want == nil {
+				if client.IgnoreNotFound(getErr) != nil || getErr == nil {
+					t.Errorf("no client secret should be written, got %v", getErr)
+				}
+				return
+			}
+			if getErr != nil {
+				t.Fatal(getErr)
+			}
+			if diff := cmp.Diff(tc.want, secret.Data); diff != "" {
+				t.Errorf("client secret: -want, +got:\n%s", diff)
+			}
+			if tc.previous == nil && !metav1.IsControlledBy(&secret, cluster) {
+				t.Error("client secret should be owned by the cluster")
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/nats-io/jwt/v2"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// natsGatewaysCAKey holds the CAs gateways are verified with, next to the server config
+const natsGatewaysCAKey = "gateways-ca.crt"
+
+// natsGateway is the gateway block of the server config.
+type natsGateway struct {
+	Name      string        `json:"name"`
+	Port      int           `json:"port"`
+	Advertise string        `json:"advertise,omitempty"`
+	TLS       natsTLSConfig `json:"tls"`
+	// NATS requires the list, even when empty
+	Gateways []natsGatewayRemote `json:"gateways"`
+}
+
+type natsGatewayRemote struct {
+	Name string   `json:"name"`
+	URLs []string `json:"urls"`
+}
+
+// natsGatewayz is the subset of the /gatewayz response we surface in status.
+type natsGatewayz struct {
+	OutboundGateways map[string]json.RawMessage `json:"outbound_gateways"`
+}
+
+func gatewayRemoteName(remote k8sv1alpha1.NatsGatewayRemote) string {
+	if remote.Cluster != nil {
+		return remote.Cluster.Name
+	}
+	return remote.Name
+}
+
+// natsGatewayRemotes resolves the gateway remotes of the server config and the CA bundle gateways
+// are verified with: the Cluster own CA, then the ones of the remotes. trust holds the JWTs
+// of the server config, which remote Clusters must share.
+func (r *ClusterReconciler) natsGatewayRemotes(ctx context.Context, cluster *k8sv1alpha1.Cluster, trust map[string]string) ([]natsGatewayRemote, []byte, error) {
+	ca, err := r.natsTrustBundle(ctx, cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+	bundle := [][]byte{ca}
+
+	remotes := []natsGatewayRemote{}
+	for _, remote := range cluster.Spec.Nats.Managed.Gateways.Remotes {
+		name := gatewayRemoteName(remote)
+		if name == cluster.GetName() || slices.ContainsFunc(remotes, func(g natsGatewayRemote) bool { return g.Name == name }) {
+			return nil, nil, fmt.Errorf("gateway %s: names must be unique across the supercluster", name)
+		}
+
+		rendered := natsGatewayRemote{Name: name, URLs: remote.URLs}
+
+		if remote.Cluster != nil {
+			url, ca, err := r.natsGatewayCluster(ctx, cluster, remote.Cluster, trust)
+			if err != nil {
+				return nil, nil, fmt.Errorf("gateway %s: %w", name, err)
+			}
+			rendered.URLs = []string{url}
+			bundle = append(bundle, ca)
+		}
+
+		if sel := remote.CASecret; sel != nil {
+			ca, err := r.secretKey(ctx, cluster.GetNamespace(), *sel)
+			if err != nil {
+				return nil, nil, fmt.Errorf("gateway %s: %w", name, err)
+			}
+			bundle = append(bundle, ca)
+		}
+
+		remotes = append(remotes, rendered)
+	}
+
+	return remotes, bytes.Join(bundle, []byte("\n")), nil
+}
+
+// natsGatewayCluster returns the gateway URL and CA of a remote Cluster, after checking it is
+// a member of the same trust chain.
+func (r *ClusterReconciler) natsGatewayCluster(ctx context.Context, cluster *k8sv1alpha1.Cluster, ref *corev1.ObjectReference, trust map[string]string) (string, []byte, error) {
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = cluster.GetNamespace()
+	}
+
+	remote, err := GetCluster(ctx, r.Client, namespace, ref.Name)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if remote.Spec.Nats.Managed == nil || remote.Spec.Nats.Managed.Gateways == nil {
+		return "", nil, fmt.Errorf("cluster %s/%s doesn't enable gateways", namespace, ref.Name)
+	}
+
+	var remoteConfig corev1.ConfigMap
+	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: "nats-" + remote.GetName()}, &remoteConfig); err != nil {
+		return "", nil, err
+	}
+
+	if err := natsSameTrust(trust, remoteConfig.Data); err != nil {
+		return "", nil, fmt.Errorf("cluster %s/%s: %w", namespace, ref.Name, err)
+	}
+
+	ca, err := r.natsTrustBundle(ctx, remote)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// the headless Service resolves to every server, gateways gossip the rest
+	url := fmt.Sprintf("tls://natsd-%s.%s.svc:%d", remote.GetName(), namespace, natsGatewayPort)
+	return url, ca, nil
+}
+
+// natsSameTrust checks two server configs were signed by the same operator and serve the same
+// system and Cluster accounts. Gateways only carry messages of accounts known on both sides.
+func natsSameTrust(local map[string]string, remote map[string]string) error {
+	for _, key := range []string{"operator.jwt", "system.jwt", "account.jwt"} {
+		localClaims, err := jwt.Decode(local[key])
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		remoteClaims, err := jwt.Decode(remote[key])
+		if err != nil {
+			return fmt.Errorf("remote %s: %w", key, err)
+		}
+
+		if localClaims.Claims().Subject != remoteClaims.Claims().Subject {
+			name := strings.TrimSuffix(key, ".jwt")
+			return fmt.Errorf("%s %s differs from %s, supply the same keys to both Clusters",
+				name, remoteClaims.Claims().Subject, localClaims.Claims().Subject)
+		}
+	}
+
+	return nil
+}
+
+// reconcileNatsGateways reports the outbound connections to each remote, taken from the /gatewayz
+// endpoint of every server. Like health, it is observed rather than reconciled.
+func (r *ClusterReconciler) reconcileNatsGateways(ctx context.Context, cluster *k8sv1alpha1.Cluster, probes []natsMonitorProbe) error {
+	gateways := cluster.Spec.Nats.Managed.Gateways
+	if gateways == nil || len(gateways.Remotes) == 0 {
+		cluster.Status.Nats.Gateways = nil
+		return recordCondition(&cluster.Status.ConditionedStatus, conditionNatsGateways, nil)
+	}
+
+	replicas := cluster.Spec.Nats.Managed.Replicas
+	servers := make([]natsGatewayz, 0, len(probes))
+	for _, probe := range probes {
+		// unreachable servers are already reported by the health check, and count as disconnected
+		if probe.gatewayz == nil || probe.gatewayzErr != nil {
+			continue
+		}
+		servers = append(servers, *probe.gatewayz)
+	}
+
+	status := natsGatewayStatus(gateways.Remotes, servers)
+	cluster.Status.Nats.Gateways = status
+
+	disconnected := []string{}
+	for _, remote := range status {
+		if remote.ConnectedServers < replicas {
+			disconnected = append(disconnected, fmt.Sprintf("%s %d/%d", remote.Name, remote.ConnectedServers, replicas))
+		}
+	}
+
+	var err error
+	if len(disconnected) > 0 {
+		err = fmt.Errorf("servers connected to gateways: %s", strings.Join(disconnected, ", "))
+	}
+
+	return recordCondition(&cluster.Status.ConditionedStatus, conditionNatsGateways, err)
+}
+
+// natsGatewayStatus counts, for each remote, the servers with an outbound connection to it.
+// Gateways discovered through gossip aren't reported.
+func natsGatewayStatus(remotes []k8sv1alpha1.NatsGatewayRemote, servers []natsGatewayz) []k8sv1alpha1.NatsGatewayStatus {
+	status := make([]k8sv1alpha1.NatsGatewayStatus, 0, len(remotes))
+	for _, remote := range remotes {
+		remoteStatus := k8sv1alpha1.NatsGatewayStatus{Name: gatewayRemoteName(remote)}
+		for _, server := range servers {
+			if _, ok := server.OutboundGateways[remoteStatus.Name]; ok {
+				remoteStatus.ConnectedServers++
+			}
+		}
+		status = append(status, remoteStatus)
+	}
+
+	return status
+}
+
+func natsGatewayAdvertise(cluster *k8sv1alpha1.Cluster) string {
+	if cluster.Status.Nats.Exposure == nil {
+		return ""
+	}
+	return cluster.Status.Nats.Exposure.GatewayAddress
+}
@@ -0,0 +1,129 @@
+package k8s
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nats-io/nkeys"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestNatsSameTrust(t *testing.T) {
+	operatorKp, err := nkeys.CreateOperator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sysKp, err := nkeys.CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountKp, err := nkeys.CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := func(operatorKp nkeys.KeyPair, accountKp nkeys.KeyPair) map[string]string {
+		operator, err := newOperator(operatorKp, sysKp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sysAccount, err := newSystemAccount(sysKp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		account, err := newAccount("wasmcloud", accountKp)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data := map[string]string{}
+		for key, claims := range map[string]interface {
+			Encode(nkeys.KeyPair) (string, error)
+		}{
+			"operator.jwt": operator,
+			"system.jwt":   sysAccount,
+			"account.jwt":  account,
+		} {
+			signed, err := claims.Encode(operatorKp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data[key] = signed
+		}
+		return data
+	}
+
+	otherOperatorKp, err := nkeys.CreateOperator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherAccountKp, err := nkeys.CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	local := config(operatorKp, accountKp)
+
+	cases := map[string]struct {
+		remote  map[string]string
+		wantErr bool
+	}{
+		"Shared": {
+			// signed at another time, JWTs differ but keys are the same
+			remote: config(operatorKp, accountKp),
+		},
+		"OtherOperator": {
+			remote:  config(otherOperatorKp, accountKp),
+			wantErr: true,
+		},
+		"OtherAccount": {
+			remote:  config(operatorKp, otherAccountKp),
+			wantErr: true,
+		},
+		"NotConfigured": {
+			remote:  map[string]string{},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := natsSameTrust(local, tc.remote)
+			if diff := cmp.Diff(tc.wantErr, err != nil); diff != "" {
+				t.Errorf("natsSameTrust(...) error %v: -want, +got:\n%s", err, diff)
+			}
+		})
+	}
+}
+
+func TestNatsGatewayStatus(t *testing.T) {
+	remotes := []k8sv1alpha1.NatsGatewayRemote{
+		{Cluster: &corev1.ObjectReference{Namespace: "eu", Name: "eu-west"}},
+		{Name: "us-east", URLs: []string{"tls://nats.us.example.com:7222"}},
+	}
+
+	gatewayz := func(raw string) natsGatewayz {
+		var gatewayz natsGatewayz
+		if err := json.Unmarshal([]byte(raw), &gatewayz); err != nil {
+			t.Fatal(err)
+		}
+		return gatewayz
+	}
+
+	servers := []natsGatewayz{
+		gatewayz(`{"name": "local", "outbound_gateways": {"eu-west": {"configured": true}, "us-east": {"configured": true}}}`),
+		gatewayz(`{"name": "local", "outbound_gateways": {"eu-west": {"configured": true}, "ap-south": {"configured": false}}}`),
+		gatewayz(`{"name": "local"}`),
+	}
+
+	want := []k8sv1alpha1.NatsGatewayStatus{
+		{Name: "eu-west", ConnectedServers: 2},
+		{Name: "us-east", ConnectedServers: 1},
+	}
+
+	if diff := cmp.Diff(want, natsGatewayStatus(remotes, servers)); diff != "" {
+		t.Errorf("status: -want, +got:\n%s", diff)
+	}
+}
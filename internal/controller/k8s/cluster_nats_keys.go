@@ -0,0 +1,176 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+
+	"github.com/nats-io/jwt/v2"
+	"github.com/nats-io/nkeys"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// natsOperatorJWTKey holds a supplied operator JWT, served instead of one signed by the operator seed.
+	natsOperatorJWTKey = "operator.jwt"
+
+	// natsStoreArchiveKey holds the nsc store in the export ConfigMap.
+	natsStoreArchiveKey = "nsc.tar.gz"
+)
+
+// loadNatsSuppliedKeys returns the seeds and operator JWT of the Secret referenced by the Cluster, if any.
+func (r *ClusterReconciler) loadNatsSuppliedKeys(ctx context.Context, cluster *k8sv1alpha1.Cluster) (map[string][]byte, error) {
+	keys := cluster.Spec.Nats.Managed.Keys
+	if keys == nil || keys.SecretName == "" {
+		return nil, nil
+	}
+
+	var secret corev1.Secret
+	if err := r.Get(
+		ctx,
+		client.ObjectKey{Namespace: cluster.GetNamespace(), Name: keys.SecretName},
+		&secret); err != nil {
+		return nil, fmt.Errorf("supplied keys: %w", err)
+	}
+
+	return secret.Data, nil
+}
+
+// checkSeed returns an error unless raw is a seed for a key of the given type.
+func checkSeed(raw []byte, prefix nkeys.PrefixByte) error {
+	kp, err := nkeys.FromSeed(raw)
+	if err != nil {
+		return err
+	}
+
+	pub, err := kp.PublicKey()
+	if err != nil {
+		return err
+	}
+
+	if got := nkeys.Prefix(pub); got != prefix {
+		return fmt.Errorf("want a %s key, got a %s key", prefix, got)
+	}
+
+	return nil
+}
+
+// natsOperatorJWT returns the supplied operator JWT when there is one, after checking that operatorKp
+// may sign accounts for it and that it trusts the Cluster system account. Otherwise it returns
+// an operator JWT signed by operatorKp itself.
+func natsOperatorJWT(supplied []byte, previous string, operatorKp nkeys.KeyPair, sysKp nkeys.KeyPair) (string, error) {
+	if len(supplied) == 0 {
+		operator, err := newOperator(operatorKp, sysKp)
+		if err != nil {
+			return "", err
+		}
+		return encodeJWT(previous, operator, operatorKp)
+	}
+
+	operatorJWT := string(bytes.TrimSpace(supplied))
+	operator, err := jwt.DecodeOperatorClaims(operatorJWT)
+	if err != nil {
+		return "", fmt.Errorf("supplied operator jwt: %w", err)
+	}
+
+	signer, err := operatorKp.PublicKey()
+	if err != nil {
+		return "", err
+	}
+	if signer != operator.Subject && !operator.SigningKeys.Contains(signer) {
+		return "", fmt.Errorf("supplied operator jwt: %s is neither the operator nor one of its signing keys", signer)
+	}
+
+	sysPub, err := sysKp.PublicKey()
+	if err != nil {
+		return "", err
+	}
+	if operator.SystemAccount != sysPub {
+		return "", fmt.Errorf("supplied operator jwt: system account is %q, want %s", operator.SystemAccount, sysPub)
+	}
+
+	return operatorJWT, nil
+}
+
+// reconcileNatsExport publishes the operator and account JWTs as an nsc store,
+// so they can be inspected with nsc. Seeds are never exported.
+func (r *ClusterReconciler) reconcileNatsExport(ctx context.Context, cluster *k8sv1alpha1.Cluster) error {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            cluster.NatsExportConfigMap(),
+			Namespace:       cluster.GetNamespace(),
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(cluster, cluster.GroupVersionKind())},
+		},
+	}
+
+	if keys := cluster.Spec.Nats.Managed.Keys; keys == nil || !keys.Export {
+		return client.IgnoreNotFound(r.Delete(ctx, cm))
+	}
+
+	var server corev1.ConfigMap
+	if err := r.Get(
+		ctx,
+		client.ObjectKey{Namespace: cluster.GetNamespace(), Name: "nats-" + cluster.GetName()},
+		&server); err != nil {
+		return err
+	}
+
+	var accountJWTs []string
+	for _, key := range natsAccountKeys {
+		if accountJWT, ok := server.Data[key]; ok {
+			accountJWTs = append(accountJWTs, accountJWT)
+		}
+	}
+
+	archive, err := natsStoreArchive(server.Data["operator.jwt"], accountJWTs)
+	if err != nil {
+		return err
+	}
+
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, cm, func() error {
+		cm.BinaryData = map[string][]byte{natsStoreArchiveKey: archive}
+		return nil
+	})
+
+	return err
+}
+
+// natsStoreArchive packs JWTs in the nsc store layout:
+//
+//	<operator>/<operator>.jwt
+//	<operator>/accounts/<account>/<account>.jwt
+//
+// Entries are sorted and carry no timestamps, so unchanged JWTs give an identical archive.
+func natsStoreArchive(operatorJWT string, accountJWTs []string) ([]byte, error) {
+	operator, err := jwt.DecodeOperatorClaims(operatorJWT)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{
+		path.Join(operator.Name, operator.Name+".jwt"): []byte(operatorJWT),
+	}
+
+	// nsc marks store roots with a .nsc file naming the operator
+	info, err := json.Marshal(map[string]string{"name": operator.Name})
+	if err != nil {
+		return nil, err
+	}
+	files[path.Join(operator.Name, ".nsc")] = info
+
+	for _, accountJWT := range accountJWTs {
+		account, err := jwt.DecodeAccountClaims(accountJWT)
+		if err != nil {
+			return nil, err
+		}
+		files[path.Join(operator.Name, "accounts", account.Name, account.Name+".jwt")] = []byte(accountJWT)
+	}
+
+	return tarArchive(files)
+}
@@ -0,0 +1,178 @@
+package k8s
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nats-io/jwt/v2"
+	"github.com/nats-io/nkeys"
+)
+
+func TestNatsOperatorJWT(t *testing.T) {
+	identityKp, err := nkeys.CreateOperator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingKp, err := nkeys.CreateOperator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sysKp, err := nkeys.CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	strangerKp, err := nkeys.CreateOperator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherSysKp, err := nkeys.CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signingPub, _ := signingKp.PublicKey()
+	supplied, err := newOperator(identityKp, sysKp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	supplied.SigningKeys.Add(signingPub)
+	suppliedJWT, err := supplied.Encode(identityKp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		supplied   string
+		operatorKp nkeys.KeyPair
+		sysKp      nkeys.KeyPair
+		wantErr    bool
+	}{
+		"Generated": {
+			operatorKp: identityKp,
+			sysKp:      sysKp,
+		},
+		"SuppliedIdentityKey": {
+			supplied:   suppliedJWT,
+			operatorKp: identityKp,
+			sysKp:      sysKp,
+		},
+		"SuppliedSigningKey": {
+			supplied:   suppliedJWT + "\n",
+			operatorKp: signingKp,
+			sysKp:      sysKp,
+		},
+		"UnknownSigner": {
+			supplied:   suppliedJWT,
+			operatorKp: strangerKp,
+			sysKp:      sysKp,
+			wantErr:    true,
+		},
+		"OtherSystemAccount": {
+			supplied:   suppliedJWT,
+			operatorKp: signingKp,
+			sysKp:      otherSysKp,
+			wantErr:    true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := natsOperatorJWT([]byte(tc.supplied), "", tc.operatorKp, tc.sysKp)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("natsOperatorJWT(...) should fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.supplied != "" {
+				if diff := cmp.Diff(suppliedJWT, got); diff != "" {
+					t.Errorf("supplied jwt should be served as-is: -want, +got:\n%s", diff)
+				}
+			}
+			if _, err := jwt.DecodeOperatorClaims(got); err != nil {
+				t.Errorf("invalid operator jwt: %v", err)
+			}
+		})
+	}
+}
+
+func TestNatsStoreArchive(t *testing.T) {
+	operatorKp, err := nkeys.CreateOperator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sysKp, err := nkeys.CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountKp, err := nkeys.CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	operatorJWT, err := natsOperatorJWT(nil, "", operatorKp, sysKp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var accountJWTs []string
+	for name, kp := range map[string]nkeys.KeyPair{"SYS": sysKp, "wasmcloud": accountKp} {
+		account, err := newAccount(name, kp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		accountJWT, err := account.Encode(operatorKp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		accountJWTs = append(accountJWTs, accountJWT)
+	}
+
+	archive, err := natsStoreArchive(operatorJWT, accountJWTs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+	}
+
+	want := []string{
+		"operator/.nsc",
+		"operator/accounts/SYS/SYS.jwt",
+		"operator/accounts/wasmcloud/wasmcloud.jwt",
+		"operator/operator.jwt",
+	}
+	if diff := cmp.Diff(want, names); diff != "" {
+		t.Errorf("archive entries: -want, +got:\n%s", diff)
+	}
+
+	again, err := natsStoreArchive(operatorJWT, []string{accountJWTs[1], accountJWTs[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(archive, again) {
+		t.Errorf("archive should not depend on account order")
+	}
+}
@@ -0,0 +1,244 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	natsLeafNodesDir = "/etc/nats-leafnodes"
+
+	natsLeafNodeAccountCluster = "Cluster"
+	natsLeafNodeAccountSystem  = "System"
+)
+
+// natsRemote is a leafnode remote in the server config.
+type natsRemote struct {
+	URLs []string `json:"urls"`
+	// public key of the local account bridged to the hub
+	Account     string         `json:"account"`
+	Credentials string         `json:"credentials,omitempty"`
+	TLS         *natsRemoteTLS `json:"tls,omitempty"`
+}
+
+type natsRemoteTLS struct {
+	CAFile   string `json:"ca_file,omitempty"`
+	CertFile string `json:"cert_file,omitempty"`
+	KeyFile  string `json:"key_file,omitempty"`
+}
+
+// natsLeaf is the subset of a /leafz connection we surface in status.
+type natsLeaf struct {
+	Name    string `json:"name"`
+	Account string `json:"account"`
+	IsSpoke bool   `json:"is_spoke"`
+}
+
+type natsLeafz struct {
+	Leafs []natsLeaf `json:"leafs"`
+}
+
+func leafNodeAccount(remote k8sv1alpha1.NatsLeafNodeRemote) string {
+	if remote.Account == "" {
+		return natsLeafNodeAccountCluster
+	}
+	return remote.Account
+}
+
+// leafNodeDir is where the files of a remote are mounted, relative to natsLeafNodesDir.
+func leafNodeDir(remote k8sv1alpha1.NatsLeafNodeRemote) string {
+	return strings.ToLower(leafNodeAccount(remote))
+}
+
+// natsLeafNodeRemotes builds the remotes of the server config. accounts maps
+// the remote account names to the public keys of the Cluster accounts.
+func natsLeafNodeRemotes(remotes []k8sv1alpha1.NatsLeafNodeRemote, accounts map[string]string) []natsRemote {
+	if len(remotes) == 0 {
+		return nil
+	}
+
+	rendered := make([]natsRemote, 0, len(remotes))
+	for _, remote := range remotes {
+		dir := path.Join(natsLeafNodesDir, leafNodeDir(remote))
+		nr := natsRemote{
+			URLs:    remote.URLs,
+			Account: accounts[leafNodeAccount(remote)],
+		}
+
+		if remote.CredentialsSecret != nil {
+			nr.Credentials = path.Join(dir, "user.creds")
+		}
+
+		if remote.TLS != nil {
+			nr.TLS = &natsRemoteTLS{}
+			if remote.TLS.CASecret != nil {
+				nr.TLS.CAFile = path.Join(dir, "ca.crt")
+			}
+			if remote.TLS.CertificateSecret != "" {
+				nr.TLS.CertFile = path.Join(dir, "tls.crt")
+				nr.TLS.KeyFile = path.Join(dir, "tls.key")
+			}
+		}
+
+		rendered = append(rendered, nr)
+	}
+
+	return rendered
+}
+
+// natsLeafNodesVolume projects the creds and certificates of the remotes, one directory per account.
+func natsLeafNodesVolume(cluster *k8sv1alpha1.Cluster) *corev1.Volume {
+	sources := []corev1.VolumeProjection{}
+	for _, remote := range cluster.Spec.Nats.Managed.LeafNodeRemotes {
+		dir := leafNodeDir(remote)
+
+		if sel := remote.CredentialsSecret; sel != nil {
+			sources = append(sources, secretKeyProjection(sel, path.Join(dir, "user.creds")))
+		}
+
+		if remote.TLS == nil {
+			continue
+		}
+
+		if sel := remote.TLS.CASecret; sel != nil {
+			sources = append(sources, secretKeyProjection(sel, path.Join(dir, "ca.crt")))
+		}
+
+		if remote.TLS.CertificateSecret != "" {
+			sources = append(sources, corev1.VolumeProjection{
+				Secret: &corev1.SecretProjection{
+					LocalObjectReference: corev1.LocalObjectReference{Name: remote.TLS.CertificateSecret},
+					Items: []corev1.KeyToPath{
+						{Key: corev1.TLSCertKey, Path: path.Join(dir, "tls.crt")},
+						{Key: corev1.TLSPrivateKeyKey, Path: path.Join(dir, "tls.key")},
+					},
+				},
+			})
+		}
+	}
+
+	if len(sources) == 0 {
+		return nil
+	}
+
+	return &corev1.Volume{
+		Name: "leafnodes",
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{Sources: sources},
+		},
+	}
+}
+
+func secretKeyProjection(sel *corev1.SecretKeySelector, file string) corev1.VolumeProjection {
+	return corev1.VolumeProjection{
+		Secret: &corev1.SecretProjection{
+			LocalObjectReference: sel.LocalObjectReference,
+			Items:                []corev1.KeyToPath{{Key: sel.Key, Path: file}},
+			Optional:             sel.Optional,
+		},
+	}
+}
+
+// reconcileNatsLeafNodes reports the hub connections of each remote, taken from the /leafz endpoint
+// of every server. Like health, it is observed rather than reconciled.
+func (r *ClusterReconciler) reconcileNatsLeafNodes(ctx context.Context, cluster *k8sv1alpha1.Cluster, probes []natsMonitorProbe) error {
+	remotes := cluster.Spec.Nats.Managed.LeafNodeRemotes
+	if len(remotes) == 0 {
+		cluster.Status.Nats.LeafNodes = nil
+		return recordCondition(&cluster.Status.ConditionedStatus, conditionNatsLeafNodes, nil)
+	}
+
+	accounts, err := r.natsLeafNodeAccounts(ctx, cluster)
+	if err != nil {
+		return recordCondition(&cluster.Status.ConditionedStatus, conditionNatsLeafNodes, err)
+	}
+
+	replicas := cluster.Spec.Nats.Managed.Replicas
+	servers := make([]natsLeafz, 0, len(probes))
+	for _, probe := range probes {
+		// unreachable servers are already reported by the health check, and count as disconnected
+		if probe.leafz == nil || probe.leafzErr != nil {
+			continue
+		}
+		servers = append(servers, *probe.leafz)
+	}
+
+	status := natsLeafNodeStatus(remotes, accounts, servers)
+	cluster.Status.Nats.LeafNodes = status
+
+	disconnected := []string{}
+	for _, remote := range status {
+		if remote.ConnectedServers < replicas {
+			disconnected = append(disconnected, fmt.Sprintf("%s %d/%d", remote.Account, remote.ConnectedServers, replicas))
+		}
+	}
+
+	if len(disconnected) > 0 {
+		err = fmt.Errorf("servers connected to hubs: %s", strings.Join(disconnected, ", "))
+	}
+
+	return recordCondition(&cluster.Status.ConditionedStatus, conditionNatsLeafNodes, err)
+}
+
+// natsLeafNodeAccounts maps the remote account names to the public keys of the Cluster accounts.
+func (r *ClusterReconciler) natsLeafNodeAccounts(ctx context.Context, cluster *k8sv1alpha1.Cluster) (map[string]string, error) {
+	seeds, err := r.seedStore().clientSeeds(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := map[string]string{}
+	for name, seed := range map[string]string{
+		natsLeafNodeAccountCluster: "account",
+		natsLeafNodeAccountSystem:  "system",
+	} {
+		kp, err := seeds.keyPair(seed)
+		if err != nil {
+			return nil, err
+		}
+		pub, err := kp.PublicKey()
+		if err != nil {
+			return nil, err
+		}
+		accounts[name] = pub
+	}
+
+	return accounts, nil
+}
+
+// natsLeafNodeStatus counts, for each remote, the servers with an outgoing leafnode connection
+// in the remote account. Incoming connections from leafnodes of this Cluster aren't counted.
+func natsLeafNodeStatus(remotes []k8sv1alpha1.NatsLeafNodeRemote, accounts map[string]string, servers []natsLeafz) []k8sv1alpha1.NatsLeafNodeStatus {
+	status := make([]k8sv1alpha1.NatsLeafNodeStatus, 0, len(remotes))
+	for _, remote := range remotes {
+		account := leafNodeAccount(remote)
+		remoteStatus := k8sv1alpha1.NatsLeafNodeStatus{Account: account}
+
+		for _, server := range servers {
+			connected := false
+			for _, leaf := range server.Leafs {
+				if !leaf.IsSpoke || leaf.Account != accounts[account] {
+					continue
+				}
+				connected = true
+				if leaf.Name != "" && !slices.Contains(remoteStatus.Hubs, leaf.Name) {
+					remoteStatus.Hubs = append(remoteStatus.Hubs, leaf.Name)
+				}
+			}
+			if connected {
+				remoteStatus.ConnectedServers++
+			}
+		}
+
+		slices.Sort(remoteStatus.Hubs)
+		status = append(status, remoteStatus)
+	}
+
+	return status
+}
@@ -0,0 +1,94 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestNatsLeafNodeRemotes(t *testing.T) {
+	accounts := map[string]string{
+		natsLeafNodeAccountCluster: "ACLUSTER",
+		natsLeafNodeAccountSystem:  "ASYSTEM",
+	}
+
+	remotes := []k8sv1alpha1.NatsLeafNodeRemote{
+		{
+			URLs: []string{"tls://hub.example.com:7422"},
+			CredentialsSecret: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "hub-creds"},
+				Key:                  "user.creds",
+			},
+			TLS: &k8sv1alpha1.NatsLeafNodeTLS{
+				CASecret: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "hub-ca"},
+					Key:                  "ca.crt",
+				},
+				CertificateSecret: "hub-client",
+			},
+		},
+		{
+			Account: natsLeafNodeAccountSystem,
+			URLs:    []string{"nats://hub.example.com:7422"},
+		},
+	}
+
+	got := natsLeafNodeRemotes(remotes, accounts)
+
+	want := []natsRemote{
+		{
+			URLs:        []string{"tls://hub.example.com:7422"},
+			Account:     "ACLUSTER",
+			Credentials: "/etc/nats-leafnodes/cluster/user.creds",
+			TLS: &natsRemoteTLS{
+				CAFile:   "/etc/nats-leafnodes/cluster/ca.crt",
+				CertFile: "/etc/nats-leafnodes/cluster/tls.crt",
+				KeyFile:  "/etc/nats-leafnodes/cluster/tls.key",
+			},
+		},
+		{
+			URLs:    []string{"nats://hub.example.com:7422"},
+			Account: "ASYSTEM",
+		},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("remotes: -want, +got:\n%s", diff)
+	}
+}
+
+func TestNatsLeafNodeStatus(t *testing.T) {
+	accounts := map[string]string{
+		natsLeafNodeAccountCluster: "ACLUSTER",
+		natsLeafNodeAccountSystem:  "ASYSTEM",
+	}
+
+	remotes := []k8sv1alpha1.NatsLeafNodeRemote{
+		{Account: natsLeafNodeAccountCluster},
+		{Account: natsLeafNodeAccountSystem},
+	}
+
+	servers := []natsLeafz{
+		{Leafs: []natsLeaf{
+			{Name: "hub-1", Account: "ACLUSTER", IsSpoke: true},
+			{Name: "hub-0", Account: "ASYSTEM", IsSpoke: true},
+		}},
+		{Leafs: []natsLeaf{
+			{Name: "hub-0", Account: "ACLUSTER", IsSpoke: true},
+			// an edge connected to this server
+			{Name: "edge-0", Account: "ASYSTEM"},
+		}},
+		{},
+	}
+
+	want := []k8sv1alpha1.NatsLeafNodeStatus{
+		{Account: natsLeafNodeAccountCluster, ConnectedServers: 2, Hubs: []string{"hub-0", "hub-1"}},
+		{Account: natsLeafNodeAccountSystem, ConnectedServers: 1, Hubs: []string{"hub-0"}},
+	}
+
+	if diff := cmp.Diff(want, natsLeafNodeStatus(remotes, accounts, servers)); diff != "" {
+		t.Errorf("status: -want, +got:\n%s", diff)
+	}
+}
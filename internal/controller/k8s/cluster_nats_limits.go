@@ -0,0 +1,44 @@
+package k8s
+
+import (
+	"github.com/nats-io/jwt/v2"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// applyNatsAccountLimits sets the account limits from the Cluster spec, leaving unset ones unlimited.
+// JetStream is always enabled. Changed limits change the account claims, which gets the account
+// JWT re-signed and pushed to the servers.
+func applyNatsAccountLimits(account *jwt.AccountClaims, limits *k8sv1alpha1.NatsAccountLimits) {
+	if limits == nil {
+		limits = &k8sv1alpha1.NatsAccountLimits{}
+	}
+
+	account.Limits.Conn = limitOrUnlimited(limits.Connections)
+	account.Limits.Subs = limitOrUnlimited(limits.Subscriptions)
+	account.Limits.Payload = quantityOrUnlimited(limits.Payload)
+
+	jetStream := limits.JetStream
+	if jetStream == nil {
+		jetStream = &k8sv1alpha1.NatsJetStreamLimits{}
+	}
+
+	account.Limits.JetStreamLimits.MemoryStorage = quantityOrUnlimited(jetStream.MemoryStorage)
+	account.Limits.JetStreamLimits.DiskStorage = quantityOrUnlimited(jetStream.DiskStorage)
+	account.Limits.JetStreamLimits.Streams = limitOrUnlimited(jetStream.Streams)
+	account.Limits.JetStreamLimits.Consumer = limitOrUnlimited(jetStream.Consumers)
+}
+
+func limitOrUnlimited(limit *int64) int64 {
+	if limit == nil {
+		return jwt.NoLimit
+	}
+	return *limit
+}
+
+func quantityOrUnlimited(limit *resource.Quantity) int64 {
+	if limit == nil {
+		return jwt.NoLimit
+	}
+	return limit.Value()
+}
@@ -0,0 +1,94 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nats-io/jwt/v2"
+	"github.com/nats-io/nkeys"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestApplyNatsAccountLimits(t *testing.T) {
+	accountKp, err := nkeys.CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		limits        *k8sv1alpha1.NatsAccountLimits
+		want          jwt.NatsLimits
+		wantJetStream jwt.JetStreamLimits
+	}{
+		"Unset": {
+			want:          jwt.NatsLimits{Subs: jwt.NoLimit, Data: jwt.NoLimit, Payload: jwt.NoLimit},
+			wantJetStream: jwt.JetStreamLimits{MemoryStorage: jwt.NoLimit, DiskStorage: jwt.NoLimit, Streams: jwt.NoLimit, Consumer: jwt.NoLimit},
+		},
+		"Set": {
+			limits: &k8sv1alpha1.NatsAccountLimits{
+				Subscriptions: pointer[int64](1000),
+				Payload:       pointer(resource.MustParse("512Ki")),
+				JetStream: &k8sv1alpha1.NatsJetStreamLimits{
+					MemoryStorage: pointer(resource.MustParse("256Mi")),
+					DiskStorage:   pointer(resource.MustParse("5Gi")),
+					Streams:       pointer[int64](20),
+				},
+			},
+			want:          jwt.NatsLimits{Subs: 1000, Data: jwt.NoLimit, Payload: 512 * 1024},
+			wantJetStream: jwt.JetStreamLimits{MemoryStorage: 256 << 20, DiskStorage: 5 << 30, Streams: 20, Consumer: jwt.NoLimit},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			account, err := newAccount("wasmcloud", accountKp)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			applyNatsAccountLimits(account, tc.limits)
+
+			if diff := cmp.Diff(tc.want, account.Limits.NatsLimits); diff != "" {
+				t.Errorf("nats limits: -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantJetStream, account.Limits.JetStreamLimits); diff != "" {
+				t.Errorf("jetstream limits: -want, +got:\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("ChangeResigns", func(t *testing.T) {
+		operatorKp, err := nkeys.CreateOperator()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		account, err := newAccount("wasmcloud", accountKp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		applyNatsAccountLimits(account, nil)
+		previous, err := encodeJWT("", account, operatorKp)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		limited, err := newAccount("wasmcloud", accountKp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		applyNatsAccountLimits(limited, &k8sv1alpha1.NatsAccountLimits{Connections: pointer[int64](10)})
+		got, err := encodeJWT(previous, limited, operatorKp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got == previous {
+			t.Errorf("changed limits should re-sign the account JWT")
+		}
+	})
+}
+
+func pointer[T any](v T) *T {
+	return &v
+}
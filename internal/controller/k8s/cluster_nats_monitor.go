@@ -0,0 +1,183 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// natsMonitorTimeout bounds a whole pass over the monitor ports, servers are probed concurrently
+const natsMonitorTimeout = 2 * time.Second
+
+// natsVarz is the subset of the NATS /varz response we surface in status.
+type natsVarz struct {
+	ServerID string `json:"server_id"`
+	Version  string `json:"version"`
+	Cluster  struct {
+		URLs []string `json:"urls"`
+	} `json:"cluster"`
+}
+
+// natsMonitorProbe is what the monitor port of a server answered in one pass.
+type natsMonitorProbe struct {
+	name      string
+	healthErr error
+	varz      natsVarz
+	varzErr   error
+	// only gathered when the Cluster has leafnode remotes
+	leafz    *natsLeafz
+	leafzErr error
+	// only gathered when the Cluster has gateway remotes
+	gatewayz    *natsGatewayz
+	gatewayzErr error
+}
+
+// probeNatsServers queries the monitor endpoints of every server at once, under a shared deadline,
+// so unreachable servers don't add up to a slow reconcile.
+func (r *ClusterReconciler) probeNatsServers(ctx context.Context, cluster *k8sv1alpha1.Cluster) []natsMonitorProbe {
+	ctx, cancel := context.WithTimeout(ctx, natsMonitorTimeout)
+	defer cancel()
+
+	probes := make([]natsMonitorProbe, cluster.Spec.Nats.Managed.Replicas)
+	var wg sync.WaitGroup
+	for i := range probes {
+		probe := &probes[i]
+		probe.name = fmt.Sprintf("nats-%s-%d", cluster.GetName(), i)
+		url := natsMonitorURL(cluster, probe.name)
+
+		gets := []func(){
+			func() { probe.healthErr = r.natsMonitorGet(ctx, url+"/healthz", nil) },
+			func() { probe.varzErr = r.natsMonitorGet(ctx, url+"/varz", &probe.varz) },
+		}
+		if len(cluster.Spec.Nats.Managed.LeafNodeRemotes) > 0 {
+			probe.leafz = &natsLeafz{}
+			gets = append(gets, func() { probe.leafzErr = r.natsMonitorGet(ctx, url+"/leafz", probe.leafz) })
+		}
+		if gateways := cluster.Spec.Nats.Managed.Gateways; gateways != nil && len(gateways.Remotes) > 0 {
+			probe.gatewayz = &natsGatewayz{}
+			gets = append(gets, func() { probe.gatewayzErr = r.natsMonitorGet(ctx, url+"/gatewayz", probe.gatewayz) })
+		}
+
+		for _, get := range gets {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				get()
+			}()
+		}
+	}
+	wg.Wait()
+
+	return probes
+}
+
+// status reports the server health, along with its identity when /varz answered.
+func (p *natsMonitorProbe) status() k8sv1alpha1.NatsServerStatus {
+	server := k8sv1alpha1.NatsServerStatus{Name: p.name}
+
+	if p.healthErr != nil {
+		server.Message = p.healthErr.Error()
+		return server
+	}
+	server.Healthy = true
+
+	if p.varzErr != nil {
+		server.Message = p.varzErr.Error()
+		return server
+	}
+	server.ServerID = p.varz.ServerID
+	server.Version = p.varz.Version
+
+	return server
+}
+
+// reconcileNatsHealth records StatefulSet readiness and the health each server's monitor port reported.
+func (r *ClusterReconciler) reconcileNatsHealth(ctx context.Context, cluster *k8sv1alpha1.Cluster, probes []natsMonitorProbe) error {
+	var statefulset appsv1.StatefulSet
+	if err := r.Get(
+		ctx,
+		client.ObjectKey{Namespace: cluster.GetNamespace(), Name: "nats-" + cluster.GetName()},
+		&statefulset); err != nil {
+		return recordCondition(&cluster.Status.ConditionedStatus, conditionNatsHealthy, err)
+	}
+
+	replicas := cluster.Spec.Nats.Managed.Replicas
+	cluster.Status.Nats.Managed = true
+	cluster.Status.Nats.Replicas = statefulset.Status.Replicas
+	cluster.Status.Nats.ReadyReplicas = statefulset.Status.ReadyReplicas
+
+	servers := make([]k8sv1alpha1.NatsServerStatus, 0, len(probes))
+	unhealthy := []string{}
+	for _, probe := range probes {
+		server := probe.status()
+		if !server.Healthy {
+			unhealthy = append(unhealthy, server.Name)
+		}
+		servers = append(servers, server)
+	}
+	cluster.Status.Nats.Servers = servers
+
+	var err error
+	switch {
+	case len(unhealthy) > 0:
+		err = fmt.Errorf("unhealthy servers: %s", strings.Join(unhealthy, ", "))
+	case statefulset.Status.ReadyReplicas < replicas:
+		err = fmt.Errorf("%d/%d replicas ready", statefulset.Status.ReadyReplicas, replicas)
+	}
+
+	return recordCondition(&cluster.Status.ConditionedStatus, conditionNatsHealthy, err)
+}
+
+// natsServerStatus probes a single server.
+func (r *ClusterReconciler) natsServerStatus(ctx context.Context, cluster *k8sv1alpha1.Cluster, name string) k8sv1alpha1.NatsServerStatus {
+	ctx, cancel := context.WithTimeout(ctx, natsMonitorTimeout)
+	defer cancel()
+
+	probe := natsMonitorProbe{name: name}
+	url := natsMonitorURL(cluster, name)
+	if probe.healthErr = r.natsMonitorGet(ctx, url+"/healthz", nil); probe.healthErr == nil {
+		probe.varzErr = r.natsMonitorGet(ctx, url+"/varz", &probe.varz)
+	}
+
+	return probe.status()
+}
+
+func natsMonitorURL(cluster *k8sv1alpha1.Cluster, server string) string {
+	return fmt.Sprintf("http://%s.natsd-%s.%s.svc:%d", server, cluster.GetName(), cluster.GetNamespace(), natsMonitorPort)
+}
+
+func (r *ClusterReconciler) natsMonitorGet(ctx context.Context, url string, out interface{}) error {
+	httpClient := r.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: natsMonitorTimeout}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", url, resp.Status)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
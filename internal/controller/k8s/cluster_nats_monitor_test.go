@@ -0,0 +1,129 @@
+package k8s
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// hangingMonitor answers the monitor endpoints of every server but the hung ones,
+// which never reply before the request is cancelled.
+type hangingMonitor map[string]bool
+
+func (m hangingMonitor) RoundTrip(req *http.Request) (*http.Response, error) {
+	if m[strings.SplitN(req.URL.Hostname(), ".", 2)[0]] {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	}
+
+	body := `{"status":"ok"}`
+	switch req.URL.Path {
+	case "/varz":
+		body = `{"server_id":"NSERVER","version":"2.10.24"}`
+	case "/leafz":
+		body = `{"leafs":[{"name":"hub","account":"AHUB","is_spoke":true}]}`
+	case "/gatewayz":
+		body = `{"name":"local","outbound_gateways":{"eu-west":{"configured":true}}}`
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}, nil
+}
+
+func TestProbeNatsServers(t *testing.T) {
+	cluster := &k8sv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "wasmcloud"}}
+	cluster.Spec.Nats.Managed = &k8sv1alpha1.NatsManagedSpec{Replicas: 3}
+
+	r := &ClusterReconciler{
+		HTTPClient: &http.Client{Transport: hangingMonitor{"nats-wasmcloud-1": true, "nats-wasmcloud-2": true}},
+	}
+
+	start := time.Now()
+	probes := r.probeNatsServers(context.Background(), cluster)
+	// probed one after the other, the hung servers would take a deadline per request
+	if elapsed := time.Since(start); elapsed > natsMonitorTimeout+time.Second {
+		t.Errorf("probing took %s, want one shared %s deadline", elapsed, natsMonitorTimeout)
+	}
+
+	got := map[string]bool{}
+	for _, probe := range probes {
+		server := probe.status()
+		got[server.Name] = server.Healthy
+		if server.Healthy && server.ServerID != "NSERVER" {
+			t.Errorf("%s: server ID %q, want NSERVER", server.Name, server.ServerID)
+		}
+	}
+	want := map[string]bool{"nats-wasmcloud-0": true, "nats-wasmcloud-1": false, "nats-wasmcloud-2": false}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("healthy servers: -want, +got:\n%s", diff)
+	}
+}
+
+func TestProbeNatsServersLeafz(t *testing.T) {
+	for name, remotes := range map[string][]k8sv1alpha1.NatsLeafNodeRemote{
+		"NoRemotes": nil,
+		"Remotes":   {{URLs: []string{"tls://hub.example.com:7422"}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cluster := &k8sv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "wasmcloud"}}
+			cluster.Spec.Nats.Managed = &k8sv1alpha1.NatsManagedSpec{Replicas: 2, LeafNodeRemotes: remotes}
+
+			r := &ClusterReconciler{HTTPClient: &http.Client{Transport: hangingMonitor{"nats-wasmcloud-1": true}}}
+			probes := r.probeNatsServers(context.Background(), cluster)
+
+			if len(remotes) == 0 {
+				for _, probe := range probes {
+					if probe.leafz != nil {
+						t.Errorf("%s: /leafz gathered without leafnode remotes", probe.name)
+					}
+				}
+				return
+			}
+			if probes[0].leafzErr != nil || len(probes[0].leafz.Leafs) != 1 {
+				t.Errorf("%s: leafz %+v, error %v", probes[0].name, probes[0].leafz, probes[0].leafzErr)
+			}
+			if probes[1].leafzErr == nil {
+				t.Errorf("%s: expected the hung /leafz to fail", probes[1].name)
+			}
+		})
+	}
+}
+
+func TestProbeNatsServersGatewayz(t *testing.T) {
+	for name, gateways := range map[string]*k8sv1alpha1.NatsGatewaysSpec{
+		"NoGateways": nil,
+		"Gateways":   {Remotes: []k8sv1alpha1.NatsGatewayRemote{{Name: "eu-west", URLs: []string{"tls://nats.eu.example.com:7222"}}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cluster := &k8sv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "wasmcloud"}}
+			cluster.Spec.Nats.Managed = &k8sv1alpha1.NatsManagedSpec{Replicas: 2, Gateways: gateways}
+
+			r := &ClusterReconciler{HTTPClient: &http.Client{Transport: hangingMonitor{"nats-wasmcloud-1": true}}}
+			probes := r.probeNatsServers(context.Background(), cluster)
+
+			if gateways == nil {
+				for _, probe := range probes {
+					if probe.gatewayz != nil {
+						t.Errorf("%s: /gatewayz gathered without gateway remotes", probe.name)
+					}
+				}
+				return
+			}
+			if probes[0].gatewayzErr != nil || len(probes[0].gatewayz.OutboundGateways) != 1 {
+				t.Errorf("%s: gatewayz %+v, error %v", probes[0].name, probes[0].gatewayz, probes[0].gatewayzErr)
+			}
+			if probes[1].gatewayzErr == nil {
+				t.Errorf("%s: expected the hung /gatewayz to fail", probes[1].name)
+			}
+		})
+	}
+}
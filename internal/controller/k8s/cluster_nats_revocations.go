@@ -0,0 +1,254 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/jwt/v2"
+	"github.com/nats-io/nats.go"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	"go.wasmcloud.dev/operator/internal/natsconn"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// servers answering a PING request are collected for this long
+const natsPingTimeout = time.Second
+
+// natsRevocation rejects the JWTs issued to a user up to At. Expires is when the last of them
+// expires, from then on the revocation rejects nothing.
+type natsRevocation struct {
+	At      time.Time `json:"at"`
+	Expires time.Time `json:"expires"`
+}
+
+// loadNatsRevocations returns the revoked users of the Cluster account.
+// Revocations live in a ConfigMap owned by the Cluster, so recording one triggers a Cluster reconcile,
+// which re-signs the account JWT and pushes it to the resolver.
+func loadNatsRevocations(ctx context.Context, apiClient client.Client, cluster *k8sv1alpha1.Cluster) (map[string]natsRevocation, error) {
+	var cm corev1.ConfigMap
+	if err := apiClient.Get(
+		ctx,
+		client.ObjectKey{Namespace: cluster.GetNamespace(), Name: cluster.NatsRevocations()},
+		&cm); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+
+	revocations := make(map[string]natsRevocation, len(cm.Data))
+	for user, raw := range cm.Data {
+		var revocation natsRevocation
+		if err := json.Unmarshal([]byte(raw), &revocation); err != nil {
+			return nil, fmt.Errorf("revocation of %s: %w", user, err)
+		}
+		revocations[user] = revocation
+	}
+
+	return revocations, nil
+}
+
+// pruneNatsRevocations drops the revocations whose JWTs have all expired and returns the others.
+// Those entries reject nothing and would only grow the ConfigMap and the account JWT.
+func pruneNatsRevocations(ctx context.Context, apiClient client.Client, cluster *k8sv1alpha1.Cluster, now time.Time) (map[string]natsRevocation, error) {
+	revocations, err := loadNatsRevocations(ctx, apiClient, cluster)
+	if err != nil || len(revocations) == 0 {
+		return revocations, err
+	}
+
+	var pruned []string
+	for user, revocation := range revocations {
+		if revocation.Expires.Before(now) {
+			pruned = append(pruned, user)
+			delete(revocations, user)
+		}
+	}
+	if len(pruned) == 0 {
+		return revocations, nil
+	}
+
+	var cm corev1.ConfigMap
+	if err := apiClient.Get(
+		ctx,
+		client.ObjectKey{Namespace: cluster.GetNamespace(), Name: cluster.NatsRevocations()},
+		&cm); err != nil {
+		return nil, err
+	}
+
+	patch := client.MergeFrom(cm.DeepCopy())
+	for _, user := range pruned {
+		delete(cm.Data, user)
+	}
+	if err := apiClient.Patch(ctx, &cm, patch); err != nil {
+		return nil, err
+	}
+
+	log.FromContext(ctx).Info("Pruned expired NATS revocations", "users", len(pruned))
+
+	return revocations, nil
+}
+
+// recordNatsRevocation adds user to the Cluster revocations. JWTs issued to user up to at are rejected
+// until the later of expires, the expiry of its current JWT, and the credentials TTL after at.
+// The expiry is kept with the revocation, so lowering the TTL doesn't release JWTs issued before.
+func recordNatsRevocation(ctx context.Context, apiClient client.Client, cluster *k8sv1alpha1.Cluster, user string, at time.Time, expires time.Time) error {
+	expires = latestTime(expires, at.Add(natsCredentialsTTL(cluster)))
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            cluster.NatsRevocations(),
+			Namespace:       cluster.GetNamespace(),
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(cluster, cluster.GroupVersionKind())},
+		},
+	}
+
+	_, err := controllerutil.CreateOrUpdate(ctx, apiClient, cm, func() error {
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+
+		revocation := natsRevocation{At: at.Truncate(time.Second), Expires: expires.Truncate(time.Second)}
+		if raw, ok := cm.Data[user]; ok {
+			// retried finalizers keep the first revocation time
+			var recorded natsRevocation
+			if err := json.Unmarshal([]byte(raw), &recorded); err == nil {
+				revocation.At = recorded.At
+				revocation.Expires = latestTime(recorded.Expires, revocation.Expires)
+			}
+		}
+
+		raw, err := json.Marshal(revocation)
+		if err != nil {
+			return err
+		}
+		cm.Data[user] = string(raw)
+		return nil
+	})
+
+	return err
+}
+
+func latestTime(a time.Time, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// revokeNatsUser revokes user from the Cluster account, whose current JWT expires at expires (zero when unknown).
+// Once the servers serve the re-signed account JWT, connections still open with the revoked
+// credentials are kicked. Returns an error while the revocation hasn't reached the servers yet.
+func revokeNatsUser(ctx context.Context, store natsSeedStore, connections *natsconn.Manager, cluster *k8sv1alpha1.Cluster, user string, expires time.Time) error {
+	logger := log.FromContext(ctx)
+
+	if err := recordNatsRevocation(ctx, store.client, cluster, user, time.Now(), expires); err != nil {
+		return err
+	}
+
+	if connections == nil {
+		return nil
+	}
+
+	seeds, err := store.clientSeeds(ctx, cluster)
+	if err != nil {
+		return err
+	}
+
+	accountKp, err := seeds.keyPair("account")
+	if err != nil {
+		return err
+	}
+
+	accountPub, err := accountKp.PublicKey()
+	if err != nil {
+		return err
+	}
+
+	nc, err := connections.SystemConn(ctx, client.ObjectKeyFromObject(cluster))
+	if err != nil {
+		return err
+	}
+
+	accountJWT, err := natsLookupAccount(ctx, nc, accountPub)
+	if err != nil {
+		return err
+	}
+
+	account, err := jwt.DecodeAccountClaims(accountJWT)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := account.Revocations[user]; !ok {
+		return fmt.Errorf("waiting for the revocation of %s to reach the servers", user)
+	}
+
+	kicked, err := natsKickUser(ctx, nc, user)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Revoked NATS user", "user", user, "kicked", kicked)
+
+	return nil
+}
+
+// natsKickUser disconnects every connection authenticated as user, across all servers.
+// Returns the number of connections closed.
+func natsKickUser(ctx context.Context, nc *nats.Conn, user string) (int, error) {
+	body, err := json.Marshal(map[string]string{"user": user})
+	if err != nil {
+		return 0, err
+	}
+
+	inbox := nats.NewInbox()
+	sub, err := nc.SubscribeSync(inbox)
+	if err != nil {
+		return 0, err
+	}
+	defer sub.Unsubscribe()
+
+	if err := nc.PublishRequest("$SYS.REQ.SERVER.PING.CONNZ", inbox, body); err != nil {
+		return 0, err
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, natsPingTimeout)
+	defer cancel()
+
+	kicked := 0
+	for {
+		msg, err := sub.NextMsgWithContext(pingCtx)
+		if err != nil {
+			// every server had its chance to answer
+			return kicked, nil
+		}
+
+		var connz struct {
+			Server struct {
+				ID string `json:"id"`
+			} `json:"server"`
+			Data struct {
+				Connections []struct {
+					CID uint64 `json:"cid"`
+				} `json:"connections"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(msg.Data, &connz); err != nil {
+			return kicked, err
+		}
+
+		for _, conn := range connz.Data.Connections {
+			kick, err := json.Marshal(map[string]uint64{"cid": conn.CID})
+			if err != nil {
+				return kicked, err
+			}
+			if err := natsAPIRequest(ctx, nc, fmt.Sprintf("$SYS.REQ.SERVER.%s.KICK", connz.Server.ID), kick, nil); err != nil {
+				return kicked, err
+			}
+			kicked++
+		}
+	}
+}
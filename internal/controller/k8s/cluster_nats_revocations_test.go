@@ -0,0 +1,121 @@
+package k8s
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPruneNatsRevocations(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	ttl := time.Hour
+
+	cases := map[string]struct {
+		// revoked is how long ago each user was revoked
+		revoked map[string]time.Duration
+		// lowerTTL shrinks the credentials TTL after the revocations were recorded
+		lowerTTL time.Duration
+		want     []string
+	}{
+		"Missing": {},
+		"Fresh": {
+			revoked: map[string]time.Duration{"UFRESH": ttl / 2},
+			want:    []string{"UFRESH"},
+		},
+		"Expired": {
+			revoked: map[string]time.Duration{"UFRESH": ttl / 2, "UEXPIRED": ttl + time.Second},
+			want:    []string{"UFRESH"},
+		},
+		"AllExpired": {
+			revoked: map[string]time.Duration{"UEXPIRED": 2 * ttl},
+		},
+		"LoweredTTL": {
+			// JWTs issued before the change keep their lifetime, and so do their revocations
+			revoked:  map[string]time.Duration{"UFRESH": ttl / 2, "UEXPIRED": ttl + time.Second},
+			lowerTTL: time.Minute,
+			want:     []string{"UFRESH"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			cluster := testNatsCluster(k8sv1alpha1.NatsManagedSpec{Replicas: 1, CredentialsTTL: &metav1.Duration{Duration: ttl}})
+			apiClient := newFakeClient(t, cluster)
+
+			for user, ago := range tc.revoked {
+				if err := recordNatsRevocation(ctx, apiClient, cluster, user, now.Add(-ago), time.Time{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tc.lowerTTL > 0 {
+				cluster.Spec.Nats.Managed.CredentialsTTL.Duration = tc.lowerTTL
+			}
+
+			revocations, err := pruneNatsRevocations(ctx, apiClient, cluster, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the ConfigMap keeps only the live revocations
+			stored, err := loadNatsRevocations(ctx, apiClient, cluster)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, got := range []map[string]natsRevocation{revocations, stored} {
+				for user, revocation := range got {
+					if want := now.Add(-tc.revoked[user]); !revocation.At.Equal(want) {
+						t.Errorf("%s revoked at %s, want %s", user, revocation.At, want)
+					}
+				}
+				if diff := cmp.Diff(tc.want, slices.Sorted(maps.Keys(got))); diff != "" {
+					t.Errorf("revoked users: -want, +got:\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestRecordNatsRevocation(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	cluster := testNatsCluster(k8sv1alpha1.NatsManagedSpec{Replicas: 1, CredentialsTTL: &metav1.Duration{Duration: time.Hour}})
+	apiClient := newFakeClient(t, cluster)
+
+	// the current JWT was issued with a day long TTL, before the TTL was lowered to an hour
+	jwtExpires := now.Add(20 * time.Hour)
+	if err := recordNatsRevocation(ctx, apiClient, cluster, "UREVOKED", now, jwtExpires); err != nil {
+		t.Fatal(err)
+	}
+	// a retried finalizer doesn't move the revocation
+	if err := recordNatsRevocation(ctx, apiClient, cluster, "UREVOKED", now.Add(time.Minute), time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	revocations, err := loadNatsRevocations(ctx, apiClient, cluster)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]natsRevocation{"UREVOKED": {At: now, Expires: jwtExpires}}
+	if diff := cmp.Diff(want, revocations); diff != "" {
+		t.Errorf("revocations: -want, +got:\n%s", diff)
+	}
+
+	for _, at := range []time.Time{now.Add(2 * time.Hour), jwtExpires.Add(time.Second)} {
+		revocations, err := pruneNatsRevocations(ctx, apiClient, cluster, at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, kept := revocations["UREVOKED"]
+		if wantKept := at.Before(jwtExpires); kept != wantKept {
+			t.Errorf("at %s: revocation kept %t, want %t", at, kept, wantKept)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	natsScalePhaseUp         = "ScalingUp"
+	natsScalePhaseRemovePeer = "RemovingPeer"
+	natsScalePhaseWaitReady  = "WaitingForReady"
+	natsScalePhaseReload     = "Reloading"
+
+	natsRequestTimeout = 10 * time.Second
+
+	// JetStream error code for a peer that isn't part of the meta group anymore.
+	jsErrCodeServerNotMember = 10044
+)
+
+// natsAPIResponse is the error envelope shared by $SYS and $JS API replies.
+type natsAPIResponse struct {
+	Error *struct {
+		Code        int    `json:"code"`
+		ErrCode     int    `json:"err_code"`
+		Description string `json:"description"`
+	} `json:"error,omitempty"`
+}
+
+// reconcileNatsScale walks the NATS cluster towards the requested size and returns
+// the replica count the StatefulSet should run right now.
+// Servers are removed one at a time: the departing server leaves the JetStream meta group
+// while quorum still holds, enters lame duck mode, and only then is its pod stopped.
+// Routes keep pointing at a departing server until its pod is gone, see natsRouteReplicas.
+// Once the cluster settles, the config reloader hands the remaining servers the shorter routes.
+func (r *ClusterReconciler) reconcileNatsScale(ctx context.Context, cluster *k8sv1alpha1.Cluster) (int32, error) {
+	want := cluster.Spec.Nats.Managed.Replicas
+
+	var statefulset appsv1.StatefulSet
+	if err := r.Get(
+		ctx,
+		client.ObjectKey{Namespace: cluster.GetNamespace(), Name: "nats-" + cluster.GetName()},
+		&statefulset); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			cluster.Status.Nats.Scale = nil
+			return want, nil
+		}
+		return want, err
+	}
+
+	current := want
+	if statefulset.Spec.Replicas != nil {
+		current = *statefulset.Spec.Replicas
+	}
+
+	scale := cluster.Status.Nats.Scale
+	if scale == nil {
+		if current == want {
+			return want, nil
+		}
+		scale = &k8sv1alpha1.NatsScaleStatus{From: current}
+		cluster.Status.Nats.Scale = scale
+	}
+	scale.To = want
+	scale.Message = ""
+
+	switch {
+	case want > current:
+		scale.Phase = natsScalePhaseUp
+		return want, nil
+	case want < current:
+		return r.scaleDownNats(ctx, cluster, &statefulset, current)
+	}
+
+	if !statefulSetSettled(&statefulset, want) {
+		scale.Phase = natsScalePhaseWaitReady
+		scale.Message = fmt.Sprintf("%d/%d replicas ready", statefulset.Status.ReadyReplicas, want)
+		return want, nil
+	}
+
+	scale.Phase = natsScalePhaseReload
+	if pending := r.natsRoutesPending(ctx, cluster); pending > 0 {
+		scale.Message = fmt.Sprintf("%d servers pending route reload", pending)
+		return want, nil
+	}
+
+	log.FromContext(ctx).Info("NATS resize complete", "from", scale.From, "to", want)
+	cluster.Status.Nats.Scale = nil
+
+	return want, nil
+}
+
+// natsRouteReplicas returns how many servers the routes point at. While scaling down, a departing
+// server stays routed until its pod is gone: it leaves the JetStream meta group and enters lame duck
+// mode with its routes intact, and the remaining servers reload the shorter routes afterwards.
+func (r *ClusterReconciler) natsRouteReplicas(ctx context.Context, cluster *k8sv1alpha1.Cluster) (int32, error) {
+	want := cluster.Spec.Nats.Managed.Replicas
+
+	var statefulset appsv1.StatefulSet
+	if err := r.Get(
+		ctx,
+		client.ObjectKey{Namespace: cluster.GetNamespace(), Name: "nats-" + cluster.GetName()},
+		&statefulset); err != nil {
+		return want, client.IgnoreNotFound(err)
+	}
+
+	return max(want, statefulset.Status.Replicas), nil
+}
+
+// scaleDownNats removes the highest ordinal server and returns the new StatefulSet size.
+func (r *ClusterReconciler) scaleDownNats(ctx context.Context, cluster *k8sv1alpha1.Cluster, statefulset *appsv1.StatefulSet, current int32) (int32, error) {
+	scale := cluster.Status.Nats.Scale
+	scale.Phase = natsScalePhaseRemovePeer
+
+	// never take a server away from a degraded cluster
+	if !statefulSetSettled(statefulset, current) {
+		scale.Message = fmt.Sprintf("waiting for %d/%d replicas before removing a server", statefulset.Status.ReadyReplicas, current)
+		return current, nil
+	}
+
+	nc, err := r.natsSystemConn(ctx, cluster)
+	if err != nil {
+		return current, err
+	}
+
+	departing := fmt.Sprintf("nats-%s-%d", cluster.GetName(), current-1)
+	scale.Message = "removing " + departing
+
+	if err := natsRemovePeer(ctx, nc, departing); err != nil {
+		return current, fmt.Errorf("removing jetstream peer %s: %w", departing, err)
+	}
+
+	server := r.natsServerStatus(ctx, cluster, departing)
+	if server.ServerID != "" {
+		if err := natsServerRequest(ctx, nc, server.ServerID, "LDM"); err != nil {
+			return current, fmt.Errorf("lame duck %s: %w", departing, err)
+		}
+	}
+
+	log.FromContext(ctx).Info("Removed NATS server", "server", departing)
+
+	return current - 1, nil
+}
+
+// natsRoutesPending counts the servers whose routes don't match the cluster size yet.
+// The mounted config lags behind the ConfigMap, so servers are checked again on the next reconcile.
+func (r *ClusterReconciler) natsRoutesPending(ctx context.Context, cluster *k8sv1alpha1.Cluster) int {
+	probes := r.probeNatsServers(ctx, cluster)
+
+	pending := 0
+	for _, probe := range probes {
+		if probe.varzErr != nil || len(probe.varz.Cluster.URLs) != len(probes) {
+			pending++
+		}
+	}
+
+	return pending
+}
+
+func (r *ClusterReconciler) natsSystemConn(ctx context.Context, cluster *k8sv1alpha1.Cluster) (*nats.Conn, error) {
+	if r.Connections == nil {
+		return nil, fmt.Errorf("no nats connections available")
+	}
+	return r.Connections.SystemConn(ctx, client.ObjectKeyFromObject(cluster))
+}
+
+// statefulSetSettled reports whether exactly replicas pods exist and all of them are ready.
+func statefulSetSettled(statefulset *appsv1.StatefulSet, replicas int32) bool {
+	return statefulset.Status.ObservedGeneration >= statefulset.Generation &&
+		statefulset.Status.Replicas == replicas &&
+		statefulset.Status.ReadyReplicas == replicas
+}
+
+// natsServerRequest sends a $SYS.REQ.SERVER request, such as LDM, to a single server.
+func natsServerRequest(ctx context.Context, nc *nats.Conn, serverID string, op string) error {
+	return natsAPIRequest(ctx, nc, fmt.Sprintf("$SYS.REQ.SERVER.%s.%s", serverID, op), nil, nil)
+}
+
+// natsRemovePeer drops a server from the JetStream meta group.
+func natsRemovePeer(ctx context.Context, nc *nats.Conn, server string) error {
+	body, err := json.Marshal(map[string]string{"peer": server})
+	if err != nil {
+		return err
+	}
+
+	return natsAPIRequest(ctx, nc, "$JS.API.SERVER.REMOVE", body, func(resp *natsAPIResponse) bool {
+		return resp.Error.ErrCode == jsErrCodeServerNotMember
+	})
+}
+
+func natsAPIRequest(ctx context.Context, nc *nats.Conn, subject string, body []byte, ignore func(*natsAPIResponse) bool) error {
+	ctx, cancel := context.WithTimeout(ctx, natsRequestTimeout)
+	defer cancel()
+
+	msg, err := nc.RequestWithContext(ctx, subject, body)
+	if err != nil {
+		return err
+	}
+
+	var resp natsAPIResponse
+	if err := json.Unmarshal(msg.Data, &resp); err != nil {
+		return err
+	}
+
+	if resp.Error == nil || (ignore != nil && ignore(&resp)) {
+		return nil
+	}
+
+	return fmt.Errorf("%s: %s", subject, resp.Error.Description)
+}
@@ -0,0 +1,522 @@
+package k8s
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nats-io/jwt/v2"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nkeys"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	"go.wasmcloud.dev/operator/internal/natsconn"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// fakeSystemServer speaks enough of the NATS protocol to answer the $SYS and $JS API requests
+// of the operator, and records them in order.
+type fakeSystemServer struct {
+	listener net.Listener
+
+	lock     sync.Mutex
+	requests []string
+}
+
+func newFakeSystemServer(t *testing.T) *fakeSystemServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	s := &fakeSystemServer{listener: listener}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+// Dial connects to the fake server whatever the address, standing in for the Cluster Service.
+func (s *fakeSystemServer) Dial(network string, _ string) (net.Conn, error) {
+	return net.Dial(network, s.listener.Addr().String())
+}
+
+func (s *fakeSystemServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(`INFO {"server_id":"FAKE","version":"2.10.0","proto":1,"max_payload":1048576}` + "\r\n")); err != nil {
+		return
+	}
+
+	subs := map[string]string{}
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		var reply string
+		switch strings.ToUpper(fields[0]) {
+		case "PING":
+			reply = "PONG\r\n"
+		case "SUB":
+			subs[fields[len(fields)-1]] = fields[1]
+		case "UNSUB":
+			delete(subs, fields[1])
+		case "PUB":
+			size, err := strconv.Atoi(fields[len(fields)-1])
+			if err != nil {
+				return
+			}
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return
+			}
+			if len(fields) != 4 {
+				continue
+			}
+
+			s.lock.Lock()
+			s.requests = append(s.requests, strings.TrimSpace(fields[1]+" "+string(payload[:size])))
+			s.lock.Unlock()
+
+			for sid, subject := range subs {
+				if natsSubjectMatches(subject, fields[2]) {
+					reply = fmt.Sprintf("MSG %s %s 2\r\n{}\r\n", fields[2], sid)
+					break
+				}
+			}
+		}
+
+		if reply != "" {
+			if _, err := conn.Write([]byte(reply)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// takeRequests returns the requests received so far and forgets them.
+func (s *fakeSystemServer) takeRequests() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	requests := s.requests
+	s.requests = nil
+	return requests
+}
+
+func natsSubjectMatches(pattern string, subject string) bool {
+	patternTokens := strings.Split(pattern, ".")
+	subjectTokens := strings.Split(subject, ".")
+	for i, token := range patternTokens {
+		if token == ">" {
+			return len(subjectTokens) > i
+		}
+		if i >= len(subjectTokens) || (token != "*" && token != subjectTokens[i]) {
+			return false
+		}
+	}
+	return len(patternTokens) == len(subjectTokens)
+}
+
+// monitorStub answers monitor requests from canned bodies, keyed by URL.
+// Other URLs are unavailable, like a server that is down.
+type monitorStub map[string]string
+
+func (m monitorStub) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, ok := m[req.URL.String()]
+	if !ok {
+		return &http.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Status:     "503 Service Unavailable",
+			Body:       io.NopCloser(strings.NewReader("")),
+		}, nil
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}, nil
+}
+
+// serve answers for a running server, reporting the given number of routes.
+func (m monitorStub) serve(cluster *k8sv1alpha1.Cluster, ordinal int, routes int) {
+	name := fmt.Sprintf("nats-%s-%d", cluster.GetName(), ordinal)
+	urls := make([]string, routes)
+	for i := range urls {
+		urls[i] = fmt.Sprintf(`"nats-%s-%d:6222"`, cluster.GetName(), i)
+	}
+
+	m[natsMonitorURL(cluster, name)+"/healthz"] = `{"status":"ok"}`
+	m[natsMonitorURL(cluster, name)+"/varz"] = fmt.Sprintf(`{"server_id":"NSERVER%d","cluster":{"urls":[%s]}}`, ordinal, strings.Join(urls, ","))
+}
+
+func testSystemCreds(t *testing.T) []byte {
+	t.Helper()
+
+	accountKp, err := nkeys.CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	userKp, err := nkeys.CreateUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	userPub, err := userKp.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	userSeed, err := userKp.Seed()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userJWT, err := jwt.NewUserClaims(userPub).Encode(accountKp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds, err := jwt.FormatUserConfig(userJWT, userSeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return creds
+}
+
+// newScaleReconciler returns a reconciler whose system connection reaches server and whose
+// monitor requests are answered by monitor.
+func newScaleReconciler(t *testing.T, server *fakeSystemServer, monitor monitorStub, objs ...client.Object) *ClusterReconciler {
+	t.Helper()
+
+	cluster := objs[0].(*k8sv1alpha1.Cluster)
+	systemSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: cluster.GetNamespace(), Name: cluster.NatsSystemSecret()},
+		Data:       map[string][]byte{"user.jwt": testSystemCreds(t)},
+	}
+
+	apiClient := newFakeClient(t, append(objs, systemSecret)...)
+	connections := natsconn.NewManager(apiClient, nats.SetCustomDialer(server))
+	t.Cleanup(func() { connections.Remove(client.ObjectKeyFromObject(cluster)) })
+
+	return &ClusterReconciler{
+		Client:      apiClient,
+		Connections: connections,
+		HTTPClient:  &http.Client{Transport: monitor},
+	}
+}
+
+func TestReconcileNatsScale(t *testing.T) {
+	cases := map[string]struct {
+		want         int32
+		statefulSet  [2]int32
+		scale        *k8sv1alpha1.NatsScaleStatus
+		servers      []int
+		routes       int
+		wantReplicas int32
+		wantScale    *k8sv1alpha1.NatsScaleStatus
+		wantRequests []string
+	}{
+		"Steady": {
+			want:         3,
+			statefulSet:  [2]int32{3, 3},
+			wantReplicas: 3,
+		},
+		"NotCreated": {
+			want:         3,
+			wantReplicas: 3,
+		},
+		"ScaleUp": {
+			want:         3,
+			statefulSet:  [2]int32{1, 1},
+			wantReplicas: 3,
+			wantScale:    &k8sv1alpha1.NatsScaleStatus{Phase: natsScalePhaseUp, From: 1, To: 3},
+		},
+		"ScaleUpWaitingForReady": {
+			want:         3,
+			statefulSet:  [2]int32{3, 1},
+			scale:        &k8sv1alpha1.NatsScaleStatus{Phase: natsScalePhaseUp, From: 1, To: 3},
+			wantReplicas: 3,
+			wantScale:    &k8sv1alpha1.NatsScaleStatus{Phase: natsScalePhaseWaitReady, From: 1, To: 3, Message: "1/3 replicas ready"},
+		},
+		"ScaleDown": {
+			want:         1,
+			statefulSet:  [2]int32{3, 3},
+			servers:      []int{0, 1, 2},
+			routes:       3,
+			wantReplicas: 2,
+			wantScale:    &k8sv1alpha1.NatsScaleStatus{Phase: natsScalePhaseRemovePeer, From: 3, To: 1, Message: "removing nats-wasmcloud-2"},
+			wantRequests: []string{
+				`$JS.API.SERVER.REMOVE {"peer":"nats-wasmcloud-2"}`,
+				"$SYS.REQ.SERVER.NSERVER2.LDM",
+			},
+		},
+		"ScaleDownUnready": {
+			want:         1,
+			statefulSet:  [2]int32{3, 2},
+			wantReplicas: 3,
+			wantScale:    &k8sv1alpha1.NatsScaleStatus{Phase: natsScalePhaseRemovePeer, From: 3, To: 1, Message: "waiting for 2/3 replicas before removing a server"},
+		},
+		"Reloading": {
+			want:         1,
+			statefulSet:  [2]int32{1, 1},
+			scale:        &k8sv1alpha1.NatsScaleStatus{Phase: natsScalePhaseRemovePeer, From: 3, To: 1},
+			servers:      []int{0},
+			routes:       3,
+			wantReplicas: 1,
+			// the config reloader picks up the new routes, the operator only waits
+			wantScale: &k8sv1alpha1.NatsScaleStatus{Phase: natsScalePhaseReload, From: 3, To: 1, Message: "1 servers pending route reload"},
+		},
+		"Complete": {
+			want:         1,
+			statefulSet:  [2]int32{1, 1},
+			scale:        &k8sv1alpha1.NatsScaleStatus{Phase: natsScalePhaseReload, From: 3, To: 1},
+			servers:      []int{0},
+			routes:       1,
+			wantReplicas: 1,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cluster := testNatsCluster(k8sv1alpha1.NatsManagedSpec{Replicas: tc.want})
+			cluster.Status.Nats.Scale = tc.scale
+
+			objs := []client.Object{cluster}
+			if tc.statefulSet[0] > 0 {
+				objs = append(objs, testNatsStatefulSet(cluster, tc.statefulSet[0], tc.statefulSet[1]))
+			}
+
+			monitor := monitorStub{}
+			for _, ordinal := range tc.servers {
+				monitor.serve(cluster, ordinal, tc.routes)
+			}
+
+			server := newFakeSystemServer(t)
+			r := newScaleReconciler(t, server, monitor, objs...)
+
+			replicas, err := r.reconcileNatsScale(context.Background(), cluster)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tc.wantReplicas, replicas); diff != "" {
+				t.Errorf("replicas: -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantScale, cluster.Status.Nats.Scale); diff != "" {
+				t.Errorf("scale status: -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantRequests, server.takeRequests()); diff != "" {
+				t.Errorf("nats requests: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestReconcileNatsScaleDown walks a 3 server cluster down to 1, one server at a time.
+func TestReconcileNatsScaleDown(t *testing.T) {
+	ctx := context.Background()
+	cluster := testNatsCluster(k8sv1alpha1.NatsManagedSpec{Replicas: 1})
+	statefulSet := testNatsStatefulSet(cluster, 3, 3)
+
+	monitor := monitorStub{}
+	server := newFakeSystemServer(t)
+	r := newScaleReconciler(t, server, monitor, cluster, statefulSet)
+
+	steps := []struct {
+		routes       int
+		wantReplicas int32
+		wantPhase    string
+		wantRequests []string
+	}{
+		{
+			routes:       3,
+			wantReplicas: 2,
+			wantPhase:    natsScalePhaseRemovePeer,
+			wantRequests: []string{`$JS.API.SERVER.REMOVE {"peer":"nats-wasmcloud-2"}`, "$SYS.REQ.SERVER.NSERVER2.LDM"},
+		},
+		{
+			routes:       3,
+			wantReplicas: 1,
+			wantPhase:    natsScalePhaseRemovePeer,
+			wantRequests: []string{`$JS.API.SERVER.REMOVE {"peer":"nats-wasmcloud-1"}`, "$SYS.REQ.SERVER.NSERVER1.LDM"},
+		},
+		{
+			// the remaining server still routes to the departed ones until the config reloader runs
+			routes:       3,
+			wantReplicas: 1,
+			wantPhase:    natsScalePhaseReload,
+		},
+		{
+			routes:       1,
+			wantReplicas: 1,
+		},
+	}
+
+	for i, step := range steps {
+		for ordinal := 0; ordinal < int(*statefulSet.Spec.Replicas); ordinal++ {
+			monitor.serve(cluster, ordinal, step.routes)
+		}
+
+		replicas, err := r.reconcileNatsScale(ctx, cluster)
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		if diff := cmp.Diff(step.wantReplicas, replicas); diff != "" {
+			t.Errorf("step %d replicas: -want, +got:\n%s", i, diff)
+		}
+
+		phase := ""
+		if cluster.Status.Nats.Scale != nil {
+			phase = cluster.Status.Nats.Scale.Phase
+		}
+		if diff := cmp.Diff(step.wantPhase, phase); diff != "" {
+			t.Errorf("step %d phase: -want, +got:\n%s", i, diff)
+		}
+		if diff := cmp.Diff(step.wantRequests, server.takeRequests()); diff != "" {
+			t.Errorf("step %d nats requests: -want, +got:\n%s", i, diff)
+		}
+
+		// the StatefulSet shrinks and settles
+		statefulSet.Spec.Replicas = &replicas
+		if err := r.Update(ctx, statefulSet); err != nil {
+			t.Fatal(err)
+		}
+		statefulSet.Status.Replicas = replicas
+		statefulSet.Status.ReadyReplicas = replicas
+		if err := r.Status().Update(ctx, statefulSet); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestNatsRoutesPending(t *testing.T) {
+	cluster := testNatsCluster(k8sv1alpha1.NatsManagedSpec{Replicas: 3})
+
+	monitor := monitorStub{}
+	// nats-wasmcloud-0 has the new routes, nats-wasmcloud-1 the old ones and nats-wasmcloud-2 is down
+	monitor.serve(cluster, 0, 3)
+	monitor.serve(cluster, 1, 2)
+
+	server := newFakeSystemServer(t)
+	r := newScaleReconciler(t, server, monitor, cluster)
+
+	if diff := cmp.Diff(2, r.natsRoutesPending(context.Background(), cluster)); diff != "" {
+		t.Errorf("pending: -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff([]string(nil), server.takeRequests()); diff != "" {
+		t.Errorf("nats requests: -want, +got:\n%s", diff)
+	}
+}
+
+func TestNatsRouteReplicas(t *testing.T) {
+	cases := map[string]struct {
+		want        int32
+		statefulSet int32
+		wantRoutes  int32
+	}{
+		"NotCreated": {
+			want:       3,
+			wantRoutes: 3,
+		},
+		"Steady": {
+			want:        3,
+			statefulSet: 3,
+			wantRoutes:  3,
+		},
+		"ScaleUp": {
+			// new servers are routed to before their pods start
+			want:        3,
+			statefulSet: 1,
+			wantRoutes:  3,
+		},
+		"ScaleDown": {
+			// departing servers keep their routes until their pods are gone
+			want:        1,
+			statefulSet: 2,
+			wantRoutes:  2,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cluster := testNatsCluster(k8sv1alpha1.NatsManagedSpec{Replicas: tc.want})
+			objs := []client.Object{cluster}
+			if tc.statefulSet > 0 {
+				objs = append(objs, testNatsStatefulSet(cluster, tc.statefulSet, tc.statefulSet))
+			}
+			r := &ClusterReconciler{Client: newFakeClient(t, objs...)}
+
+			got, err := r.natsRouteReplicas(context.Background(), cluster)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.wantRoutes, got); diff != "" {
+				t.Errorf("route replicas: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStatefulSetSettled(t *testing.T) {
+	cases := map[string]struct {
+		generation int64
+		status     appsv1.StatefulSetStatus
+		want       bool
+	}{
+		"Settled": {
+			generation: 2,
+			status:     appsv1.StatefulSetStatus{ObservedGeneration: 2, Replicas: 3, ReadyReplicas: 3},
+			want:       true,
+		},
+		"NotObserved": {
+			generation: 3,
+			status:     appsv1.StatefulSetStatus{ObservedGeneration: 2, Replicas: 3, ReadyReplicas: 3},
+		},
+		"Unready": {
+			generation: 2,
+			status:     appsv1.StatefulSetStatus{ObservedGeneration: 2, Replicas: 3, ReadyReplicas: 2},
+		},
+		"ExtraPods": {
+			generation: 2,
+			status:     appsv1.StatefulSetStatus{ObservedGeneration: 2, Replicas: 4, ReadyReplicas: 4},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			statefulSet := &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Generation: tc.generation},
+				Status:     tc.status,
+			}
+			if diff := cmp.Diff(tc.want, statefulSetSettled(statefulSet, 3)); diff != "" {
+				t.Errorf("statefulSetSettled(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/nats-io/nkeys"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	"go.wasmcloud.dev/operator/internal/envelope"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// natsSeeds maps seed names to plaintext nkey seeds.
+type natsSeeds map[string][]byte
+
+func (s natsSeeds) keyPair(name string) (nkeys.KeyPair, error) {
+	raw, ok := s[name]
+	if !ok {
+		return nil, fmt.Errorf("missing %s seed", name)
+	}
+	return nkeys.FromSeed(raw)
+}
+
+// natsSeedStore reads and writes the Cluster seed Secrets. The operator seed is kept apart from
+// the seeds client credentials are issued with, and seeds are sealed when an encrypter is set,
+// so they are only ever decrypted in the operator process.
+type natsSeedStore struct {
+	client    client.Client
+	encrypter envelope.KeyEncrypter
+}
+
+// load returns the seeds held in secretName. Missing Secrets hold no seeds.
+// Plaintext seeds left from before encryption was enabled are read as-is.
+func (s natsSeedStore) load(ctx context.Context, cluster *k8sv1alpha1.Cluster, secretName string) (natsSeeds, error) {
+	var secret corev1.Secret
+	if err := s.client.Get(
+		ctx,
+		client.ObjectKey{Namespace: cluster.GetNamespace(), Name: secretName},
+		&secret); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			return natsSeeds{}, nil
+		}
+		return nil, err
+	}
+
+	return s.open(ctx, secret.Data)
+}
+
+func (s natsSeedStore) open(ctx context.Context, data map[string][]byte) (natsSeeds, error) {
+	seeds := make(natsSeeds, len(data))
+	for name, value := range data {
+		if !envelope.IsSealed(value) {
+			seeds[name] = value
+			continue
+		}
+
+		if s.encrypter == nil {
+			return nil, fmt.Errorf("%s seed is encrypted, but no seed encryption is configured", name)
+		}
+
+		plaintext, err := envelope.Open(ctx, s.encrypter, value)
+		if err != nil {
+			return nil, fmt.Errorf("decrypting %s seed: %w", name, err)
+		}
+		seeds[name] = plaintext
+	}
+
+	return seeds, nil
+}
+
+// save writes seeds to secretName, sealing them when an encrypter is set.
+// Stored values are kept when their plaintext didn't change, as sealing isn't deterministic.
+func (s natsSeedStore) save(ctx context.Context, cluster *k8sv1alpha1.Cluster, secretName string, seeds natsSeeds) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            secretName,
+			Namespace:       cluster.GetNamespace(),
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(cluster, cluster.GroupVersionKind())},
+		},
+	}
+
+	_, err := controllerutil.CreateOrUpdate(ctx, s.client, secret, func() error {
+		data := make(map[string][]byte, len(seeds))
+		for name, seed := range seeds {
+			if stored, ok := secret.Data[name]; ok && s.current(ctx, stored, seed) {
+				data[name] = stored
+				continue
+			}
+
+			if s.encrypter == nil {
+				data[name] = seed
+				continue
+			}
+
+			sealed, err := envelope.Seal(ctx, s.encrypter, seed)
+			if err != nil {
+				return fmt.Errorf("encrypting %s seed: %w", name, err)
+			}
+			data[name] = sealed
+		}
+
+		secret.Data = data
+		return nil
+	})
+
+	return err
+}
+
+// current reports whether stored holds seed in the form the store writes.
+func (s natsSeedStore) current(ctx context.Context, stored []byte, seed []byte) bool {
+	if s.encrypter == nil || !envelope.IsSealed(stored) {
+		return s.encrypter == nil && bytes.Equal(stored, seed)
+	}
+
+	plaintext, err := envelope.Open(ctx, s.encrypter, stored)
+	return err == nil && bytes.Equal(plaintext, seed)
+}
+
+// clientSeeds returns the account and user seeds client credentials are issued with.
+func (s natsSeedStore) clientSeeds(ctx context.Context, cluster *k8sv1alpha1.Cluster) (natsSeeds, error) {
+	return s.load(ctx, cluster, cluster.NatsSeedSecret())
+}
+
+// operatorSeeds returns the operator seed, and the operator JWT when one was supplied.
+func (s natsSeedStore) operatorSeeds(ctx context.Context, cluster *k8sv1alpha1.Cluster) (natsSeeds, error) {
+	return s.load(ctx, cluster, cluster.NatsOperatorSeedSecret())
+}
@@ -0,0 +1,186 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const natsDataVolume = "data"
+
+// natsVolumeClaimTemplates renders the JetStream volume claim, or nothing when storage isn't configured.
+func natsVolumeClaimTemplates(cluster *k8sv1alpha1.Cluster) []corev1.PersistentVolumeClaim {
+	storage := cluster.Spec.Nats.Managed.Storage
+	if storage == nil {
+		return nil
+	}
+
+	return []corev1.PersistentVolumeClaim{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: natsDataVolume,
+			},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				StorageClassName: storage.StorageClassName,
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceStorage: storage.Size,
+					},
+				},
+			},
+		},
+	}
+}
+
+func natsRetentionPolicy(cluster *k8sv1alpha1.Cluster) *appsv1.StatefulSetPersistentVolumeClaimRetentionPolicy {
+	storage := cluster.Spec.Nats.Managed.Storage
+	if storage == nil || storage.Retention == "" {
+		return nil
+	}
+
+	return &appsv1.StatefulSetPersistentVolumeClaimRetentionPolicy{
+		WhenDeleted: storage.Retention,
+		WhenScaled:  storage.Retention,
+	}
+}
+
+// reconcileNatsStorage handles changes to the StatefulSet volume claim templates, which Kubernetes
+// doesn't allow to be updated in place. Existing claims are expanded, then the StatefulSet is deleted
+// without its pods so the next reconcile can recreate it with the new templates.
+// Returns true while the StatefulSet is being recreated.
+func (r *ClusterReconciler) reconcileNatsStorage(ctx context.Context, cluster *k8sv1alpha1.Cluster, claims []corev1.PersistentVolumeClaim) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	var statefulset appsv1.StatefulSet
+	if err := r.Get(
+		ctx,
+		client.ObjectKey{Namespace: cluster.GetNamespace(), Name: "nats-" + cluster.GetName()},
+		&statefulset); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+
+	if !statefulset.DeletionTimestamp.IsZero() {
+		return true, fmt.Errorf("waiting for statefulset %s to be deleted", statefulset.GetName())
+	}
+
+	current := statefulset.Spec.VolumeClaimTemplates
+	if volumeClaimTemplatesEqual(current, claims) {
+		return false, nil
+	}
+
+	if len(current) > 0 && len(claims) > 0 {
+		currentSize := current[0].Spec.Resources.Requests[corev1.ResourceStorage]
+		wantSize := claims[0].Spec.Resources.Requests[corev1.ResourceStorage]
+
+		switch wantSize.Cmp(currentSize) {
+		case -1:
+			return false, fmt.Errorf("nats storage can't shrink from %s to %s", currentSize.String(), wantSize.String())
+		case 1:
+			if err := r.expandNatsVolumes(ctx, cluster, &statefulset, claims[0]); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	logger.Info("NATS volume claims changed, recreating statefulset", "statefulset", statefulset.GetName())
+	orphan := metav1.DeletePropagationOrphan
+	if err := r.Delete(ctx, &statefulset, &client.DeleteOptions{PropagationPolicy: &orphan}); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+
+	return true, nil
+}
+
+// expandNatsVolumes grows the existing server volumes to the size of claim. Volumes of a
+// StorageClass that doesn't allow expansion are refused before any of them is patched.
+func (r *ClusterReconciler) expandNatsVolumes(ctx context.Context, cluster *k8sv1alpha1.Cluster, statefulset *appsv1.StatefulSet, claim corev1.PersistentVolumeClaim) error {
+	size := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+
+	replicas := int32(1)
+	if statefulset.Spec.Replicas != nil {
+		replicas = *statefulset.Spec.Replicas
+	}
+
+	var expand []*corev1.PersistentVolumeClaim
+	for i := 0; i < int(replicas); i++ {
+		var pvc corev1.PersistentVolumeClaim
+		if err := r.Get(
+			ctx,
+			client.ObjectKey{Namespace: cluster.GetNamespace(), Name: fmt.Sprintf("%s-%s-%d", claim.GetName(), statefulset.GetName(), i)},
+			&pvc); err != nil {
+			if client.IgnoreNotFound(err) == nil {
+				continue
+			}
+			return err
+		}
+
+		current := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		if current.Cmp(size) >= 0 {
+			continue
+		}
+
+		if err := r.checkVolumeExpansion(ctx, &pvc); err != nil {
+			return err
+		}
+		expand = append(expand, &pvc)
+	}
+
+	for _, pvc := range expand {
+		patch := client.MergeFrom(pvc.DeepCopy())
+		pvc.Spec.Resources.Requests[corev1.ResourceStorage] = size
+		if err := r.Patch(ctx, pvc, patch); err != nil {
+			return fmt.Errorf("expanding %s: %w", pvc.GetName(), err)
+		}
+	}
+
+	return nil
+}
+
+// checkVolumeExpansion fails when the StorageClass of a volume doesn't allow expansion.
+// Volumes without a class are left to the API server.
+func (r *ClusterReconciler) checkVolumeExpansion(ctx context.Context, pvc *corev1.PersistentVolumeClaim) error {
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		return nil
+	}
+
+	var storageClass storagev1.StorageClass
+	if err := r.Get(ctx, client.ObjectKey{Name: *pvc.Spec.StorageClassName}, &storageClass); err != nil {
+		return err
+	}
+
+	if storageClass.AllowVolumeExpansion == nil || !*storageClass.AllowVolumeExpansion {
+		return fmt.Errorf("storage class %s of %s doesn't allow volume expansion", storageClass.GetName(), pvc.GetName())
+	}
+
+	return nil
+}
+
+// volumeClaimTemplatesEqual compares the fields we manage, ignoring API server defaults.
+func volumeClaimTemplatesEqual(current []corev1.PersistentVolumeClaim, want []corev1.PersistentVolumeClaim) bool {
+	if len(current) != len(want) {
+		return false
+	}
+
+	for i := range want {
+		if current[i].GetName() != want[i].GetName() {
+			return false
+		}
+		if !equality.Semantic.DeepEqual(current[i].Spec.StorageClassName, want[i].Spec.StorageClassName) {
+			return false
+		}
+		if !equality.Semantic.DeepEqual(current[i].Spec.Resources.Requests, want[i].Spec.Resources.Requests) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,430 @@
+package k8s
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func testNatsCluster(managed k8sv1alpha1.NatsManagedSpec) *k8sv1alpha1.Cluster {
+	cluster := &k8sv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "wasmcloud"}}
+	cluster.Spec.Nats.Managed = &managed
+	return cluster
+}
+
+func testNatsStatefulSet(cluster *k8sv1alpha1.Cluster, replicas int32, ready int32) *appsv1.StatefulSet {
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: cluster.GetNamespace(), Name: "nats-" + cluster.GetName()},
+		Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+		Status:     appsv1.StatefulSetStatus{Replicas: replicas, ReadyReplicas: ready},
+	}
+}
+
+func testStorageCluster(size string, storageClass *string) *k8sv1alpha1.Cluster {
+	return testNatsCluster(k8sv1alpha1.NatsManagedSpec{
+		Replicas: 2,
+		Storage: &k8sv1alpha1.NatsStorageSpec{
+			StorageClassName: storageClass,
+			Size:             resource.MustParse(size),
+		},
+	})
+}
+
+// testStorageStatefulSet returns the NATS StatefulSet as it was created for size and storageClass.
+func testStorageStatefulSet(size string, storageClass *string) *appsv1.StatefulSet {
+	cluster := testStorageCluster(size, storageClass)
+	statefulset := testNatsStatefulSet(cluster, 2, 2)
+	statefulset.Spec.VolumeClaimTemplates = natsVolumeClaimTemplates(cluster)
+	return statefulset
+}
+
+func testNatsVolume(index string, size string, storageClass *string) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "data-nats-wasmcloud-" + index},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			StorageClassName: storageClass,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+			},
+		},
+	}
+}
+
+func testStorageClass(name string, expansion *bool) *storagev1.StorageClass {
+	return &storagev1.StorageClass{
+		ObjectMeta:           metav1.ObjectMeta{Name: name},
+		Provisioner:          "example.com/csi",
+		AllowVolumeExpansion: expansion,
+	}
+}
+
+func TestReconcileNatsStorage(t *testing.T) {
+	cases := map[string]struct {
+		statefulset *appsv1.StatefulSet
+		cluster     *k8sv1alpha1.Cluster
+		objs        []client.Object
+		// sizes of the volumes after the reconcile, by name
+		wantSizes    map[string]string
+		wantRecreate bool
+		wantErr      string
+	}{
+		"Unchanged": {
+			statefulset: testStorageStatefulSet("10Gi", nil),
+			cluster:     testStorageCluster("10Gi", nil),
+			objs: []client.Object{
+				testNatsVolume("0", "10Gi", nil),
+				testNatsVolume("1", "10Gi", nil),
+			},
+			wantSizes: map[string]string{
+				"data-nats-wasmcloud-0": "10Gi",
+				"data-nats-wasmcloud-1": "10Gi",
+			},
+		},
+		"Shrink": {
+			statefulset: testStorageStatefulSet("10Gi", nil),
+			cluster:     testStorageCluster("5Gi", nil),
+			objs: []client.Object{
+				testNatsVolume("0", "10Gi", nil),
+				testNatsVolume("1", "10Gi", nil),
+			},
+			wantSizes: map[string]string{
+				"data-nats-wasmcloud-0": "10Gi",
+				"data-nats-wasmcloud-1": "10Gi",
+			},
+			wantErr: "can't shrink",
+		},
+		"StorageClassChanged": {
+			statefulset: testStorageStatefulSet("10Gi", ptr.To("standard")),
+			cluster:     testStorageCluster("10Gi", ptr.To("fast")),
+			objs: []client.Object{
+				testNatsVolume("0", "10Gi", ptr.To("standard")),
+				testNatsVolume("1", "10Gi", ptr.To("standard")),
+			},
+			// only new volumes use the new class, existing ones are left alone
+			wantSizes: map[string]string{
+				"data-nats-wasmcloud-0": "10Gi",
+				"data-nats-wasmcloud-1": "10Gi",
+			},
+			wantRecreate: true,
+		},
+		"StorageAdded": {
+			statefulset: testNatsStatefulSet(testStorageCluster("10Gi", nil), 2, 2),
+			cluster:     testStorageCluster("10Gi", nil),
+			wantSizes:   map[string]string{},
+			// nothing to expand, the templates are still added
+			wantRecreate: true,
+		},
+		"Expand": {
+			statefulset: testStorageStatefulSet("10Gi", ptr.To("standard")),
+			cluster:     testStorageCluster("20Gi", ptr.To("standard")),
+			objs: []client.Object{
+				testStorageClass("standard", ptr.To(true)),
+				testNatsVolume("0", "10Gi", ptr.To("standard")),
+				testNatsVolume("1", "10Gi", ptr.To("standard")),
+			},
+			wantSizes: map[string]string{
+				"data-nats-wasmcloud-0": "20Gi",
+				"data-nats-wasmcloud-1": "20Gi",
+			},
+			wantRecreate: true,
+		},
+		"ExpandMissingVolume": {
+			statefulset: testStorageStatefulSet("10Gi", ptr.To("standard")),
+			cluster:     testStorageCluster("20Gi", ptr.To("standard")),
+			objs: []client.Object{
+				testStorageClass("standard", ptr.To(true)),
+				testNatsVolume("0", "10Gi", ptr.To("standard")),
+			},
+			wantSizes: map[string]string{
+				"data-nats-wasmcloud-0": "20Gi",
+			},
+			wantRecreate: true,
+		},
+		"ExpansionNotAllowed": {
+			statefulset: testStorageStatefulSet("10Gi", ptr.To("standard")),
+			cluster:     testStorageCluster("20Gi", ptr.To("standard")),
+			objs: []client.Object{
+				testStorageClass("standard", ptr.To(false)),
+				testNatsVolume("0", "10Gi", ptr.To("standard")),
+				testNatsVolume("1", "10Gi", ptr.To("standard")),
+			},
+			wantSizes: map[string]string{
+				"data-nats-wasmcloud-0": "10Gi",
+				"data-nats-wasmcloud-1": "10Gi",
+			},
+			wantErr: "doesn't allow volume expansion",
+		},
+		"ExpansionUnset": {
+			statefulset: testStorageStatefulSet("10Gi", ptr.To("standard")),
+			cluster:     testStorageCluster("20Gi", ptr.To("standard")),
+			objs: []client.Object{
+				testStorageClass("standard", nil),
+				// already expanded by an earlier pass
+				testNatsVolume("0", "20Gi", ptr.To("standard")),
+				testNatsVolume("1", "10Gi", ptr.To("standard")),
+			},
+			wantSizes: map[string]string{
+				"data-nats-wasmcloud-0": "20Gi",
+				"data-nats-wasmcloud-1": "10Gi",
+			},
+			wantErr: "doesn't allow volume expansion",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// the pods of the StatefulSet must survive its recreation
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Name:      "nats-wasmcloud-0",
+					OwnerReferences: []metav1.OwnerReference{
+						{APIVersion: "apps/v1", Kind: "StatefulSet", Name: tc.statefulset.GetName(), UID: "statefulset"},
+					},
+				},
+			}
+
+			objs := append([]client.Object{tc.cluster, tc.statefulset, pod}, tc.objs...)
+			r := &ClusterReconciler{Client: newFakeClient(t, objs...)}
+			ctx := context.Background()
+
+			recreate, err := r.reconcileNatsStorage(ctx, tc.cluster, natsVolumeClaimTemplates(tc.cluster))
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tc.wantErr)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if recreate != tc.wantRecreate {
+				t.Errorf("recreate: got %v, want %v", recreate, tc.wantRecreate)
+			}
+
+			err = r.Get(ctx, client.ObjectKeyFromObject(tc.statefulset), &appsv1.StatefulSet{})
+			if tc.wantRecreate != apierrors.IsNotFound(err) {
+				t.Errorf("statefulset deleted: got %v, want %v", apierrors.IsNotFound(err), tc.wantRecreate)
+			}
+			if err := r.Get(ctx, client.ObjectKeyFromObject(pod), &corev1.Pod{}); err != nil {
+				t.Errorf("pods should be orphaned, not deleted: %v", err)
+			}
+
+			var volumes corev1.PersistentVolumeClaimList
+			if err := r.List(ctx, &volumes, client.InNamespace("default")); err != nil {
+				t.Fatal(err)
+			}
+			sizes := map[string]string{}
+			for _, volume := range volumes.Items {
+				size := volume.Spec.Resources.Requests[corev1.ResourceStorage]
+				sizes[volume.GetName()] = size.String()
+			}
+			if diff := cmp.Diff(tc.wantSizes, sizes); diff != "" {
+				t.Errorf("volume sizes: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReconcileNatsStorageNoStatefulSet(t *testing.T) {
+	cluster := testStorageCluster("10Gi", nil)
+	r := &ClusterReconciler{Client: newFakeClient(t, cluster)}
+
+	recreate, err := r.reconcileNatsStorage(context.Background(), cluster, natsVolumeClaimTemplates(cluster))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recreate {
+		t.Error("a missing statefulset is created, not recreated")
+	}
+}
+
+func TestExpandNatsVolumes(t *testing.T) {
+	cases := map[string]struct {
+		replicas  *int32
+		objs      []client.Object
+		wantSizes map[string]string
+		wantErr   string
+	}{
+		"AllReplicas": {
+			replicas: ptr.To[int32](2),
+			objs: []client.Object{
+				testStorageClass("standard", ptr.To(true)),
+				testNatsVolume("0", "10Gi", ptr.To("standard")),
+				testNatsVolume("1", "10Gi", ptr.To("standard")),
+				// left over from a scale down, beyond the replicas
+				testNatsVolume("2", "10Gi", ptr.To("standard")),
+			},
+			wantSizes: map[string]string{
+				"data-nats-wasmcloud-0": "20Gi",
+				"data-nats-wasmcloud-1": "20Gi",
+				"data-nats-wasmcloud-2": "10Gi",
+			},
+		},
+		"DefaultReplicas": {
+			objs: []client.Object{
+				testStorageClass("standard", ptr.To(true)),
+				testNatsVolume("0", "10Gi", ptr.To("standard")),
+				testNatsVolume("1", "10Gi", ptr.To("standard")),
+			},
+			wantSizes: map[string]string{
+				"data-nats-wasmcloud-0": "20Gi",
+				"data-nats-wasmcloud-1": "10Gi",
+			},
+		},
+		"NeverShrinks": {
+			replicas: ptr.To[int32](1),
+			objs: []client.Object{
+				testNatsVolume("0", "30Gi", ptr.To("standard")),
+			},
+			wantSizes: map[string]string{
+				"data-nats-wasmcloud-0": "30Gi",
+			},
+		},
+		"NoStorageClass": {
+			replicas: ptr.To[int32](1),
+			objs: []client.Object{
+				testNatsVolume("0", "10Gi", nil),
+			},
+			wantSizes: map[string]string{
+				"data-nats-wasmcloud-0": "20Gi",
+			},
+		},
+		"MissingStorageClass": {
+			replicas: ptr.To[int32](1),
+			objs: []client.Object{
+				testNatsVolume("0", "10Gi", ptr.To("standard")),
+			},
+			wantSizes: map[string]string{
+				"data-nats-wasmcloud-0": "10Gi",
+			},
+			wantErr: "not found",
+		},
+		"ExpansionNotAllowed": {
+			replicas: ptr.To[int32](2),
+			objs: []client.Object{
+				testStorageClass("standard", ptr.To(true)),
+				testStorageClass("fixed", ptr.To(false)),
+				testNatsVolume("0", "10Gi", ptr.To("standard")),
+				testNatsVolume("1", "10Gi", ptr.To("fixed")),
+			},
+			// no volume is expanded when one of them can't be
+			wantSizes: map[string]string{
+				"data-nats-wasmcloud-0": "10Gi",
+				"data-nats-wasmcloud-1": "10Gi",
+			},
+			wantErr: "storage class fixed of data-nats-wasmcloud-1 doesn't allow volume expansion",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cluster := testStorageCluster("20Gi", ptr.To("standard"))
+			statefulset := testStorageStatefulSet("10Gi", ptr.To("standard"))
+			statefulset.Spec.Replicas = tc.replicas
+
+			r := &ClusterReconciler{Client: newFakeClient(t, tc.objs...)}
+			ctx := context.Background()
+
+			err := r.expandNatsVolumes(ctx, cluster, statefulset, natsVolumeClaimTemplates(cluster)[0])
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tc.wantErr)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			var volumes corev1.PersistentVolumeClaimList
+			if err := r.List(ctx, &volumes, client.InNamespace("default")); err != nil {
+				t.Fatal(err)
+			}
+			sizes := map[string]string{}
+			for _, volume := range volumes.Items {
+				size := volume.Spec.Resources.Requests[corev1.ResourceStorage]
+				sizes[volume.GetName()] = size.String()
+			}
+			if diff := cmp.Diff(tc.wantSizes, sizes); diff != "" {
+				t.Errorf("volume sizes: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestVolumeClaimTemplatesEqual(t *testing.T) {
+	claims := func(size string, storageClass *string) []corev1.PersistentVolumeClaim {
+		return natsVolumeClaimTemplates(testStorageCluster(size, storageClass))
+	}
+
+	// the API server fills in defaults the operator doesn't manage
+	defaulted := claims("10Gi", ptr.To("standard"))
+	defaulted[0].Spec.VolumeMode = ptr.To(corev1.PersistentVolumeFilesystem)
+	defaulted[0].Status.Phase = corev1.ClaimPending
+
+	renamed := claims("10Gi", ptr.To("standard"))
+	renamed[0].Name = "jetstream"
+
+	cases := map[string]struct {
+		current []corev1.PersistentVolumeClaim
+		want    []corev1.PersistentVolumeClaim
+		equal   bool
+	}{
+		"NoStorage": {
+			equal: true,
+		},
+		"Same": {
+			current: claims("10Gi", ptr.To("standard")),
+			want:    claims("10Gi", ptr.To("standard")),
+			equal:   true,
+		},
+		"SameQuantity": {
+			current: claims("10240Mi", ptr.To("standard")),
+			want:    claims("10Gi", ptr.To("standard")),
+			equal:   true,
+		},
+		"APIServerDefaults": {
+			current: defaulted,
+			want:    claims("10Gi", ptr.To("standard")),
+			equal:   true,
+		},
+		"Added": {
+			want: claims("10Gi", nil),
+		},
+		"Removed": {
+			current: claims("10Gi", nil),
+		},
+		"Size": {
+			current: claims("10Gi", ptr.To("standard")),
+			want:    claims("20Gi", ptr.To("standard")),
+		},
+		"StorageClass": {
+			current: claims("10Gi", ptr.To("standard")),
+			want:    claims("10Gi", ptr.To("fast")),
+		},
+		"DefaultStorageClass": {
+			current: claims("10Gi", ptr.To("standard")),
+			want:    claims("10Gi", nil),
+		},
+		"Name": {
+			current: renamed,
+			want:    claims("10Gi", ptr.To("standard")),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := volumeClaimTemplatesEqual(tc.current, tc.want); got != tc.equal {
+				t.Errorf("got %v, want %v", got, tc.equal)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nats-io/jwt/v2"
+	"github.com/nats-io/nkeys"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	"go.wasmcloud.dev/operator/internal/pki"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestEncodeJWT(t *testing.T) {
+	operatorKp, err := nkeys.CreateOperator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountKp, err := nkeys.CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := func(name string) jwt.Claims {
+		account, err := newAccount(name, accountKp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return account
+	}
+
+	previous, err := encodeJWT("", claims("wasmcloud"), operatorKp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		claims jwt.Claims
+		want   bool
+	}{
+		"SameClaimsReused": {
+			claims: claims("wasmcloud"),
+			want:   true,
+		},
+		"ChangedClaimsSigned": {
+			claims: claims("other"),
+			want:   false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := encodeJWT(previous, tc.claims, operatorKp)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tc.want, got == previous); diff != "" {
+				t.Errorf("encodeJWT(...) reused previous: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEncodeExpiringJWT(t *testing.T) {
+	accountKp, err := nkeys.CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	userKp, err := nkeys.CreateUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := func(name string) jwt.Claims {
+		user, err := newUser(name, userKp, accountKp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return user
+	}
+
+	now := time.Now()
+	previous, err := encodeExpiringJWT("", claims("client"), accountKp, time.Hour, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		claims jwt.Claims
+		ttl    time.Duration
+		now    time.Time
+		want   bool
+	}{
+		"NotDueReused": {
+			claims: claims("client"),
+			ttl:    time.Hour,
+			now:    now.Add(30 * time.Minute),
+			want:   true,
+		},
+		"DueRenewed": {
+			claims: claims("client"),
+			ttl:    time.Hour,
+			now:    now.Add(41 * time.Minute),
+			want:   false,
+		},
+		"TTLChangeSigned": {
+			claims: claims("client"),
+			ttl:    2 * time.Hour,
+			now:    now,
+			want:   false,
+		},
+		"ChangedClaimsSigned": {
+			claims: claims("other"),
+			ttl:    time.Hour,
+			now:    now,
+			want:   false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := encodeExpiringJWT(previous, tc.claims, accountKp, tc.ttl, tc.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tc.want, got == previous); diff != "" {
+				t.Errorf("encodeExpiringJWT(...) reused previous: -want, +got:\n%s", diff)
+			}
+
+			signed, err := jwt.DecodeUserClaims(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if signed.Expires == 0 {
+				t.Errorf("encodeExpiringJWT(...) issued a JWT without expiry")
+			}
+		})
+	}
+}
+
+func TestReconcileNatsTLSSecrets(t *testing.T) {
+	cases := map[string]struct {
+		// a CA being rotated out, still trusted
+		previousCA bool
+	}{
+		"New":        {},
+		"PreviousCA": {previousCA: true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			cluster := testNatsCluster(k8sv1alpha1.NatsManagedSpec{Replicas: 3})
+			cluster.TypeMeta = metav1.TypeMeta{APIVersion: k8sv1alpha1.GroupVersion.String(), Kind: "Cluster"}
+			cluster.UID = "cluster"
+			r := &ClusterReconciler{Client: newFakeClient(t, cluster)}
+
+			getSecret := func(name string) *corev1.Secret {
+				t.Helper()
+				var secret corev1.Secret
+				if err := r.Get(ctx, client.ObjectKey{Namespace: cluster.GetNamespace(), Name: name}, &secret); err != nil {
+					t.Fatal(err)
+				}
+				return &secret
+			}
+
+			if err := r.reconcileCertificateAuthority(ctx, cluster); err != nil {
+				t.Fatal(err)
+			}
+			caSecret := getSecret(cluster.NatsCASecret())
+			wantBundle := caSecret.Data["ca.crt"]
+			if tc.previousCA {
+				previous, err := pki.NewCertificateAuthority("previous", natsCertificateOptions(cluster, true))
+				if err != nil {
+					t.Fatal(err)
+				}
+				previousCert, err := previous.SelfSign()
+				if err != nil {
+					t.Fatal(err)
+				}
+				caSecret.Data[caPreviousKey] = previousCert
+				if err := r.Update(ctx, caSecret); err != nil {
+					t.Fatal(err)
+				}
+				wantBundle = append(bytes.Clone(wantBundle), previousCert...)
+			}
+
+			if err := r.reconcileNatsTLS(ctx, cluster); err != nil {
+				t.Fatal(err)
+			}
+			if err := r.reconcileNatsCredentials(ctx, cluster); err != nil {
+				t.Fatal(err)
+			}
+			if err := r.reconcileNatsClientConfig(ctx, cluster); err != nil {
+				t.Fatal(err)
+			}
+
+			// servers and clients trust the same CAs
+			tlsSecret := getSecret(cluster.NatsTLSSecret())
+			for _, name := range []string{cluster.NatsTLSSecret(), cluster.NatsClientSecret(), cluster.NatsSystemSecret()} {
+				if diff := cmp.Diff(string(wantBundle), string(getSecret(name).Data["ca.crt"])); diff != "" {
+					t.Errorf("%s ca.crt: -want, +got:\n%s", name, diff)
+				}
+			}
+
+			roots := x509.NewCertPool()
+			if !roots.AppendCertsFromPEM(getSecret(cluster.NatsClientSecret()).Data["ca.crt"]) {
+				t.Fatal("client secret carries no CA")
+			}
+			cert, err := pki.ParseCertificate(tlsSecret.Data["tls.crt"])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := pki.LoadClient(tlsSecret.Data["tls.crt"], tlsSecret.Data["tls.key"]); err != nil {
+				t.Errorf("server key doesn't match its certificate: %v", err)
+			}
+
+			// clients reach the servers through the Service, peers verify each other's pod names
+			for _, check := range []struct {
+				dnsName string
+				usage   x509.ExtKeyUsage
+			}{
+				{dnsName: "nats-wasmcloud.default.svc", usage: x509.ExtKeyUsageServerAuth},
+				{dnsName: "nats-wasmcloud-2.natsd-wasmcloud", usage: x509.ExtKeyUsageServerAuth},
+				{dnsName: "nats-wasmcloud-2.natsd-wasmcloud", usage: x509.ExtKeyUsageClientAuth},
+			} {
+				if _, err := cert.Verify(x509.VerifyOptions{
+					DNSName:   check.dnsName,
+					Roots:     roots,
+					KeyUsages: []x509.ExtKeyUsage{check.usage},
+				}); err != nil {
+					t.Errorf("verifying the server certificate for %s: %v", check.dnsName, err)
+				}
+			}
+		})
+	}
+}
@@ -3,22 +3,90 @@ package k8s
 import (
 	"context"
 	"fmt"
+	"time"
 
 	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	"go.wasmcloud.dev/operator/internal/wadmapi"
+	"go.wasmcloud.dev/x/wasmbus/wadm"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
+const wadmProbeTimeout = 5 * time.Second
+
 func (r *ClusterReconciler) reconcileWadm(ctx context.Context, cluster *k8sv1alpha1.Cluster) error {
+	if cluster.Spec.Wadm.External != nil {
+		return r.reconcileWadmExternal(ctx, cluster)
+	}
+
+	if cluster.Spec.Wadm.Managed == nil {
+		cluster.Status.Wadm = k8sv1alpha1.WadmStatus{}
+		return nil
+	}
+
 	if err := r.reconcileWadmStatefulset(ctx, cluster); err != nil {
+		return recordCondition(&cluster.Status.ConditionedStatus, conditionWadm, err)
+	}
+
+	var statefulset appsv1.StatefulSet
+	if err := r.Get(
+		ctx,
+		client.ObjectKey{Namespace: cluster.GetNamespace(), Name: "wadm-" + cluster.GetName()},
+		&statefulset); err != nil {
+		return recordCondition(&cluster.Status.ConditionedStatus, conditionWadm, err)
+	}
+
+	cluster.Status.Wadm.Managed = true
+	cluster.Status.Wadm.Replicas = statefulset.Status.Replicas
+	cluster.Status.Wadm.ReadyReplicas = statefulset.Status.ReadyReplicas
+
+	// readiness is reported without failing the reconcile
+	if err := recordCondition(&cluster.Status.ConditionedStatus, conditionWadm, statefulSetReady(&statefulset)); err != nil {
+		log.FromContext(ctx).Info("wadm is not ready", "reason", err.Error())
+	}
+
+	return nil
+}
+
+// reconcileWadmExternal probes a wadm deployed outside the operator.
+func (r *ClusterReconciler) reconcileWadmExternal(ctx context.Context, cluster *k8sv1alpha1.Cluster) error {
+	cluster.Status.Wadm = k8sv1alpha1.WadmStatus{Managed: false}
+
+	// reachability is reported without failing the reconcile
+	if err := recordCondition(&cluster.Status.ConditionedStatus, conditionWadm, r.probeWadm(ctx, cluster)); err != nil {
+		log.FromContext(ctx).Info("wadm is not reachable", "reason", err.Error())
+		return nil
+	}
+	cluster.Status.Wadm.Reachable = true
+
+	return nil
+}
+
+func (r *ClusterReconciler) probeWadm(ctx context.Context, cluster *k8sv1alpha1.Cluster) error {
+	if r.Connections == nil {
+		return fmt.Errorf("no nats connections available")
+	}
+
+	bus, err := r.Connections.Bus(ctx, client.ObjectKeyFromObject(cluster))
+	if err != nil {
 		return err
 	}
 
-	if err := r.reconcileWadmStatefulset(ctx, cluster); err != nil {
+	ctx, cancel := context.WithTimeout(ctx, wadmProbeTimeout)
+	defer cancel()
+
+	c := wadmapi.NewClient(bus, cluster.Spec.Wadm.External.Lattice, cluster.WadmAPIPrefix())
+	resp, err := c.ModelList(ctx, &wadm.ModelListRequest{})
+	if err != nil {
 		return err
 	}
+	if resp.IsError() {
+		return fmt.Errorf("model list error: %s", resp.Message)
+	}
 
 	return nil
 }
@@ -70,12 +138,23 @@ func (r *ClusterReconciler) reconcileWadmStatefulset(ctx context.Context, cluste
 		// wadm specific vars
 		{
 			Name:  "WADM_NATS_SERVER",
-			Value: fmt.Sprintf("nats-%s:4222", cluster.GetName()),
+			Value: fmt.Sprintf("%s:%s", cluster.NatsHost(), cluster.NatsPort()),
 		},
 		{
 			Name:  "WADM_NATS_CREDS_FILE",
 			Value: "/creds/user.jwt",
 		},
+		{
+			Name:  "WADM_JETSTREAM_DOMAIN",
+			Value: cluster.JetStreamDomain(),
+		},
+	}
+
+	if cluster.NatsCA() {
+		defaultEnv = append(defaultEnv, corev1.EnvVar{
+			Name:  "WADM_TLS_CA_FILE",
+			Value: "/creds/ca.crt",
+		})
 	}
 
 	volumes := []corev1.Volume{
@@ -98,27 +177,20 @@ func (r *ClusterReconciler) reconcileWadmStatefulset(ctx context.Context, cluste
 	hostContainer := corev1.Container{
 		Name:         "wadm",
 		Image:        image,
-		EnvFrom:      mergeEnvFromSource(cluster.Spec.Wadm.Managed.EnvFrom),
-		Env:          mergeEnvVar(cluster.Spec.Wadm.Managed.Env, defaultEnv),
-		VolumeMounts: mergeMounts(defaultMounts, cluster.Spec.Wadm.Managed.VolumeMounts),
+		Env:          defaultEnv,
+		VolumeMounts: defaultMounts,
 	}
 
-	volumes = append(volumes, cluster.Spec.Wadm.Managed.Volumes...)
-
-	podTemplate := corev1.PodTemplateSpec{
-		ObjectMeta: metav1.ObjectMeta{
-			Labels: mergeLabels(wantLabels),
-		},
-		Spec: corev1.PodSpec{
-			EnableServiceLinks:            boolPtr(false),
-			AutomountServiceAccountToken:  cluster.Spec.Wadm.Managed.AutomountServiceAccountToken,
-			TerminationGracePeriodSeconds: int64Ptr(0),
-			ServiceAccountName:            cluster.Spec.Wadm.Managed.ServiceAccountName,
-			Containers:                    []corev1.Container{hostContainer},
-			Volumes:                       volumes,
-		},
+	// wadm reads the creds once at connect, so renewed user JWTs roll the pods like a new CA does.
+	// Renewal leaves a third of the TTL for the rollout.
+	checksum, err := objectChecksum(ctx, r.Client, client.ObjectKey{Namespace: cluster.GetNamespace(), Name: cluster.NatsClientSecret()}, &corev1.Secret{}, "ca.crt", "user.jwt")
+	if err != nil {
+		return err
 	}
 
+	podTemplate := newPodTemplate(wantLabels, cluster.Spec.Wadm.Managed.ReplicaSpec, cluster.Spec.Wadm.Managed.ContainerSpec, hostContainer, volumes)
+	podTemplate.Annotations = map[string]string{checksumAnnotation: checksum}
+
 	spec := appsv1.StatefulSetSpec{
 		Selector: &metav1.LabelSelector{
 			MatchLabels: wantLabels,
@@ -137,7 +209,7 @@ func (r *ClusterReconciler) reconcileWadmStatefulset(ctx context.Context, cluste
 		Spec: spec,
 	}
 
-	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, statefulset, func() error {
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, statefulset, func() error {
 		statefulset.Spec = spec
 		// labels might have been modified elsewhere, so merge them
 		statefulset.SetLabels(mergeLabels(statefulset.GetLabels(), cluster.Spec.Wadm.Managed.Labels, defaultLabels))
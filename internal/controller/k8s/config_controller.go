@@ -19,9 +19,11 @@ package k8s
 import (
 	"context"
 
+	"go.wasmcloud.dev/operator/internal/natsconn"
 	"go.wasmcloud.dev/x/wasmbus"
 	"go.wasmcloud.dev/x/wasmbus/config"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -34,6 +36,10 @@ type ConfigReconciler struct {
 
 	Lattice string
 	Scheme  *runtime.Scheme
+	// Cluster whose lattice the config server answers on.
+	// The config server is disabled if blank.
+	Cluster     types.NamespacedName
+	Connections *natsconn.Manager
 
 	configServer *configServer
 }
@@ -45,11 +51,10 @@ func (r *ConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 // SetupWithManager sets up the controller with the Manager.
 func (r *ConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	var err error
-	// TODO(lxf): hook this back up
-	if true {
+	if r.Cluster.Name == "" {
 		return nil
 	}
-	r.configServer = newConfigServer(nil, "default")
+	r.configServer = newConfigServer(r.Connections, r.Cluster, r.Lattice)
 
 	if err = mgr.Add(r.configServer); err != nil {
 		return err
@@ -62,7 +67,9 @@ func (r *ConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
 }
 
 type configServer struct {
-	server *config.Server
+	connections *natsconn.Manager
+	cluster     types.NamespacedName
+	lattice     string
 }
 
 var _ config.API = (*configServer)(nil)
@@ -75,13 +82,12 @@ func (s *configServer) Host(
 	return &config.HostResponse{}, nil
 }
 
-func newConfigServer(bus wasmbus.Bus, lattice string) *configServer {
-	s := &configServer{}
-
-	server := config.NewServer(bus, lattice, s)
-	s.server = server
-
-	return s
+func newConfigServer(connections *natsconn.Manager, cluster types.NamespacedName, lattice string) *configServer {
+	return &configServer{
+		connections: connections,
+		cluster:     cluster,
+		lattice:     lattice,
+	}
 }
 
 func (s *configServer) NeedLeaderElection() bool {
@@ -89,9 +95,7 @@ func (s *configServer) NeedLeaderElection() bool {
 }
 
 func (s *configServer) Start(ctx context.Context) error {
-	if err := s.server.Serve(); err != nil {
-		return err
-	}
-	<-ctx.Done()
-	return s.server.Drain()
+	return s.connections.Serve(ctx, s.cluster, func(bus wasmbus.Bus) natsconn.Server {
+		return config.NewServer(bus, s.lattice, s)
+	})
 }
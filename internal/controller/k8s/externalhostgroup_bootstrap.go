@@ -0,0 +1,247 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/nats-io/jwt/v2"
+	"github.com/nats-io/nkeys"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// externalHostGroupLabel tells the hosts of a group apart from the others on the lattice.
+	// Hosts read their labels from the environment, so the key has to be a valid variable name.
+	externalHostGroupLabel = "kubernetes_externalhostgroup"
+
+	// hosts outside Kubernetes can't pick up renewed credentials, the bundle is downloaded again instead
+	externalHostGroupDefaultCredentialsTTL = 30 * 24 * time.Hour
+
+	bootstrapCredsKey  = "user.creds"
+	bootstrapCAKey     = "ca.crt"
+	bootstrapEnvKey    = "host.env"
+	bootstrapBundleKey = "bundle.tar.gz"
+)
+
+func externalHostGroupLabelValue(hostGroup *k8sv1alpha1.ExternalHostGroup) string {
+	return hostGroup.GetNamespace() + "/" + hostGroup.GetName()
+}
+
+// reconcileBootstrap keeps the bootstrap bundle of the group: creds scoped to its lattice,
+// the Cluster CA and the host settings, both as separate keys and packed under "bundle.tar.gz".
+// Clusters on external NATS only have their own credentials, which aren't handed out to groups.
+func (r *ExternalHostGroupReconciler) reconcileBootstrap(ctx context.Context, cluster *k8sv1alpha1.Cluster, hostGroup *k8sv1alpha1.ExternalHostGroup) error {
+	if cluster.Spec.Nats.External != nil {
+		return errors.New("external host groups need managed NATS, the credentials of external NATS aren't scoped to a lattice")
+	}
+
+	natsHost, natsPort, err := externalNatsEndpoint(cluster)
+	if err != nil {
+		return err
+	}
+
+	var sourceCreds corev1.Secret
+	if err := r.Client.Get(
+		ctx,
+		client.ObjectKey{Namespace: cluster.GetNamespace(), Name: cluster.NatsClientSecret()},
+		&sourceCreds); err != nil {
+		return err
+	}
+
+	seeds, err := r.seedStore().clientSeeds(ctx, cluster)
+	if err != nil {
+		return err
+	}
+
+	accountKp, err := seeds.keyPair("account")
+	if err != nil {
+		return err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            hostGroup.BootstrapSecret(),
+			Namespace:       hostGroup.GetNamespace(),
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(hostGroup, hostGroup.GroupVersionKind())},
+			Labels: map[string]string{
+				"host-cluster":        cluster.ResourceLabel(),
+				"external-host-group": hostGroup.GetName(),
+			},
+		},
+	}
+
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		creds, claims, err := hostCreds(secret.Data[bootstrapCredsKey], accountKp, externalHostGroupCredentialsTTL(hostGroup), time.Now(), func(userKp nkeys.KeyPair) (*jwt.UserClaims, error) {
+			return newExternalHostGroupUser(hostGroup, cluster.JetStreamDomain(), userKp, accountKp)
+		})
+		if err != nil {
+			return err
+		}
+
+		hostGroup.Status.Credentials = &k8sv1alpha1.HostGroupCredentialsStatus{
+			UserPublicKey:    claims.Subject,
+			AccountPublicKey: claims.Issuer,
+			JWTID:            claims.ID,
+			IssuedAt:         metav1.NewTime(time.Unix(claims.IssuedAt, 0)),
+			Expires:          &metav1.Time{Time: time.Unix(claims.Expires, 0)},
+		}
+
+		files := map[string][]byte{
+			bootstrapCredsKey: creds,
+		}
+		ca, hasCA := sourceCreds.Data["ca.crt"]
+		if hasCA {
+			files[bootstrapCAKey] = ca
+		}
+		env, err := bootstrapEnv(hostGroup, cluster.JetStreamDomain(), natsHost, natsPort, hasCA)
+		if err != nil {
+			return err
+		}
+		files[bootstrapEnvKey] = env
+
+		bundle := make(map[string][]byte, len(files))
+		for name, content := range files {
+			bundle[hostGroup.GetName()+"/"+name] = content
+		}
+		archive, err := tarArchive(bundle)
+		if err != nil {
+			return err
+		}
+
+		secret.Data = files
+		secret.Data[bootstrapBundleKey] = archive
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	hostGroup.Status.BootstrapSecret = secret.GetName()
+	return nil
+}
+
+// externalHostGroupCredentialsTTL returns the lifetime of the credentials in the bootstrap bundle.
+func externalHostGroupCredentialsTTL(hostGroup *k8sv1alpha1.ExternalHostGroup) time.Duration {
+	if hostGroup.Spec.CredentialsTTL == nil {
+		return externalHostGroupDefaultCredentialsTTL
+	}
+	return hostGroup.Spec.CredentialsTTL.Duration
+}
+
+// externalNatsEndpoint returns the address managed NATS is exposed at outside Kubernetes.
+func externalNatsEndpoint(cluster *k8sv1alpha1.Cluster) (string, string, error) {
+	if cluster.Spec.Nats.Managed == nil || cluster.Spec.Nats.Managed.Expose == nil {
+		return "", "", errors.New("cluster NATS isn't exposed outside Kubernetes, see spec.nats.managed.expose")
+	}
+
+	address := natsClientAdvertise(cluster)
+	if address == "" {
+		return "", "", errors.New("waiting for the cluster NATS address")
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", "", fmt.Errorf("cluster NATS address %q: %w", address, err)
+	}
+
+	return host, port, nil
+}
+
+// bootstrapEnv returns the host settings as an env file, with paths relative to the bundle directory.
+// Values are double quoted, which both shells and systemd read.
+func bootstrapEnv(hostGroup *k8sv1alpha1.ExternalHostGroup, jsDomain string, natsHost string, natsPort string, hasCA bool) ([]byte, error) {
+	env := []corev1.EnvVar{
+		{Name: "WASMCLOUD_LATTICE", Value: hostGroup.LatticeName()},
+		{Name: "WASMCLOUD_NATS_HOST", Value: natsHost},
+		{Name: "WASMCLOUD_NATS_PORT", Value: natsPort},
+		{Name: "WASMCLOUD_NATS_CREDS", Value: bootstrapCredsKey},
+		{Name: "WASMCLOUD_JS_DOMAIN", Value: jsDomain},
+		{Name: "WASMCLOUD_NATS_INBOX_PREFIX", Value: hostGroupInboxPrefix("ExternalHostGroup", hostGroup.GetNamespace(), hostGroup.GetName())},
+	}
+	if hasCA {
+		env = append(env,
+			corev1.EnvVar{Name: "WASMCLOUD_CTL_TLS_CA_FILE", Value: bootstrapCAKey},
+			corev1.EnvVar{Name: "WASMCLOUD_RPC_TLS_CA_FILE", Value: bootstrapCAKey},
+		)
+	}
+
+	labels := mergeLabels(hostGroup.Spec.HostLabels, map[string]string{
+		externalHostGroupLabel: externalHostGroupLabelValue(hostGroup),
+	})
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	// label keys differing only in the replaced characters would set the same variable
+	envKeys := make(map[string]string, len(keys))
+	for _, key := range keys {
+		envKey := envLabelKey(key)
+		if other, ok := envKeys[envKey]; ok {
+			return nil, fmt.Errorf("host labels %q and %q both set WASMCLOUD_LABEL_%s", other, key, envKey)
+		}
+		envKeys[envKey] = key
+		env = append(env, corev1.EnvVar{Name: "WASMCLOUD_LABEL_" + envKey, Value: labels[key]})
+	}
+
+	var buf strings.Builder
+	for _, v := range env {
+		value, err := quoteEnvValue(v.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", v.Name, err)
+		}
+		buf.WriteString(v.Name + "=" + value + "\n")
+	}
+
+	return []byte(buf.String()), nil
+}
+
+// quoteEnvValue double quotes value, escaping the characters shells expand inside double quotes.
+// Control characters have no portable escape and are rejected, a newline would start another variable.
+func quoteEnvValue(value string) (string, error) {
+	if strings.ContainsFunc(value, unicode.IsControl) {
+		return "", fmt.Errorf("value %q contains control characters", value)
+	}
+
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`").Replace(value)
+	return `"` + escaped + `"`, nil
+}
+
+// envLabelKey turns a label key into a valid environment variable name suffix, shells can't
+// export names with dots or slashes. Other characters than letters, digits and underscores become underscores.
+func envLabelKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, key)
+}
+
+func newExternalHostGroupUser(hostGroup *k8sv1alpha1.ExternalHostGroup, jsDomain string, kp nkeys.KeyPair, account nkeys.KeyPair) (*jwt.UserClaims, error) {
+	if err := checkLatticeName(hostGroup.LatticeName()); err != nil {
+		return nil, err
+	}
+
+	claims, err := newUser(hostGroup.GetNamespace()+"/"+hostGroup.GetName(), kp, account)
+	if err != nil {
+		return nil, err
+	}
+
+	claims.Tags.Add("externalhostgroup:" + hostGroup.GetNamespace() + "/" + hostGroup.GetName())
+	claims.Permissions = hostGroupPermissions(hostGroup.LatticeName(), jsDomain,
+		hostGroupInboxPrefix("ExternalHostGroup", hostGroup.GetNamespace(), hostGroup.GetName()))
+
+	return claims, nil
+}
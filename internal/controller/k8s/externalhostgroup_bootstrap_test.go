@@ -0,0 +1,269 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nats-io/jwt/v2"
+	"github.com/nats-io/nkeys"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	"go.wasmcloud.dev/operator/internal/lattice"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestBootstrapEnv(t *testing.T) {
+	cases := map[string]struct {
+		hostLabels map[string]string
+		want       string
+		wantErr    bool
+	}{
+		"Labels": {
+			hostLabels: map[string]string{"site": "warehouse-1", "topology.kubernetes.io/zone": "eu-1a"},
+			want: `WASMCLOUD_LATTICE="team-a"
+WASMCLOUD_NATS_HOST="nats.example.com"
+WASMCLOUD_NATS_PORT="4222"
+WASMCLOUD_NATS_CREDS="user.creds"
+WASMCLOUD_JS_DOMAIN="default"
+WASMCLOUD_NATS_INBOX_PREFIX="_INBOX_EXTERNALHOSTGROUP_team-a_edge"
+WASMCLOUD_CTL_TLS_CA_FILE="ca.crt"
+WASMCLOUD_RPC_TLS_CA_FILE="ca.crt"
+WASMCLOUD_LABEL_kubernetes_externalhostgroup="team-a/edge"
+WASMCLOUD_LABEL_site="warehouse-1"
+WASMCLOUD_LABEL_topology_kubernetes_io_zone="eu-1a"
+`,
+		},
+		"EscapedValue": {
+			hostLabels: map[string]string{"owner": "a \"b\" $HOME \\"},
+			want: `WASMCLOUD_LATTICE="team-a"
+WASMCLOUD_NATS_HOST="nats.example.com"
+WASMCLOUD_NATS_PORT="4222"
+WASMCLOUD_NATS_CREDS="user.creds"
+WASMCLOUD_JS_DOMAIN="default"
+WASMCLOUD_NATS_INBOX_PREFIX="_INBOX_EXTERNALHOSTGROUP_team-a_edge"
+WASMCLOUD_CTL_TLS_CA_FILE="ca.crt"
+WASMCLOUD_RPC_TLS_CA_FILE="ca.crt"
+WASMCLOUD_LABEL_kubernetes_externalhostgroup="team-a/edge"
+WASMCLOUD_LABEL_owner="a \"b\" \$HOME \\"
+`,
+		},
+		"ControlCharacter": {
+			hostLabels: map[string]string{"site": "warehouse-1\nWASMCLOUD_LATTICE=other"},
+			wantErr:    true,
+		},
+		"CollidingKeys": {
+			hostLabels: map[string]string{"a.b": "1", "a_b": "2"},
+			wantErr:    true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			hostGroup := &k8sv1alpha1.ExternalHostGroup{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "edge"},
+				Spec: k8sv1alpha1.ExternalHostGroupSpec{
+					Lattice:    "team-a",
+					HostLabels: tc.hostLabels,
+				},
+			}
+
+			got, err := bootstrapEnv(hostGroup, "default", "nats.example.com", "4222", true)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got:\n%s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, string(got)); diff != "" {
+				t.Errorf("env: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestExternalNatsEndpoint(t *testing.T) {
+	cases := map[string]struct {
+		nats     k8sv1alpha1.NatsSpec
+		exposure *k8sv1alpha1.NatsExposureStatus
+		wantHost string
+		wantPort string
+		wantErr  bool
+	}{
+		"NotExposed": {
+			nats:    k8sv1alpha1.NatsSpec{Managed: &k8sv1alpha1.NatsManagedSpec{}},
+			wantErr: true,
+		},
+		"Pending": {
+			nats: k8sv1alpha1.NatsSpec{Managed: &k8sv1alpha1.NatsManagedSpec{
+				Expose: &k8sv1alpha1.NatsExposeSpec{Type: "LoadBalancer"},
+			}},
+			exposure: &k8sv1alpha1.NatsExposureStatus{},
+			wantErr:  true,
+		},
+		"Exposed": {
+			nats: k8sv1alpha1.NatsSpec{Managed: &k8sv1alpha1.NatsManagedSpec{
+				Expose: &k8sv1alpha1.NatsExposeSpec{Type: "LoadBalancer"},
+			}},
+			exposure: &k8sv1alpha1.NatsExposureStatus{ClientAddress: "lb.example.com:4222"},
+			wantHost: "lb.example.com",
+			wantPort: "4222",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cluster := &k8sv1alpha1.Cluster{}
+			cluster.Spec.Nats = tc.nats
+			cluster.Status.Nats.Exposure = tc.exposure
+
+			host, port, err := externalNatsEndpoint(cluster)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %s:%s", host, port)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.wantHost+":"+tc.wantPort, host+":"+port); diff != "" {
+				t.Errorf("endpoint: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestExternalHosts(t *testing.T) {
+	hostGroup := &k8sv1alpha1.ExternalHostGroup{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "edge"},
+	}
+	seen := time.Now().Truncate(time.Second)
+
+	hosts := []lattice.Host{
+		{Id: "NHOSTA", Labels: map[string]string{externalHostGroupLabel: "team-a/edge"}, Version: "1.4.0", FirstSeen: seen, LastSeen: seen},
+		{Id: "NHOSTB", Labels: map[string]string{externalHostGroupLabel: "team-b/edge"}, FirstSeen: seen, LastSeen: seen},
+		{Id: "NHOSTC", FirstSeen: seen, LastSeen: seen},
+	}
+
+	want := []k8sv1alpha1.ExternalHostStatus{
+		{ID: "NHOSTA", Version: "1.4.0", FirstSeen: metav1.NewTime(seen), LastSeen: metav1.NewTime(seen)},
+	}
+	if diff := cmp.Diff(want, externalHosts(hostGroup, hosts)); diff != "" {
+		t.Errorf("hosts: -want, +got:\n%s", diff)
+	}
+}
+
+func TestReconcileBootstrap(t *testing.T) {
+	accountKp, err := nkeys.CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountSeed, err := accountKp.Seed()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		nats    k8sv1alpha1.NatsSpec
+		ttl     *metav1.Duration
+		wantTTL time.Duration
+		wantErr bool
+	}{
+		"DefaultTTL": {
+			nats: k8sv1alpha1.NatsSpec{Managed: &k8sv1alpha1.NatsManagedSpec{
+				Expose: &k8sv1alpha1.NatsExposeSpec{Type: "LoadBalancer"},
+			}},
+			wantTTL: externalHostGroupDefaultCredentialsTTL,
+		},
+		"CustomTTL": {
+			nats: k8sv1alpha1.NatsSpec{Managed: &k8sv1alpha1.NatsManagedSpec{
+				Expose: &k8sv1alpha1.NatsExposeSpec{Type: "LoadBalancer"},
+				// the Cluster TTL is for in-cluster clients that pick up renewals
+				CredentialsTTL: &metav1.Duration{Duration: time.Hour},
+			}},
+			ttl:     &metav1.Duration{Duration: 90 * 24 * time.Hour},
+			wantTTL: 90 * 24 * time.Hour,
+		},
+		"ExternalNats": {
+			// the only credentials of external NATS are the Cluster ones
+			nats: k8sv1alpha1.NatsSpec{
+				External: &k8sv1alpha1.NatsExternalSpec{URLs: []string{"tls://nats.example.com:4443"}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			cluster := &k8sv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "wasmcloud-system", Name: "wasmcloud"}}
+			cluster.Spec.Nats = tc.nats
+			cluster.Status.Nats.Exposure = &k8sv1alpha1.NatsExposureStatus{ClientAddress: "lb.example.com:4222"}
+
+			hostGroup := &k8sv1alpha1.ExternalHostGroup{
+				TypeMeta:   metav1.TypeMeta{APIVersion: k8sv1alpha1.GroupVersion.String(), Kind: "ExternalHostGroup"},
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "edge"},
+			}
+			hostGroup.Spec.CredentialsTTL = tc.ttl
+
+			r := &ExternalHostGroupReconciler{Client: newFakeClient(t,
+				cluster,
+				hostGroup,
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Namespace: cluster.GetNamespace(), Name: cluster.NatsSeedSecret()},
+					Data:       map[string][]byte{"account": accountSeed},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Namespace: cluster.GetNamespace(), Name: cluster.NatsClientSecret()},
+					Data:       map[string][]byte{"user.jwt": []byte("cluster-wide creds"), "ca.crt": []byte("ca")},
+				},
+			)}
+
+			before := time.Now().Truncate(time.Second)
+			err := r.reconcileBootstrap(ctx, cluster, hostGroup)
+
+			var secret corev1.Secret
+			getErr := r.Get(ctx, client.ObjectKey{Namespace: hostGroup.GetNamespace(), Name: hostGroup.BootstrapSecret()}, &secret)
+
+			if tc.wantErr {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				if client.IgnoreNotFound(getErr) != nil || getErr == nil {
+					t.Errorf("no bootstrap bundle should be issued, got %v", getErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if getErr != nil {
+				t.Fatal(getErr)
+			}
+
+			userJWT, err := jwt.ParseDecoratedJWT(secret.Data[bootstrapCredsKey])
+			if err != nil {
+				t.Fatal(err)
+			}
+			claims, err := jwt.DecodeUserClaims(userJWT)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expires := time.Unix(claims.Expires, 0)
+			if expires.Before(before.Add(tc.wantTTL)) || expires.After(time.Now().Add(tc.wantTTL)) {
+				t.Errorf("bundle credentials expire at %s, want %s after issuance", expires, tc.wantTTL)
+			}
+			if got := hostGroup.Status.Credentials; got == nil || got.Expires == nil || !got.Expires.Time.Equal(expires) {
+				t.Errorf("status should report the bundle expiry %s, got %+v", expires, got)
+			}
+		})
+	}
+}
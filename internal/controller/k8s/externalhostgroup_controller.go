@@ -0,0 +1,293 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"go.wasmcloud.dev/operator/api/condition"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	"go.wasmcloud.dev/operator/internal/envelope"
+	"go.wasmcloud.dev/operator/internal/lattice"
+	"go.wasmcloud.dev/operator/internal/natsconn"
+)
+
+const (
+	externalHostGroupFinalizer = "k8s.wasmcloud.dev/externalhostgroup-finalizer"
+	// hosts heartbeat every 30s, refreshing more often wouldn't tell anything new
+	externalHostGroupRefreshInterval = 30 * time.Second
+
+	conditionBootstrap = "Bootstrap"
+	conditionHosts     = "Hosts"
+)
+
+// ExternalHostGroupReconciler reconciles an ExternalHostGroup object
+type ExternalHostGroupReconciler struct {
+	client.Client
+	Scheme      *runtime.Scheme
+	Connections *natsconn.Manager
+	// Decrypts the Cluster seeds. Seeds are stored in plaintext when unset.
+	SeedEncrypter envelope.KeyEncrypter
+
+	// lattice caches, shared by the groups of a Cluster lattice
+	cacheLock sync.Mutex
+	caches    map[latticeKey]*lattice.Cache
+}
+
+type latticeKey struct {
+	cluster types.NamespacedName
+	lattice string
+}
+
+// +kubebuilder:rbac:groups=k8s.wasmcloud.dev,resources=externalhostgroups,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=k8s.wasmcloud.dev,resources=externalhostgroups/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=k8s.wasmcloud.dev,resources=externalhostgroups/finalizers,verbs=update
+
+func (r *ExternalHostGroupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	var hostGroup k8sv1alpha1.ExternalHostGroup
+	if err := r.Get(ctx, req.NamespacedName, &hostGroup); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !hostGroup.DeletionTimestamp.IsZero() {
+		if controllerutil.ContainsFinalizer(&hostGroup, externalHostGroupFinalizer) {
+			if err := r.finalize(ctx, &hostGroup); err != nil {
+				logger.Error(err, "unable to finalize")
+				return ctrl.Result{}, err
+			}
+
+			controllerutil.RemoveFinalizer(&hostGroup, externalHostGroupFinalizer)
+			if err := r.Update(ctx, &hostGroup); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(&hostGroup, externalHostGroupFinalizer) {
+		controllerutil.AddFinalizer(&hostGroup, externalHostGroupFinalizer)
+		if err := r.Update(ctx, &hostGroup); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	reconcileErr := r.reconcileSpec(ctx, &hostGroup)
+	if reconcileErr != nil {
+		logger.Error(reconcileErr, "Failed to reconcile external host group")
+	}
+
+	if err := r.reconcileStatus(ctx, &hostGroup, reconcileErr); err != nil {
+		logger.Error(err, "Failed to update external host group status")
+		return ctrl.Result{}, err
+	}
+
+	if reconcileErr != nil {
+		return ctrl.Result{}, reconcileErr
+	}
+
+	return ctrl.Result{RequeueAfter: externalHostGroupRefreshInterval}, nil
+}
+
+func (r *ExternalHostGroupReconciler) reconcileSpec(ctx context.Context, hostGroup *k8sv1alpha1.ExternalHostGroup) error {
+	cluster, err := GetCluster(ctx, r.Client, hostGroup.Spec.Cluster.Namespace, hostGroup.Spec.Cluster.Name)
+	if err != nil {
+		return err
+	}
+
+	if err := recordCondition(&hostGroup.Status.ConditionedStatus, conditionBootstrap, r.reconcileBootstrap(ctx, cluster, hostGroup)); err != nil {
+		return err
+	}
+
+	// hosts are observed rather than reconciled, so they don't fail the reconcile
+	if err := recordCondition(&hostGroup.Status.ConditionedStatus, conditionHosts, r.reconcileHosts(ctx, cluster, hostGroup)); err != nil {
+		log.FromContext(ctx).Info("Not watching lattice hosts", "reason", err.Error())
+	}
+
+	return nil
+}
+
+func (r *ExternalHostGroupReconciler) reconcileStatus(ctx context.Context, hostGroup *k8sv1alpha1.ExternalHostGroup, reconcileErr error) error {
+	if reconcileErr != nil {
+		hostGroup.Status.SetConditions(condition.ReconcileError(reconcileErr))
+	} else {
+		hostGroup.Status.SetConditions(condition.ReconcileSuccess())
+		hostGroup.Status.ObservedGeneration = hostGroup.Generation
+	}
+
+	ready := true
+	for _, cond := range hostGroup.Status.Conditions {
+		if cond.Type == condition.TypeReady {
+			continue
+		}
+		if cond.Status != corev1.ConditionTrue {
+			ready = false
+		}
+	}
+	if ready {
+		hostGroup.Status.SetConditions(condition.Available())
+	} else {
+		hostGroup.Status.SetConditions(condition.Unavailable())
+	}
+
+	return r.Status().Update(ctx, hostGroup)
+}
+
+// reconcileHosts lists the hosts of the group heard from on the lattice.
+func (r *ExternalHostGroupReconciler) reconcileHosts(ctx context.Context, cluster *k8sv1alpha1.Cluster, hostGroup *k8sv1alpha1.ExternalHostGroup) error {
+	if r.Connections == nil {
+		return fmt.Errorf("no nats connections available")
+	}
+
+	cache := r.latticeCache(client.ObjectKeyFromObject(cluster), hostGroup.LatticeName())
+	if err := cache.Watch(ctx); err != nil {
+		return err
+	}
+
+	hosts := externalHosts(hostGroup, cache.Hosts(time.Now()))
+	hostGroup.Status.Hosts = hosts
+	hostGroup.Status.ReadyHosts = int32(len(hosts))
+
+	return nil
+}
+
+// externalHosts picks the hosts carrying the group label set by the bootstrap bundle.
+func externalHosts(hostGroup *k8sv1alpha1.ExternalHostGroup, hosts []lattice.Host) []k8sv1alpha1.ExternalHostStatus {
+	var status []k8sv1alpha1.ExternalHostStatus
+	for _, host := range hosts {
+		if host.Labels[externalHostGroupLabel] != externalHostGroupLabelValue(hostGroup) {
+			continue
+		}
+		status = append(status, k8sv1alpha1.ExternalHostStatus{
+			ID:        host.Id,
+			Version:   host.Version,
+			FirstSeen: metav1.NewTime(host.FirstSeen),
+			LastSeen:  metav1.NewTime(host.LastSeen),
+		})
+	}
+	return status
+}
+
+func (r *ExternalHostGroupReconciler) latticeCache(cluster types.NamespacedName, latticeName string) *lattice.Cache {
+	r.cacheLock.Lock()
+	defer r.cacheLock.Unlock()
+
+	key := latticeKey{cluster: cluster, lattice: latticeName}
+	if cache, ok := r.caches[key]; ok {
+		return cache
+	}
+
+	if r.caches == nil {
+		r.caches = make(map[latticeKey]*lattice.Cache)
+	}
+
+	cache := &lattice.Cache{
+		Lattice:     latticeName,
+		Cluster:     cluster,
+		Connections: r.Connections,
+	}
+	r.caches[key] = cache
+
+	return cache
+}
+
+// releaseLatticeCache stops watching the lattice of a deleted group, unless other groups use it.
+func (r *ExternalHostGroupReconciler) releaseLatticeCache(ctx context.Context, hostGroup *k8sv1alpha1.ExternalHostGroup) error {
+	var hostGroups k8sv1alpha1.ExternalHostGroupList
+	if err := r.List(ctx, &hostGroups); err != nil {
+		return err
+	}
+
+	cluster := types.NamespacedName{Namespace: hostGroup.Spec.Cluster.Namespace, Name: hostGroup.Spec.Cluster.Name}
+	for _, other := range hostGroups.Items {
+		if other.GetUID() == hostGroup.GetUID() {
+			continue
+		}
+		if other.Spec.Cluster.Namespace == cluster.Namespace &&
+			other.Spec.Cluster.Name == cluster.Name &&
+			other.LatticeName() == hostGroup.LatticeName() {
+			return nil
+		}
+	}
+
+	r.cacheLock.Lock()
+	defer r.cacheLock.Unlock()
+
+	key := latticeKey{cluster: cluster, lattice: hostGroup.LatticeName()}
+	if cache, ok := r.caches[key]; ok {
+		cache.Stop()
+		delete(r.caches, key)
+	}
+
+	return nil
+}
+
+// finalize revokes the group NATS user, so bundles handed out stop working.
+func (r *ExternalHostGroupReconciler) finalize(ctx context.Context, hostGroup *k8sv1alpha1.ExternalHostGroup) error {
+	if err := r.releaseLatticeCache(ctx, hostGroup); err != nil {
+		return err
+	}
+
+	creds := hostGroup.Status.Credentials
+	if creds == nil {
+		return nil
+	}
+
+	cluster, err := GetCluster(ctx, r.Client, hostGroup.Spec.Cluster.Namespace, hostGroup.Spec.Cluster.Name)
+	if err != nil {
+		// without its Cluster there is no account left to revoke from
+		return client.IgnoreNotFound(err)
+	}
+
+	if cluster.Spec.Nats.External != nil || !cluster.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	var expires time.Time
+	if creds.Expires != nil {
+		expires = creds.Expires.Time
+	}
+
+	return revokeNatsUser(ctx, r.seedStore(), r.Connections, cluster, creds.UserPublicKey, expires)
+}
+
+func (r *ExternalHostGroupReconciler) seedStore() natsSeedStore {
+	return natsSeedStore{client: r.Client, encrypter: r.SeedEncrypter}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ExternalHostGroupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&k8sv1alpha1.ExternalHostGroup{}).
+		Named("k8s-externalhostgroup").
+		Owns(&corev1.Secret{}).
+		Complete(r)
+}
@@ -30,6 +30,8 @@ import (
 
 	"go.wasmcloud.dev/operator/api/condition"
 	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	"go.wasmcloud.dev/operator/internal/envelope"
+	"go.wasmcloud.dev/operator/internal/natsconn"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 )
@@ -42,7 +44,10 @@ const (
 // HostGroupReconciler reconciles a HostGroup object
 type HostGroupReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme      *runtime.Scheme
+	Connections *natsconn.Manager
+	// Decrypts the Cluster seeds. Seeds are stored in plaintext when unset.
+	SeedEncrypter envelope.KeyEncrypter
 }
 
 // +kubebuilder:rbac:groups=k8s.wasmcloud.dev,resources=hostgroups,verbs=get;list;watch;create;update;patch;delete
@@ -84,6 +89,15 @@ func (r *HostGroupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{}, err
 	}
 
+	// the finalizer revoking the HostGroup user is in place before the user is issued.
+	// Updating reloads the object, status set before would be lost.
+	if !controllerutil.ContainsFinalizer(&hostGroup, finalizer) {
+		controllerutil.AddFinalizer(&hostGroup, finalizer)
+		if err := r.Update(ctx, &hostGroup); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	if err := r.reconcileCredentials(ctx, cluster, &hostGroup); err != nil {
 		return ctrl.Result{}, err
 	}
@@ -107,46 +121,7 @@ func GetCluster(ctx context.Context, apiClient client.Client, namespace string,
 	return &cluster, nil
 }
 
-func (r *HostGroupReconciler) reconcileCredentials(ctx context.Context, cluster *k8sv1alpha1.Cluster, hostGroup *k8sv1alpha1.HostGroup) error {
-	// for now simply copy the secret from the cluster
-	var sourceCreds corev1.Secret
-	if err := r.Client.Get(
-		ctx,
-		client.ObjectKey{Namespace: cluster.GetNamespace(), Name: cluster.NatsClientSecret()},
-		&sourceCreds); err != nil {
-		return err
-	}
-
-	destCreds := &corev1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:            hostGroup.NatsClientSecret(),
-			Namespace:       hostGroup.GetNamespace(),
-			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(hostGroup, hostGroup.GroupVersionKind())},
-			Labels: map[string]string{
-				"host-cluster": cluster.ResourceLabel(),
-				"host-group":   hostGroup.GetName(),
-			},
-		},
-	}
-	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, destCreds, func() error {
-		destCreds.Data = map[string][]byte{
-			"user.jwt": sourceCreds.Data["user.jwt"],
-		}
-		return nil
-	})
-
-	return err
-}
-
 func (r *HostGroupReconciler) reconcileSpec(ctx context.Context, cluster *k8sv1alpha1.Cluster, hostGroup *k8sv1alpha1.HostGroup) error {
-	// ensure finalizer
-	if !controllerutil.ContainsFinalizer(hostGroup, finalizer) {
-		controllerutil.AddFinalizer(hostGroup, finalizer)
-		if err := r.Update(ctx, hostGroup); err != nil {
-			return err
-		}
-	}
-
 	if err := r.reconcileDeployment(ctx, cluster, hostGroup); err != nil {
 		return err
 	}
@@ -262,7 +237,7 @@ func (r *HostGroupReconciler) reconcileDeployment(ctx context.Context, cluster *
 		},
 		{
 			Name:  "WASMCLOUD_JS_DOMAIN",
-			Value: "default",
+			Value: cluster.JetStreamDomain(),
 		},
 		{
 			Name:  "WASMCLOUD_RPC_TIMEOUT_MS",
@@ -284,9 +259,13 @@ func (r *HostGroupReconciler) reconcileDeployment(ctx context.Context, cluster *
 			Name:  "WASMCLOUD_NATS_CREDS",
 			Value: "/creds/user.jwt",
 		},
+		{
+			Name:  "WASMCLOUD_NATS_INBOX_PREFIX",
+			Value: hostGroupInboxPrefix("HostGroup", hostGroup.GetNamespace(), hostGroup.GetName()),
+		},
 		{
 			Name:  "WASMCLOUD_LATTICE",
-			Value: "default",
+			Value: hostGroup.LatticeName(),
 		},
 		{
 			Name:  "WASMCLOUD_NATS_HOST",
@@ -294,10 +273,23 @@ func (r *HostGroupReconciler) reconcileDeployment(ctx context.Context, cluster *
 		},
 		{
 			Name:  "WASMCLOUD_NATS_PORT",
-			Value: "4222",
+			Value: cluster.NatsPort(),
 		},
 	}
 
+	if cluster.NatsCA() {
+		defaultEnv = append(defaultEnv,
+			corev1.EnvVar{
+				Name:  "WASMCLOUD_CTL_TLS_CA_FILE",
+				Value: "/creds/ca.crt",
+			},
+			corev1.EnvVar{
+				Name:  "WASMCLOUD_RPC_TLS_CA_FILE",
+				Value: "/creds/ca.crt",
+			},
+		)
+	}
+
 	volumes := []corev1.Volume{
 		{
 			Name: "wasmcloud-share",
@@ -326,20 +318,11 @@ func (r *HostGroupReconciler) reconcileDeployment(ctx context.Context, cluster *
 		},
 	}
 
-	image := hostGroup.Spec.Image
-	if image == "" {
-		image = "ghcr.io/wasmcloud/wasmcloud:canary"
-	}
-
 	hostContainer := corev1.Container{
 		Name:         "host",
-		Image:        image,
-		Command:      hostGroup.Spec.Command,
-		Args:         hostGroup.Spec.Args,
-		WorkingDir:   hostGroup.Spec.WorkingDir,
-		EnvFrom:      mergeEnvFromSource(hostGroup.Spec.EnvFrom),
-		Env:          mergeEnvVar(hostGroup.Spec.Env, defaultEnv),
-		VolumeMounts: mergeMounts(defaultMounts, hostGroup.Spec.VolumeMounts),
+		Image:        "ghcr.io/wasmcloud/wasmcloud:canary",
+		Env:          defaultEnv,
+		VolumeMounts: defaultMounts,
 		LivenessProbe: &corev1.Probe{
 			PeriodSeconds: 3,
 			ProbeHandler: corev1.ProbeHandler{
@@ -357,25 +340,15 @@ func (r *HostGroupReconciler) reconcileDeployment(ctx context.Context, cluster *
 		},
 	}
 
-	if hostGroup.Spec.Resources != nil {
-		hostContainer.Resources = *hostGroup.Spec.Resources
+	// hosts read the creds once at connect, so renewed user JWTs roll the pods like a new CA does.
+	// Renewal leaves a third of the TTL for the rollout.
+	checksum, err := objectChecksum(ctx, r.Client, client.ObjectKey{Namespace: hostGroup.GetNamespace(), Name: hostGroup.NatsClientSecret()}, &corev1.Secret{}, "ca.crt", "user.jwt")
+	if err != nil {
+		return err
 	}
 
-	volumes = append(volumes, hostGroup.Spec.Volumes...)
-
-	podTemplate := corev1.PodTemplateSpec{
-		ObjectMeta: metav1.ObjectMeta{
-			Labels: mergeLabels(hostGroup.Spec.Labels, wantLabels),
-		},
-		Spec: corev1.PodSpec{
-			EnableServiceLinks:            boolPtr(false),
-			AutomountServiceAccountToken:  hostGroup.Spec.AutomountServiceAccountToken,
-			TerminationGracePeriodSeconds: int64Ptr(0),
-			ServiceAccountName:            hostGroup.Spec.ServiceAccountName,
-			Containers:                    []corev1.Container{hostContainer},
-			Volumes:                       volumes,
-		},
-	}
+	podTemplate := newPodTemplate(wantLabels, hostGroup.Spec.ReplicaSpec, hostGroup.Spec.ContainerSpec, hostContainer, volumes)
+	podTemplate.Annotations = map[string]string{checksumAnnotation: checksum}
 
 	spec := appsv1.DeploymentSpec{
 		Selector: &metav1.LabelSelector{
@@ -394,7 +367,7 @@ func (r *HostGroupReconciler) reconcileDeployment(ctx context.Context, cluster *
 		},
 	}
 
-	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, deployment, func() error {
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, deployment, func() error {
 		deployment.Spec = spec
 		return nil
 	})
@@ -411,8 +384,33 @@ func (r *HostGroupReconciler) reconcileStatus(ctx context.Context, cluster *k8sv
 	return nil
 }
 
+// finalize revokes the HostGroup NATS user, so copies of its credentials stop working.
 func (r *HostGroupReconciler) finalize(ctx context.Context, hostGroup *k8sv1alpha1.HostGroup) error {
-	return nil
+	creds := hostGroup.Status.Credentials
+	if creds == nil {
+		return nil
+	}
+
+	cluster, err := GetCluster(ctx, r.Client, hostGroup.Spec.Cluster.Namespace, hostGroup.Spec.Cluster.Name)
+	if err != nil {
+		// without its Cluster there is no account left to revoke from
+		return client.IgnoreNotFound(err)
+	}
+
+	if cluster.Spec.Nats.External != nil || !cluster.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	var expires time.Time
+	if creds.Expires != nil {
+		expires = creds.Expires.Time
+	}
+
+	return revokeNatsUser(ctx, r.seedStore(), r.Connections, cluster, creds.UserPublicKey, expires)
+}
+
+func (r *HostGroupReconciler) seedStore() natsSeedStore {
+	return natsSeedStore{client: r.Client, encrypter: r.SeedEncrypter}
 }
 
 // SetupWithManager sets up the controller with the Manager.
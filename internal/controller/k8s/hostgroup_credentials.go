@@ -0,0 +1,282 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nats-io/jwt/v2"
+	"github.com/nats-io/nkeys"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// reconcileCredentials issues each HostGroup its own NATS user, signed by the Cluster account
+// and limited to the HostGroup lattice. The operator can't sign users for external NATS,
+// so those HostGroups share the Cluster credentials.
+func (r *HostGroupReconciler) reconcileCredentials(ctx context.Context, cluster *k8sv1alpha1.Cluster, hostGroup *k8sv1alpha1.HostGroup) error {
+	var sourceCreds corev1.Secret
+	if err := r.Client.Get(
+		ctx,
+		client.ObjectKey{Namespace: cluster.GetNamespace(), Name: cluster.NatsClientSecret()},
+		&sourceCreds); err != nil {
+		return err
+	}
+
+	destCreds := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            hostGroup.NatsClientSecret(),
+			Namespace:       hostGroup.GetNamespace(),
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(hostGroup, hostGroup.GroupVersionKind())},
+			Labels: map[string]string{
+				"host-cluster": cluster.ResourceLabel(),
+				"host-group":   hostGroup.GetName(),
+			},
+		},
+	}
+
+	if cluster.Spec.Nats.External != nil {
+		hostGroup.Status.Credentials = nil
+
+		_, err := controllerutil.CreateOrUpdate(ctx, r.Client, destCreds, func() error {
+			destCreds.Data = map[string][]byte{
+				"user.jwt": sourceCreds.Data["user.jwt"],
+			}
+			if ca, ok := sourceCreds.Data["ca.crt"]; ok {
+				destCreds.Data["ca.crt"] = ca
+			}
+			return nil
+		})
+		return err
+	}
+
+	seeds, err := r.seedStore().clientSeeds(ctx, cluster)
+	if err != nil {
+		return err
+	}
+
+	accountKp, err := seeds.keyPair("account")
+	if err != nil {
+		return err
+	}
+
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, destCreds, func() error {
+		userCreds, _, err := hostGroupCreds(destCreds.Data["user.jwt"], hostGroup, cluster.JetStreamDomain(), accountKp, natsCredentialsTTL(cluster), time.Now())
+		if err != nil {
+			return err
+		}
+
+		destCreds.Data = map[string][]byte{
+			"user.jwt": userCreds,
+		}
+		if ca, ok := sourceCreds.Data["ca.crt"]; ok {
+			destCreds.Data["ca.crt"] = ca
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// report the user hosts actually get, as persisted
+	status, err := hostCredentialsStatus(destCreds.Data["user.jwt"])
+	if err != nil {
+		return err
+	}
+	hostGroup.Status.Credentials = status
+
+	return nil
+}
+
+// hostCredentialsStatus describes the user of a creds file.
+func hostCredentialsStatus(creds []byte) (*k8sv1alpha1.HostGroupCredentialsStatus, error) {
+	userJWT, err := jwt.ParseDecoratedJWT(creds)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := jwt.DecodeUserClaims(userJWT)
+	if err != nil {
+		return nil, err
+	}
+
+	return &k8sv1alpha1.HostGroupCredentialsStatus{
+		UserPublicKey:    claims.Subject,
+		AccountPublicKey: claims.Issuer,
+		JWTID:            claims.ID,
+		IssuedAt:         metav1.NewTime(time.Unix(claims.IssuedAt, 0)),
+		Expires:          &metav1.Time{Time: time.Unix(claims.Expires, 0)},
+	}, nil
+}
+
+// hostGroupCreds returns a creds file for the HostGroup user, reusing the user key and JWT
+// from previous creds when they still match and aren't due for renewal at now.
+// Returns the claims of the JWT in the creds.
+func hostGroupCreds(
+	previous []byte,
+	hostGroup *k8sv1alpha1.HostGroup,
+	jsDomain string,
+	accountKp nkeys.KeyPair,
+	ttl time.Duration,
+	now time.Time,
+) ([]byte, *jwt.UserClaims, error) {
+	return hostCreds(previous, accountKp, ttl, now, func(userKp nkeys.KeyPair) (*jwt.UserClaims, error) {
+		return newHostGroupUser(hostGroup, jsDomain, userKp, accountKp)
+	})
+}
+
+// hostCreds returns a creds file for the user newClaims describes, reusing the user key and JWT
+// from previous creds when they still match and aren't due for renewal at now.
+func hostCreds(
+	previous []byte,
+	accountKp nkeys.KeyPair,
+	ttl time.Duration,
+	now time.Time,
+	newClaims func(userKp nkeys.KeyPair) (*jwt.UserClaims, error),
+) ([]byte, *jwt.UserClaims, error) {
+	var previousJWT string
+	userKp, err := jwt.ParseDecoratedUserNKey(previous)
+	if err == nil {
+		previousJWT, _ = jwt.ParseDecoratedJWT(previous)
+	} else if userKp, err = nkeys.CreateUser(); err != nil {
+		return nil, nil, err
+	}
+
+	claims, err := newClaims(userKp)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	userJWT, err := encodeExpiringJWT(previousJWT, claims, accountKp, ttl, now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	signed, err := jwt.DecodeUserClaims(userJWT)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	userSeed, err := userKp.Seed()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	userCreds, err := jwt.FormatUserConfig(userJWT, userSeed)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return userCreds, signed, nil
+}
+
+func newHostGroupUser(hostGroup *k8sv1alpha1.HostGroup, jsDomain string, kp nkeys.KeyPair, account nkeys.KeyPair) (*jwt.UserClaims, error) {
+	if err := checkLatticeName(hostGroup.LatticeName()); err != nil {
+		return nil, err
+	}
+
+	claims, err := newUser(hostGroup.GetNamespace()+"/"+hostGroup.GetName(), kp, account)
+	if err != nil {
+		return nil, err
+	}
+
+	claims.Tags.Add("hostgroup:" + hostGroup.GetNamespace() + "/" + hostGroup.GetName())
+	claims.Permissions = hostGroupPermissions(hostGroup.LatticeName(), jsDomain,
+		hostGroupInboxPrefix("HostGroup", hostGroup.GetNamespace(), hostGroup.GetName()))
+
+	return claims, nil
+}
+
+// hostGroupPermissions limits hosts to the subjects of their lattice,
+// JetStream included: they only reach the lattice KV buckets.
+// Replies come in on inboxPrefix only, so hosts can't read the replies of other groups,
+// and they answer requests through the response permission rather than any inbox.
+func hostGroupPermissions(lattice string, jsDomain string, inboxPrefix string) jwt.Permissions {
+	pub := slices.Concat(latticeSubjects(lattice), latticeJetStreamSubjects(lattice, jsDomain), []string{
+		"wasmcloud.policy",
+		"wasmcloud.secrets.>",
+	})
+
+	sub := slices.Concat(latticeSubjects(lattice), []string{
+		inboxPrefix + ".>",
+	})
+
+	return jwt.Permissions{
+		Pub:  jwt.Permission{Allow: pub},
+		Sub:  jwt.Permission{Allow: sub},
+		Resp: &jwt.ResponsePermission{MaxMsgs: 1},
+	}
+}
+
+// hostGroupInboxPrefix returns the inbox prefix the hosts of a group connect with, kind being
+// HostGroup or ExternalHostGroup. Like serviceAccountInboxPrefix, namespaces and names never
+// contain "_" and dots of the name are replaced; the upper case kind keeps the prefixes of
+// both kinds apart from each other and from the ServiceAccount ones.
+func hostGroupInboxPrefix(kind string, namespace string, name string) string {
+	return "_INBOX_" + strings.ToUpper(kind) + "_" + namespace + "_" + strings.ReplaceAll(name, ".", "_")
+}
+
+// latticeJetStreamSubjects lists the JetStream API subjects hosts use on the lattice KV buckets,
+// with and without the JetStream domain: looking up and creating the bucket streams, reading keys
+// and consuming watches.
+func latticeJetStreamSubjects(lattice string, jsDomain string) []string {
+	subjects := []string{}
+	for _, prefix := range []string{"$JS.API", "$JS." + jsDomain + ".API"} {
+		for _, bucket := range []string{"LATTICEDATA_" + lattice, "CONFIGDATA_" + lattice} {
+			stream := "KV_" + bucket
+			subjects = append(subjects,
+				prefix+".STREAM.INFO."+stream,
+				prefix+".STREAM.CREATE."+stream,
+				prefix+".STREAM.MSG.GET."+stream,
+				prefix+".DIRECT.GET."+stream,
+				prefix+".DIRECT.GET."+stream+".>",
+				prefix+".CONSUMER.CREATE."+stream,
+				prefix+".CONSUMER.*."+stream+".>",
+				prefix+".CONSUMER.MSG.NEXT."+stream+".*",
+			)
+		}
+	}
+
+	for _, bucket := range []string{"LATTICEDATA_" + lattice, "CONFIGDATA_" + lattice} {
+		subjects = append(subjects,
+			"$JS.ACK.KV_"+bucket+".>",
+			// acks carry the domain and account hash since NATS 2.9
+			"$JS.ACK.*.*.KV_"+bucket+".>",
+		)
+	}
+
+	return subjects
+}
+
+// reservedLatticeNames would prefix the wRPC subjects of their lattice onto the wasmCloud and wadm ones.
+var reservedLatticeNames = []string{"wasmbus", "wasmcloud", "wadm"}
+
+// checkLatticeName rejects lattices whose subjects would reach beyond the lattice: names that
+// aren't a single subject token, reserved names and the "$" and "_" prefixes NATS uses.
+func checkLatticeName(lattice string) error {
+	if lattice == "" || strings.ContainsAny(lattice, ".*> \t\r\n") {
+		return fmt.Errorf("lattice %q must be a single subject token", lattice)
+	}
+	if slices.Contains(reservedLatticeNames, lattice) || strings.HasPrefix(lattice, "$") || strings.HasPrefix(lattice, "_") {
+		return fmt.Errorf("lattice name %q is reserved", lattice)
+	}
+	return nil
+}
+
+// latticeSubjects lists the subjects of a lattice: control interface, events,
+// RPC (including wRPC, which is prefixed by the lattice name) and the lattice KV buckets.
+func latticeSubjects(lattice string) []string {
+	return []string{
+		"wasmbus.ctl.*." + lattice + ".>",
+		"wasmbus.evt." + lattice + ".>",
+		"wasmbus.rpc." + lattice + ".>",
+		lattice + ".>",
+		"$KV.LATTICEDATA_" + lattice + ".>",
+		"$KV.CONFIGDATA_" + lattice + ".>",
+	}
+}
@@ -0,0 +1,301 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nats-io/jwt/v2"
+	"github.com/nats-io/nkeys"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestHostGroupCreds(t *testing.T) {
+	accountKp, err := nkeys.CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountPub, err := accountKp.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hostGroup := &k8sv1alpha1.HostGroup{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "hosts"},
+		Spec:       k8sv1alpha1.HostGroupSpec{Lattice: "team-a"},
+	}
+
+	now := time.Now()
+
+	creds, claims, err := hostGroupCreds(nil, hostGroup, "default", accountKp, time.Hour, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(accountPub, claims.Issuer); diff != "" {
+		t.Errorf("issuer: -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff("team-a/hosts", claims.Name); diff != "" {
+		t.Errorf("name: -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff(now.Add(time.Hour).Unix(), claims.Expires); diff != "" {
+		t.Errorf("expires: -want, +got:\n%s", diff)
+	}
+	if !claims.Pub.Allow.Contains("wasmbus.evt.team-a.>") || claims.Pub.Allow.Contains("wasmbus.evt.default.>") {
+		t.Errorf("publish permissions not scoped to the lattice: %v", claims.Pub.Allow)
+	}
+	if !claims.Sub.Allow.Contains("wasmbus.ctl.*.team-a.>") {
+		t.Errorf("subscribe permissions missing the control interface: %v", claims.Sub.Allow)
+	}
+	for _, subject := range []string{"$JS.API.>", "$JS.default.API.>", "$JS.ACK.>"} {
+		if claims.Pub.Allow.Contains(subject) {
+			t.Errorf("publish permissions reach the JetStream API of every lattice: %s", subject)
+		}
+	}
+	for _, subject := range []string{
+		"$JS.API.STREAM.INFO.KV_LATTICEDATA_team-a",
+		"$JS.default.API.DIRECT.GET.KV_CONFIGDATA_team-a.>",
+		"$JS.API.CONSUMER.*.KV_LATTICEDATA_team-a.>",
+	} {
+		if !claims.Pub.Allow.Contains(subject) {
+			t.Errorf("publish permissions missing the lattice buckets: %s", subject)
+		}
+	}
+
+	t.Run("ReservedLattice", func(t *testing.T) {
+		reserved := hostGroup.DeepCopy()
+		reserved.Spec.Lattice = "wasmbus"
+		if _, _, err := hostGroupCreds(nil, reserved, "default", accountKp, time.Hour, now); err == nil {
+			t.Errorf("reserved lattice names should be rejected")
+		}
+	})
+
+	t.Run("Reuse", func(t *testing.T) {
+		again, againClaims, err := hostGroupCreds(creds, hostGroup, "default", accountKp, time.Hour, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(string(creds), string(again)); diff != "" {
+			t.Errorf("creds should be reused: -want, +got:\n%s", diff)
+		}
+		if diff := cmp.Diff(claims.ID, againClaims.ID); diff != "" {
+			t.Errorf("jwt id: -want, +got:\n%s", diff)
+		}
+	})
+
+	t.Run("Renewal", func(t *testing.T) {
+		again, againClaims, err := hostGroupCreds(creds, hostGroup, "default", accountKp, time.Hour, now.Add(45*time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(again) == string(creds) {
+			t.Errorf("creds should be renewed after two thirds of their lifetime")
+		}
+		if diff := cmp.Diff(claims.Subject, againClaims.Subject); diff != "" {
+			t.Errorf("user: -want, +got:\n%s", diff)
+		}
+		if diff := cmp.Diff(now.Add(105*time.Minute).Unix(), againClaims.Expires); diff != "" {
+			t.Errorf("expires: -want, +got:\n%s", diff)
+		}
+	})
+
+	t.Run("LatticeChange", func(t *testing.T) {
+		moved := hostGroup.DeepCopy()
+		moved.Spec.Lattice = "team-b"
+
+		again, againClaims, err := hostGroupCreds(creds, moved, "default", accountKp, time.Hour, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(again) == string(creds) {
+			t.Errorf("creds should be re-signed when the lattice changes")
+		}
+		// the user keeps its identity
+		if diff := cmp.Diff(claims.Subject, againClaims.Subject); diff != "" {
+			t.Errorf("user: -want, +got:\n%s", diff)
+		}
+	})
+
+	t.Run("SeparateUsers", func(t *testing.T) {
+		other := hostGroup.DeepCopy()
+		other.Name = "other"
+
+		_, otherClaims, err := hostGroupCreds(nil, other, "default", accountKp, time.Hour, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if otherClaims.Subject == claims.Subject {
+			t.Errorf("host groups should not share a user")
+		}
+	})
+
+	if _, err := jwt.ParseDecoratedUserNKey(creds); err != nil {
+		t.Errorf("creds should carry the user seed: %v", err)
+	}
+}
+
+func TestHostGroupPermissions(t *testing.T) {
+	permissions := hostGroupPermissions("team-a", "default", hostGroupInboxPrefix("HostGroup", "team-a", "hosts.v2"))
+
+	cases := map[string]struct {
+		subject string
+		pub     bool
+		sub     bool
+	}{
+		"ControlInterface": {
+			subject: "wasmbus.ctl.v1.team-a.host.ping",
+			pub:     true,
+			sub:     true,
+		},
+		"OwnInbox": {
+			subject: "_INBOX_HOSTGROUP_team-a_hosts_v2.abc.1",
+			sub:     true,
+		},
+		"SharedInbox": {
+			subject: "_INBOX.abc.1",
+		},
+		"OtherHostGroupInbox": {
+			subject: "_INBOX_HOSTGROUP_team-a_other.abc.1",
+		},
+		"ExternalHostGroupInbox": {
+			subject: "_INBOX_EXTERNALHOSTGROUP_team-a_hosts_v2.abc.1",
+		},
+		"ServiceAccountInbox": {
+			subject: serviceAccountInboxPrefix("team-a", "hosts.v2") + ".abc.1",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := subjectAllowed(permissions.Pub, tc.subject); got != tc.pub {
+				t.Errorf("publish on %s: got %v, want %v", tc.subject, got, tc.pub)
+			}
+			if got := subjectAllowed(permissions.Sub, tc.subject); got != tc.sub {
+				t.Errorf("subscribe on %s: got %v, want %v", tc.subject, got, tc.sub)
+			}
+		})
+	}
+
+	// replies go out through the response permission instead
+	if permissions.Resp == nil || permissions.Resp.MaxMsgs != 1 {
+		t.Errorf("response permission: %+v", permissions.Resp)
+	}
+}
+
+func TestReconcileCredentials(t *testing.T) {
+	accountKp, err := nkeys.CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountSeed, err := accountKp.Seed()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		nats       k8sv1alpha1.NatsSpec
+		wantStatus bool
+	}{
+		"Managed": {
+			nats:       k8sv1alpha1.NatsSpec{Managed: &k8sv1alpha1.NatsManagedSpec{}},
+			wantStatus: true,
+		},
+		"ExternalNats": {
+			// hosts share the Cluster credentials, there is no HostGroup user to report
+			nats: k8sv1alpha1.NatsSpec{
+				External: &k8sv1alpha1.NatsExternalSpec{URLs: []string{"tls://nats.example.com:4443"}},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			cluster := &k8sv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "wasmcloud-system", Name: "wasmcloud"}}
+			cluster.Spec.Nats = tc.nats
+
+			hostGroup := &k8sv1alpha1.HostGroup{
+				TypeMeta:   metav1.TypeMeta{APIVersion: k8sv1alpha1.GroupVersion.String(), Kind: "HostGroup"},
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "hosts"},
+				Spec:       k8sv1alpha1.HostGroupSpec{Lattice: "team-a"},
+			}
+
+			clusterCreds := testSystemCreds(t)
+			r := &HostGroupReconciler{Client: newFakeClient(t,
+				cluster,
+				hostGroup,
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Namespace: cluster.GetNamespace(), Name: cluster.NatsSeedSecret()},
+					Data:       map[string][]byte{"account": accountSeed},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Namespace: cluster.GetNamespace(), Name: cluster.NatsClientSecret()},
+					Data:       map[string][]byte{"user.jwt": clusterCreds, "ca.crt": []byte("ca")},
+				},
+			)}
+
+			// every reconcile starts from the stored HostGroup, whose status may lag behind
+			for pass := range 2 {
+				var stored k8sv1alpha1.HostGroup
+				if err := r.Get(ctx, client.ObjectKeyFromObject(hostGroup), &stored); err != nil {
+					t.Fatal(err)
+				}
+				stored.TypeMeta = hostGroup.TypeMeta
+
+				if err := r.reconcileCredentials(ctx, cluster, &stored); err != nil {
+					t.Fatal(err)
+				}
+
+				var secret corev1.Secret
+				if err := r.Get(ctx, client.ObjectKey{Namespace: hostGroup.GetNamespace(), Name: hostGroup.NatsClientSecret()}, &secret); err != nil {
+					t.Fatal(err)
+				}
+
+				if !tc.wantStatus {
+					if stored.Status.Credentials != nil {
+						t.Errorf("pass %d: no credentials should be reported, got %+v", pass, stored.Status.Credentials)
+					}
+					if diff := cmp.Diff(string(clusterCreds), string(secret.Data["user.jwt"])); diff != "" {
+						t.Errorf("pass %d: creds: -want, +got:\n%s", pass, diff)
+					}
+					continue
+				}
+
+				want, err := hostCredentialsStatus(secret.Data["user.jwt"])
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(want, stored.Status.Credentials); diff != "" {
+					t.Errorf("pass %d: status should describe the stored user: -want, +got:\n%s", pass, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckLatticeName(t *testing.T) {
+	cases := map[string]bool{
+		"default":   true,
+		"team-a":    true,
+		"wasmbus":   false,
+		"wasmcloud": false,
+		"wadm":      false,
+		"$JS":       false,
+		"_INBOX":    false,
+		"team.a":    false,
+		"*":         false,
+		">":         false,
+		"":          false,
+	}
+
+	for lattice, valid := range cases {
+		if err := checkLatticeName(lattice); (err == nil) != valid {
+			t.Errorf("checkLatticeName(%q) = %v, want valid %v", lattice, err, valid)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package k8s
+
+import (
+	"fmt"
+	"slices"
+
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newContainer layers a user ContainerSpec on top of the operator defaults in base.
+// Env, EnvFrom and VolumeMounts are merged (operator env vars win on conflicts) and Args
+// are appended to the operator ones, which the workloads need. Every other field set in
+// spec replaces the default.
+func newContainer(base corev1.Container, spec k8sv1alpha1.ContainerSpec) corev1.Container {
+	container := *base.DeepCopy()
+
+	if spec.Image != "" {
+		container.Image = spec.Image
+	}
+	if len(spec.Command) > 0 {
+		container.Command = spec.Command
+	}
+	if len(spec.Args) > 0 {
+		container.Args = slices.Concat(base.Args, spec.Args)
+	}
+	if spec.WorkingDir != "" {
+		container.WorkingDir = spec.WorkingDir
+	}
+	if spec.ImagePullPolicy != "" {
+		container.ImagePullPolicy = spec.ImagePullPolicy
+	}
+	if spec.Resources != nil {
+		container.Resources = *spec.Resources
+	}
+	if spec.ContainerSecurityContext != nil {
+		container.SecurityContext = spec.ContainerSecurityContext
+	}
+	if spec.ReadinessProbe != nil {
+		container.ReadinessProbe = spec.ReadinessProbe
+	}
+	if spec.LivenessProbe != nil {
+		container.LivenessProbe = spec.LivenessProbe
+	}
+
+	container.Env = mergeEnvVar(spec.Env, base.Env)
+	container.EnvFrom = mergeEnvFromSource(base.EnvFrom, spec.EnvFrom)
+	container.VolumeMounts = mergeMounts(base.VolumeMounts, spec.VolumeMounts)
+
+	return container
+}
+
+// newPodTemplate renders the pod template shared by every operator managed workload.
+// main is the operator built container, customized by container; replica drives
+// scheduling, security and any extra init or sidecar containers.
+func newPodTemplate(
+	labels map[string]string,
+	replica k8sv1alpha1.ReplicaSpec,
+	container k8sv1alpha1.ContainerSpec,
+	main corev1.Container,
+	volumes []corev1.Volume,
+) corev1.PodTemplateSpec {
+	containers := []corev1.Container{newContainer(main, container)}
+	for i, sidecar := range replica.Containers {
+		containers = append(containers, newContainer(corev1.Container{Name: fmt.Sprintf("%s-sidecar-%d", main.Name, i)}, sidecar))
+	}
+
+	var initContainers []corev1.Container
+	for i, init := range replica.InitContainers {
+		initContainers = append(initContainers, newContainer(corev1.Container{Name: fmt.Sprintf("%s-init-%d", main.Name, i)}, init))
+	}
+
+	return corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: mergeLabels(replica.Labels, labels),
+		},
+		Spec: corev1.PodSpec{
+			EnableServiceLinks:            boolPtr(false),
+			AutomountServiceAccountToken:  replica.AutomountServiceAccountToken,
+			TerminationGracePeriodSeconds: int64Ptr(0),
+			ServiceAccountName:            replica.ServiceAccountName,
+			ImagePullSecrets:              container.ImagePullSecrets,
+			Affinity:                      replica.Affinity,
+			NodeSelector:                  replica.NodeSelector,
+			Tolerations:                   replica.Tolerations,
+			TopologySpreadConstraints:     replica.TopologySpreadConstraints,
+			SecurityContext:               replica.SecurityContext,
+			InitContainers:                initContainers,
+			Containers:                    containers,
+			Volumes:                       slices.Concat(volumes, replica.Volumes),
+		},
+	}
+}
@@ -0,0 +1,246 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestNewContainer(t *testing.T) {
+	base := corev1.Container{
+		Name:         "nats",
+		Image:        "nats:default",
+		Args:         []string{"--config", "/config/nats.conf"},
+		Env:          []corev1.EnvVar{{Name: "SERVER_NAME", Value: "operator"}},
+		VolumeMounts: []corev1.VolumeMount{{Name: "config", MountPath: "/config"}},
+	}
+
+	probe := &corev1.Probe{PeriodSeconds: 7}
+	resources := &corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+	}
+	securityContext := &corev1.SecurityContext{RunAsNonRoot: boolPtr(true)}
+
+	cases := map[string]struct {
+		spec  k8sv1alpha1.ContainerSpec
+		check func(c corev1.Container) (interface{}, interface{})
+	}{
+		"DefaultsKept": {
+			spec: k8sv1alpha1.ContainerSpec{},
+			check: func(c corev1.Container) (interface{}, interface{}) {
+				return []string{"nats:default", "--config"}, []string{c.Image, c.Args[0]}
+			},
+		},
+		"Image": {
+			spec: k8sv1alpha1.ContainerSpec{Image: "nats:pinned"},
+			check: func(c corev1.Container) (interface{}, interface{}) {
+				return "nats:pinned", c.Image
+			},
+		},
+		"Command": {
+			spec: k8sv1alpha1.ContainerSpec{Command: []string{"/bin/nats"}},
+			check: func(c corev1.Container) (interface{}, interface{}) {
+				return []string{"/bin/nats"}, c.Command
+			},
+		},
+		"Args": {
+			spec: k8sv1alpha1.ContainerSpec{Args: []string{"--debug"}},
+			check: func(c corev1.Container) (interface{}, interface{}) {
+				return []string{"--config", "/config/nats.conf", "--debug"}, c.Args
+			},
+		},
+		"WorkingDir": {
+			spec: k8sv1alpha1.ContainerSpec{WorkingDir: "/data"},
+			check: func(c corev1.Container) (interface{}, interface{}) {
+				return "/data", c.WorkingDir
+			},
+		},
+		"ImagePullPolicy": {
+			spec: k8sv1alpha1.ContainerSpec{ImagePullPolicy: corev1.PullAlways},
+			check: func(c corev1.Container) (interface{}, interface{}) {
+				return corev1.PullAlways, c.ImagePullPolicy
+			},
+		},
+		"Resources": {
+			spec: k8sv1alpha1.ContainerSpec{Resources: resources},
+			check: func(c corev1.Container) (interface{}, interface{}) {
+				return "1Gi", c.Resources.Limits.Memory().String()
+			},
+		},
+		"ContainerSecurityContext": {
+			spec: k8sv1alpha1.ContainerSpec{ContainerSecurityContext: securityContext},
+			check: func(c corev1.Container) (interface{}, interface{}) {
+				return securityContext, c.SecurityContext
+			},
+		},
+		"ReadinessProbe": {
+			spec: k8sv1alpha1.ContainerSpec{ReadinessProbe: probe},
+			check: func(c corev1.Container) (interface{}, interface{}) {
+				return probe, c.ReadinessProbe
+			},
+		},
+		"LivenessProbe": {
+			spec: k8sv1alpha1.ContainerSpec{LivenessProbe: probe},
+			check: func(c corev1.Container) (interface{}, interface{}) {
+				return probe, c.LivenessProbe
+			},
+		},
+		"EnvMergedOperatorWins": {
+			spec: k8sv1alpha1.ContainerSpec{Env: []corev1.EnvVar{
+				{Name: "EXTRA", Value: "user"},
+				{Name: "SERVER_NAME", Value: "user"},
+			}},
+			check: func(c corev1.Container) (interface{}, interface{}) {
+				return []corev1.EnvVar{
+					{Name: "EXTRA", Value: "user"},
+					{Name: "SERVER_NAME", Value: "operator"},
+				}, c.Env
+			},
+		},
+		"EnvFrom": {
+			spec: k8sv1alpha1.ContainerSpec{EnvFrom: []corev1.EnvFromSource{{Prefix: "USER_"}}},
+			check: func(c corev1.Container) (interface{}, interface{}) {
+				return []corev1.EnvFromSource{{Prefix: "USER_"}}, c.EnvFrom
+			},
+		},
+		"VolumeMountsAppended": {
+			spec: k8sv1alpha1.ContainerSpec{VolumeMounts: []corev1.VolumeMount{{Name: "extra", MountPath: "/extra"}}},
+			check: func(c corev1.Container) (interface{}, interface{}) {
+				return []corev1.VolumeMount{
+					{Name: "config", MountPath: "/config"},
+					{Name: "extra", MountPath: "/extra"},
+				}, c.VolumeMounts
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			want, got := tc.check(newContainer(base, tc.spec))
+
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("newContainer(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewPodTemplate(t *testing.T) {
+	main := corev1.Container{Name: "wadm", Image: "wadm:default"}
+	volumes := []corev1.Volume{{Name: "creds"}}
+	labels := map[string]string{"cluster": "test"}
+
+	affinity := &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{}}
+	podSecurityContext := &corev1.PodSecurityContext{RunAsUser: int64Ptr(1000)}
+
+	cases := map[string]struct {
+		replica   k8sv1alpha1.ReplicaSpec
+		container k8sv1alpha1.ContainerSpec
+		check     func(p corev1.PodTemplateSpec) (interface{}, interface{})
+	}{
+		"Labels": {
+			replica: k8sv1alpha1.ReplicaSpec{Labels: map[string]string{"team": "platform", "cluster": "other"}},
+			check: func(p corev1.PodTemplateSpec) (interface{}, interface{}) {
+				return map[string]string{"team": "platform", "cluster": "test"}, p.Labels
+			},
+		},
+		"Affinity": {
+			replica: k8sv1alpha1.ReplicaSpec{Affinity: affinity},
+			check: func(p corev1.PodTemplateSpec) (interface{}, interface{}) {
+				return affinity, p.Spec.Affinity
+			},
+		},
+		"AutomountServiceAccountToken": {
+			replica: k8sv1alpha1.ReplicaSpec{AutomountServiceAccountToken: boolPtr(false)},
+			check: func(p corev1.PodTemplateSpec) (interface{}, interface{}) {
+				return boolPtr(false), p.Spec.AutomountServiceAccountToken
+			},
+		},
+		"NodeSelector": {
+			replica: k8sv1alpha1.ReplicaSpec{NodeSelector: map[string]string{"dedicated": "nats"}},
+			check: func(p corev1.PodTemplateSpec) (interface{}, interface{}) {
+				return map[string]string{"dedicated": "nats"}, p.Spec.NodeSelector
+			},
+		},
+		"Tolerations": {
+			replica: k8sv1alpha1.ReplicaSpec{Tolerations: []corev1.Toleration{{Key: "dedicated", Value: "nats"}}},
+			check: func(p corev1.PodTemplateSpec) (interface{}, interface{}) {
+				return []corev1.Toleration{{Key: "dedicated", Value: "nats"}}, p.Spec.Tolerations
+			},
+		},
+		"TopologySpreadConstraints": {
+			replica: k8sv1alpha1.ReplicaSpec{TopologySpreadConstraints: []corev1.TopologySpreadConstraint{{MaxSkew: 1}}},
+			check: func(p corev1.PodTemplateSpec) (interface{}, interface{}) {
+				return []corev1.TopologySpreadConstraint{{MaxSkew: 1}}, p.Spec.TopologySpreadConstraints
+			},
+		},
+		"SecurityContext": {
+			replica: k8sv1alpha1.ReplicaSpec{SecurityContext: podSecurityContext},
+			check: func(p corev1.PodTemplateSpec) (interface{}, interface{}) {
+				return podSecurityContext, p.Spec.SecurityContext
+			},
+		},
+		"Volumes": {
+			replica: k8sv1alpha1.ReplicaSpec{Volumes: []corev1.Volume{{Name: "extra"}}},
+			check: func(p corev1.PodTemplateSpec) (interface{}, interface{}) {
+				return []corev1.Volume{{Name: "creds"}, {Name: "extra"}}, p.Spec.Volumes
+			},
+		},
+		"ServiceAccountName": {
+			replica: k8sv1alpha1.ReplicaSpec{ServiceAccountName: "wadm"},
+			check: func(p corev1.PodTemplateSpec) (interface{}, interface{}) {
+				return "wadm", p.Spec.ServiceAccountName
+			},
+		},
+		"InitContainers": {
+			replica: k8sv1alpha1.ReplicaSpec{InitContainers: []k8sv1alpha1.ContainerSpec{{Image: "busybox"}}},
+			check: func(p corev1.PodTemplateSpec) (interface{}, interface{}) {
+				return []string{"wadm-init-0", "busybox"}, []string{p.Spec.InitContainers[0].Name, p.Spec.InitContainers[0].Image}
+			},
+		},
+		"Containers": {
+			replica: k8sv1alpha1.ReplicaSpec{Containers: []k8sv1alpha1.ContainerSpec{{Image: "envoy"}}},
+			check: func(p corev1.PodTemplateSpec) (interface{}, interface{}) {
+				return []string{"wadm", "wadm-sidecar-0", "envoy"}, []string{p.Spec.Containers[0].Name, p.Spec.Containers[1].Name, p.Spec.Containers[1].Image}
+			},
+		},
+		"ImagePullSecrets": {
+			container: k8sv1alpha1.ContainerSpec{ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}}},
+			check: func(p corev1.PodTemplateSpec) (interface{}, interface{}) {
+				return []corev1.LocalObjectReference{{Name: "registry"}}, p.Spec.ImagePullSecrets
+			},
+		},
+		"MainContainer": {
+			container: k8sv1alpha1.ContainerSpec{Image: "wadm:pinned"},
+			check: func(p corev1.PodTemplateSpec) (interface{}, interface{}) {
+				return "wadm:pinned", p.Spec.Containers[0].Image
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			want, got := tc.check(newPodTemplate(labels, tc.replica, tc.container, main, volumes))
+
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("newPodTemplate(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+	t.Run("VolumesNotShared", func(t *testing.T) {
+		// callers build volumes with spare capacity, the extra volumes must not land in it
+		owned := make([]corev1.Volume, 1, 4)
+		owned[0] = corev1.Volume{Name: "creds"}
+		replica := k8sv1alpha1.ReplicaSpec{Volumes: []corev1.Volume{{Name: "extra"}}}
+
+		first := newPodTemplate(labels, replica, k8sv1alpha1.ContainerSpec{}, main, owned)
+		owned = append(owned, corev1.Volume{Name: "config"})
+
+		if diff := cmp.Diff([]corev1.Volume{{Name: "creds"}, {Name: "extra"}}, first.Spec.Volumes); diff != "" {
+			t.Errorf("volumes: -want, +got:\n%s", diff)
+		}
+	})
+}
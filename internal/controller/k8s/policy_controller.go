@@ -22,9 +22,11 @@ import (
 	"sync"
 
 	"github.com/open-policy-agent/opa/v1/rego"
+	"go.wasmcloud.dev/operator/internal/natsconn"
 	"go.wasmcloud.dev/x/wasmbus"
 	"go.wasmcloud.dev/x/wasmbus/policy"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -39,6 +41,10 @@ type PolicyReconciler struct {
 	Scheme             *runtime.Scheme
 	ConfigMapNamespace string
 	ConfigMapName      string
+	// Cluster whose lattice the policy server answers on.
+	// The policy server is disabled if blank.
+	Cluster     types.NamespacedName
+	Connections *natsconn.Manager
 
 	policyServer *policyServer
 }
@@ -84,11 +90,10 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 // SetupWithManager sets up the controller with the Manager.
 func (r *PolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	var err error
-	// TODO(lxf): hook this back up
-	if true {
+	if r.Cluster.Name == "" {
 		return nil
 	}
-	r.policyServer = newPolicyServer(nil, "wasmcloud.policy")
+	r.policyServer = newPolicyServer(r.Connections, r.Cluster, "wasmcloud.policy")
 
 	if err = mgr.Add(r.policyServer); err != nil {
 		return err
@@ -105,7 +110,10 @@ func (r *PolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
 }
 
 type policyServer struct {
-	server   *policy.Server
+	connections *natsconn.Manager
+	cluster     types.NamespacedName
+	subject     string
+
 	policies []func(r *rego.Rego)
 	lock     sync.Mutex
 }
@@ -130,13 +138,12 @@ func (s *policyServer) StartProvider(
 	return servePolicy(ctx, req, s.policies...)
 }
 
-func newPolicyServer(bus wasmbus.Bus, subject string) *policyServer {
-	s := &policyServer{}
-
-	server := policy.NewServer(bus, subject, s)
-	s.server = server
-
-	return s
+func newPolicyServer(connections *natsconn.Manager, cluster types.NamespacedName, subject string) *policyServer {
+	return &policyServer{
+		connections: connections,
+		cluster:     cluster,
+		subject:     subject,
+	}
 }
 
 func (s *policyServer) NeedLeaderElection() bool {
@@ -150,11 +157,9 @@ func (s *policyServer) setPolicies(policy ...func(r *rego.Rego)) {
 }
 
 func (s *policyServer) Start(ctx context.Context) error {
-	if err := s.server.Serve(); err != nil {
-		return err
-	}
-	<-ctx.Done()
-	return s.server.Drain()
+	return s.connections.Serve(ctx, s.cluster, func(bus wasmbus.Bus) natsconn.Server {
+		return policy.NewServer(bus, s.subject, s)
+	})
 }
 
 type policyRequest interface {
@@ -1,11 +1,94 @@
 package k8s
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"sort"
 
+	"go.wasmcloud.dev/operator/api/condition"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// recordCondition sets a ready or error condition of the given type,
+// passing the error through.
+func recordCondition(status *condition.ConditionedStatus, tpy string, err error) error {
+	if err != nil {
+		status.SetConditions(condition.ErrorCondition(tpy, err))
+		return err
+	}
+	status.SetConditions(condition.ReadyCondition(tpy))
+	return nil
+}
+
+// checksumAnnotation carries a hash of the config a pod consumes, so config changes roll the pods.
+const checksumAnnotation = "k8s.wasmcloud.dev/config-checksum"
+
+// contentChecksum hashes the JSON form of objs. Map keys are sorted by encoding/json,
+// so the result is stable for Secret and ConfigMap data.
+func contentChecksum(objs ...interface{}) (string, error) {
+	h := sha256.New()
+	for _, obj := range objs {
+		raw, err := json.Marshal(obj)
+		if err != nil {
+			return "", err
+		}
+		h.Write(raw)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// objectChecksum fetches a Secret or ConfigMap a pod consumes and hashes its data.
+// When keys are given only those entries are hashed, so the others can change without a rollout.
+func objectChecksum(ctx context.Context, apiClient client.Client, key client.ObjectKey, obj client.Object, keys ...string) (string, error) {
+	if err := apiClient.Get(ctx, key, obj); err != nil {
+		return "", err
+	}
+
+	switch o := obj.(type) {
+	case *corev1.Secret:
+		return contentChecksum(selectKeys(o.Data, keys))
+	case *corev1.ConfigMap:
+		return contentChecksum(selectKeys(o.Data, keys), selectKeys(o.BinaryData, keys))
+	default:
+		return "", fmt.Errorf("can't checksum %T", obj)
+	}
+}
+
+// selectKeys returns the entries of data under keys, or data itself when no keys are given.
+func selectKeys[V any](data map[string]V, keys []string) map[string]V {
+	if len(keys) == 0 {
+		return data
+	}
+
+	ret := make(map[string]V, len(keys))
+	for _, k := range keys {
+		if v, ok := data[k]; ok {
+			ret[k] = v
+		}
+	}
+	return ret
+}
+
+// statefulSetReady returns an error until every desired replica is ready.
+func statefulSetReady(statefulset *appsv1.StatefulSet) error {
+	want := int32(1)
+	if statefulset.Spec.Replicas != nil {
+		want = *statefulset.Spec.Replicas
+	}
+	if statefulset.Status.ReadyReplicas < want {
+		return fmt.Errorf("%d/%d replicas ready", statefulset.Status.ReadyReplicas, want)
+	}
+	return nil
+}
+
 func mergeLabels(lbls ...map[string]string) map[string]string {
 	ret := make(map[string]string)
 
@@ -72,3 +155,40 @@ func int32Ptr(i int32) *int32 {
 func boolPtr(t bool) *bool {
 	return &t
 }
+
+// tarArchive packs files into a gzipped tarball. Files are sorted and carry no timestamps,
+// so the same files always give the same archive.
+func tarArchive(files map[string][]byte) ([]byte, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for _, name := range names {
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(files[name])),
+		}); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
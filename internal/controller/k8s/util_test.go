@@ -0,0 +1,80 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestObjectChecksum(t *testing.T) {
+	base := map[string][]byte{
+		"ca.crt":   []byte("ca"),
+		"user.jwt": []byte("jwt"),
+		"user.nk":  []byte("seed"),
+	}
+
+	cases := map[string]struct {
+		keys    []string
+		changed map[string][]byte
+		want    bool
+	}{
+		"AllKeysChanged": {
+			changed: map[string][]byte{"user.nk": []byte("rotated")},
+			want:    true,
+		},
+		"SelectedKeyChanged": {
+			keys:    []string{"ca.crt", "user.jwt"},
+			changed: map[string][]byte{"user.jwt": []byte("renewed")},
+			want:    true,
+		},
+		"OtherKeyChanged": {
+			// creds re-read by their consumers don't roll the pods
+			keys:    []string{"ca.crt", "user.jwt"},
+			changed: map[string][]byte{"user.nk": []byte("rotated")},
+			want:    false,
+		},
+		"Unchanged": {
+			keys: []string{"ca.crt", "user.jwt"},
+			want: false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "creds"},
+				Data:       map[string][]byte{},
+			}
+			for k, v := range base {
+				secret.Data[k] = v
+			}
+			apiClient := newFakeClient(t, secret)
+			key := client.ObjectKeyFromObject(secret)
+
+			before, err := objectChecksum(ctx, apiClient, key, &corev1.Secret{}, tc.keys...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for k, v := range tc.changed {
+				secret.Data[k] = v
+			}
+			if err := apiClient.Update(ctx, secret); err != nil {
+				t.Fatal(err)
+			}
+
+			after, err := objectChecksum(ctx, apiClient, key, &corev1.Secret{}, tc.keys...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, before != after); diff != "" {
+				t.Errorf("checksum changed: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package oam
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	coreoamv1beta1 "go.wasmcloud.dev/operator/api/oam/core/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const conditionClusterResolved = "ClusterResolved"
+
+// ParseClusterReference parses a "name" or "namespace/name" Cluster reference.
+// References without a namespace resolve to defaultNamespace.
+func ParseClusterReference(ref string, defaultNamespace string) (types.NamespacedName, error) {
+	parts := strings.Split(ref, "/")
+	switch {
+	case len(parts) == 1 && parts[0] != "":
+		return types.NamespacedName{Namespace: defaultNamespace, Name: parts[0]}, nil
+	case len(parts) == 2 && parts[0] != "" && parts[1] != "":
+		return types.NamespacedName{Namespace: parts[0], Name: parts[1]}, nil
+	default:
+		return types.NamespacedName{}, fmt.Errorf("invalid cluster reference %q", ref)
+	}
+}
+
+// clusterKey picks the Cluster an Application is bound to.
+// Order of precedence: Application spec, Namespace annotation, operator default.
+func (r *ApplicationReconciler) clusterKey(ctx context.Context, application *coreoamv1beta1.Application) (types.NamespacedName, error) {
+	if ref := application.Spec.Cluster; ref != nil && ref.Name != "" {
+		namespace := ref.Namespace
+		if namespace == "" {
+			namespace = application.GetNamespace()
+		}
+		return types.NamespacedName{Namespace: namespace, Name: ref.Name}, nil
+	}
+
+	var namespace corev1.Namespace
+	if err := r.Get(ctx, client.ObjectKey{Name: application.GetNamespace()}, &namespace); err != nil {
+		return types.NamespacedName{}, err
+	}
+	if ref, ok := namespace.GetAnnotations()[coreoamv1beta1.ClusterAnnotation]; ok {
+		return ParseClusterReference(ref, namespace.GetName())
+	}
+
+	if r.DefaultCluster.Name != "" {
+		return r.DefaultCluster, nil
+	}
+
+	return types.NamespacedName{}, fmt.Errorf("no cluster bound to application, namespace %q or operator", application.GetNamespace())
+}
+
+func (r *ApplicationReconciler) resolveCluster(ctx context.Context, application *coreoamv1beta1.Application) (*k8sv1alpha1.Cluster, error) {
+	key, err := r.clusterKey(ctx, application)
+	if err != nil {
+		return nil, err
+	}
+
+	var cluster k8sv1alpha1.Cluster
+	if err := r.Get(ctx, key, &cluster); err != nil {
+		return nil, fmt.Errorf("cluster %s: %w", key, err)
+	}
+
+	if ref := application.Spec.Cluster; ref != nil && ref.Name != "" && !clusterAllowsNamespace(&cluster, application.GetNamespace()) {
+		return nil, fmt.Errorf("cluster %s doesn't allow applications from namespace %q, see the %s annotation",
+			key, application.GetNamespace(), coreoamv1beta1.ClusterAllowedNamespacesAnnotation)
+	}
+
+	return &cluster, nil
+}
+
+// clusterAllowsNamespace tells whether Applications in namespace may name the Cluster in their spec.
+// Its own namespace always may, others have to be listed in its allowed namespaces annotation.
+func clusterAllowsNamespace(cluster *k8sv1alpha1.Cluster, namespace string) bool {
+	if cluster.GetNamespace() == namespace {
+		return true
+	}
+	allowed := cluster.GetAnnotations()[coreoamv1beta1.ClusterAllowedNamespacesAnnotation]
+	for _, ns := range strings.Split(allowed, ",") {
+		if ns = strings.TrimSpace(ns); ns == "*" || ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// clusterChanged tells whether the Application was deployed to another Cluster than the one it is bound to now.
+func clusterChanged(application *coreoamv1beta1.Application, cluster *k8sv1alpha1.Cluster) bool {
+	deployed := application.Status.Cluster
+	return deployed != nil && (deployed.Namespace != cluster.GetNamespace() || deployed.Name != cluster.GetName())
+}
+
+// deployedCluster returns the Cluster to clean an Application up from, nil if it is gone.
+// That's the Cluster recorded at deploy time. Applications without one fall back to their
+// current binding, and an unresolvable binding counts as a missing Cluster so deletion isn't blocked.
+func (r *ApplicationReconciler) deployedCluster(ctx context.Context, application *coreoamv1beta1.Application) (*k8sv1alpha1.Cluster, error) {
+	var key types.NamespacedName
+	if ref := application.Status.Cluster; ref != nil {
+		key = types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}
+	} else {
+		var err error
+		if key, err = r.clusterKey(ctx, application); err != nil {
+			log.FromContext(ctx).Info("unable to resolve cluster, skipping cleanup", "error", err.Error())
+			return nil, nil
+		}
+	}
+
+	var cluster k8sv1alpha1.Cluster
+	if err := r.Get(ctx, key, &cluster); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+
+	return &cluster, nil
+}
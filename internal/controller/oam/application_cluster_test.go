@@ -0,0 +1,339 @@
+package oam
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	coreoamv1beta1 "go.wasmcloud.dev/operator/api/oam/core/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newFakeClient(t *testing.T, objs ...client.Object) client.Client {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{
+		clientgoscheme.AddToScheme,
+		k8sv1alpha1.AddToScheme,
+		coreoamv1beta1.AddToScheme,
+	} {
+		if err := add(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
+
+func namespaceWithBinding(name string, ref string) *corev1.Namespace {
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if ref != "" {
+		namespace.Annotations = map[string]string{coreoamv1beta1.ClusterAnnotation: ref}
+	}
+	return namespace
+}
+
+func TestParseClusterReference(t *testing.T) {
+	cases := map[string]struct {
+		ref     string
+		want    types.NamespacedName
+		wantErr bool
+	}{
+		"Name": {
+			ref:  "wasmcloud",
+			want: types.NamespacedName{Namespace: "team-a", Name: "wasmcloud"},
+		},
+		"NamespacedName": {
+			ref:  "wasmcloud-system/wasmcloud",
+			want: types.NamespacedName{Namespace: "wasmcloud-system", Name: "wasmcloud"},
+		},
+		"Empty": {
+			ref:     "",
+			wantErr: true,
+		},
+		"MissingName": {
+			ref:     "wasmcloud-system/",
+			wantErr: true,
+		},
+		"MissingNamespace": {
+			ref:     "/wasmcloud",
+			wantErr: true,
+		},
+		"ExtraSegments": {
+			ref:     "a/b/c",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseClusterReference(tc.ref, "team-a")
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ParseClusterReference(%q): -want, +got:\n%s", tc.ref, diff)
+			}
+		})
+	}
+}
+
+func TestClusterKey(t *testing.T) {
+	defaultCluster := types.NamespacedName{Namespace: "wasmcloud-system", Name: "default"}
+
+	cases := map[string]struct {
+		spec           *corev1.ObjectReference
+		binding        string
+		defaultCluster types.NamespacedName
+		want           types.NamespacedName
+		wantErr        bool
+	}{
+		"Spec": {
+			spec:           &corev1.ObjectReference{Namespace: "wasmcloud-system", Name: "spec"},
+			binding:        "wasmcloud-system/namespace",
+			defaultCluster: defaultCluster,
+			want:           types.NamespacedName{Namespace: "wasmcloud-system", Name: "spec"},
+		},
+		"SpecInApplicationNamespace": {
+			spec: &corev1.ObjectReference{Name: "spec"},
+			want: types.NamespacedName{Namespace: "team-a", Name: "spec"},
+		},
+		"Namespace": {
+			binding:        "wasmcloud-system/namespace",
+			defaultCluster: defaultCluster,
+			want:           types.NamespacedName{Namespace: "wasmcloud-system", Name: "namespace"},
+		},
+		"NamespaceWithoutNamespace": {
+			binding: "namespace",
+			want:    types.NamespacedName{Namespace: "team-a", Name: "namespace"},
+		},
+		"InvalidNamespaceBinding": {
+			binding:        "a/b/c",
+			defaultCluster: defaultCluster,
+			wantErr:        true,
+		},
+		"Default": {
+			defaultCluster: defaultCluster,
+			want:           defaultCluster,
+		},
+		"Unbound": {
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := &ApplicationReconciler{
+				Client:         newFakeClient(t, namespaceWithBinding("team-a", tc.binding)),
+				DefaultCluster: tc.defaultCluster,
+			}
+			application := &coreoamv1beta1.Application{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "app"}}
+			application.Spec.Cluster = tc.spec
+
+			got, err := r.clusterKey(context.Background(), application)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("clusterKey: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDeployedCluster(t *testing.T) {
+	deployed := &k8sv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "wasmcloud-system", Name: "deployed"}}
+	bound := &k8sv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "wasmcloud-system", Name: "bound"}}
+
+	cases := map[string]struct {
+		recorded *corev1.ObjectReference
+		binding  string
+		want     string
+	}{
+		"Recorded": {
+			// the binding moved after the deploy, cleanup happens where the app runs
+			recorded: &corev1.ObjectReference{Namespace: "wasmcloud-system", Name: "deployed"},
+			binding:  "wasmcloud-system/bound",
+			want:     "deployed",
+		},
+		"RecordedGone": {
+			recorded: &corev1.ObjectReference{Namespace: "wasmcloud-system", Name: "deleted"},
+			binding:  "wasmcloud-system/bound",
+		},
+		"Binding": {
+			binding: "wasmcloud-system/bound",
+			want:    "bound",
+		},
+		"BindingGone": {
+			binding: "wasmcloud-system/deleted",
+		},
+		"Unresolvable": {
+			binding: "a/b/c",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := &ApplicationReconciler{
+				Client: newFakeClient(t, deployed, bound, namespaceWithBinding("team-a", tc.binding)),
+			}
+			application := &coreoamv1beta1.Application{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "app"}}
+			application.Status.Cluster = tc.recorded
+
+			cluster, err := r.deployedCluster(context.Background(), application)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := ""
+			if cluster != nil {
+				got = cluster.GetName()
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("cluster: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestResolveClusterAllowedNamespaces(t *testing.T) {
+	cases := map[string]struct {
+		spec    *corev1.ObjectReference
+		binding string
+		allowed string
+		wantErr bool
+	}{
+		"SameNamespace": {
+			spec: &corev1.ObjectReference{Namespace: "team-a", Name: "wasmcloud"},
+		},
+		"OtherNamespace": {
+			spec:    &corev1.ObjectReference{Namespace: "wasmcloud-system", Name: "wasmcloud"},
+			wantErr: true,
+		},
+		"OtherNamespaceAllowed": {
+			spec:    &corev1.ObjectReference{Namespace: "wasmcloud-system", Name: "wasmcloud"},
+			allowed: "team-b, team-a",
+		},
+		"OtherNamespaceNotListed": {
+			spec:    &corev1.ObjectReference{Namespace: "wasmcloud-system", Name: "wasmcloud"},
+			allowed: "team-b",
+			wantErr: true,
+		},
+		"OtherNamespaceWildcard": {
+			spec:    &corev1.ObjectReference{Namespace: "wasmcloud-system", Name: "wasmcloud"},
+			allowed: "*",
+		},
+		"NamespaceBinding": {
+			// namespace annotations are up to admins
+			binding: "wasmcloud-system/wasmcloud",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var objs []client.Object
+			for _, namespace := range []string{"team-a", "wasmcloud-system"} {
+				cluster := &k8sv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "wasmcloud"}}
+				if tc.allowed != "" {
+					cluster.Annotations = map[string]string{coreoamv1beta1.ClusterAllowedNamespacesAnnotation: tc.allowed}
+				}
+				objs = append(objs, cluster)
+			}
+			r := &ApplicationReconciler{
+				Client: newFakeClient(t, append(objs, namespaceWithBinding("team-a", tc.binding))...),
+			}
+			application := &coreoamv1beta1.Application{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "app"}}
+			application.Spec.Cluster = tc.spec
+
+			cluster, err := r.resolveCluster(context.Background(), application)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got cluster %s/%s", cluster.GetNamespace(), cluster.GetName())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestClusterChanged(t *testing.T) {
+	deployed := &corev1.ObjectReference{Namespace: "team-a", Name: "blue"}
+
+	cases := map[string]struct {
+		recorded *corev1.ObjectReference
+		spec     *corev1.ObjectReference
+		binding  string
+		want     bool
+	}{
+		"NeverDeployed": {
+			binding: "team-a/green",
+		},
+		"Unchanged": {
+			recorded: deployed,
+			binding:  "team-a/blue",
+		},
+		"NamespaceBindingChanged": {
+			recorded: deployed,
+			binding:  "team-a/green",
+			want:     true,
+		},
+		"SpecChanged": {
+			recorded: deployed,
+			spec:     &corev1.ObjectReference{Name: "green"},
+			binding:  "team-a/blue",
+			want:     true,
+		},
+		"DefaultChanged": {
+			// the operator default cluster is green
+			recorded: deployed,
+			want:     true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := &ApplicationReconciler{
+				Client: newFakeClient(t,
+					&k8sv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "blue"}},
+					&k8sv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "green"}},
+					namespaceWithBinding("team-a", tc.binding),
+				),
+				DefaultCluster: types.NamespacedName{Namespace: "team-a", Name: "green"},
+			}
+			application := &coreoamv1beta1.Application{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "app"}}
+			application.Spec.Cluster = tc.spec
+			application.Status.Cluster = tc.recorded
+
+			cluster, err := r.resolveCluster(context.Background(), application)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := clusterChanged(application, cluster); got != tc.want {
+				t.Errorf("clusterChanged = %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
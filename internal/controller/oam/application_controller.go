@@ -22,12 +22,13 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/nats-io/nats.go"
-	"github.com/nats-io/nkeys"
+	"go.wasmcloud.dev/operator/api/condition"
 	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	"go.wasmcloud.dev/operator/internal/natsconn"
+	"go.wasmcloud.dev/operator/internal/wadmapi"
 	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -49,11 +50,20 @@ type ApplicationReconciler struct {
 	// Will use the object namespace if blank.
 	// wasmcloud clusters usually operate on a single 'default' lattice.
 	Lattice string
+	// Cluster used by Applications without a spec or namespace binding.
+	// Applications stay unresolved if blank.
+	DefaultCluster types.NamespacedName
+	// Shared NATS connections, one per Cluster.
+	Connections *natsconn.Manager
 }
 
 // +kubebuilder:rbac:groups=core.oam.dev,resources=applications,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core.oam.dev,resources=applications/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=core.oam.dev,resources=applications/finalizers,verbs=update
+// +kubebuilder:rbac:groups=k8s.wasmcloud.dev,resources=clusters,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+
 func (r *ApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 
@@ -66,7 +76,12 @@ func (r *ApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		// deletion timestamp is set.
 		// cleanup resources if we have a finalizer.
 		if controllerutil.ContainsFinalizer(&application, finalizer) {
-			if err := r.finalize(ctx, &application); err != nil {
+			cluster, err := r.deployedCluster(ctx, &application)
+			if err != nil {
+				logger.Error(err, "unable to resolve cluster")
+				return ctrl.Result{}, err
+			}
+			if err := r.finalize(ctx, cluster, &application); err != nil {
 				logger.Error(err, "unable to finalize")
 				return ctrl.Result{}, err
 			}
@@ -80,19 +95,32 @@ func (r *ApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, nil
 	}
 
-	if err := r.reconcileSpec(ctx, &application); err != nil {
+	cluster, err := r.resolveCluster(ctx, &application)
+	if err != nil {
+		logger.Info("unable to resolve cluster", "error", err.Error())
+		application.Status.SetConditions(condition.ErrorCondition(conditionClusterResolved, err))
+		if err := r.Status().Update(ctx, &application); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: refreshInterval}, nil
+	}
+	application.Status.SetConditions(condition.ReadyCondition(conditionClusterResolved))
+
+	if err := r.reconcileSpec(ctx, cluster, &application); err != nil {
 		return ctrl.Result{}, err
 	}
 
-	if err := r.reconcileStatus(ctx, &application); err != nil {
+	if err := r.reconcileStatus(ctx, cluster, &application); err != nil {
 		return ctrl.Result{}, err
 	}
 
 	return ctrl.Result{RequeueAfter: refreshInterval}, nil
 }
 
-func (r *ApplicationReconciler) reconcileSpec(ctx context.Context, application *coreoamv1beta1.Application) error {
-	if application.Status.ObservedGeneration == application.Generation {
+func (r *ApplicationReconciler) reconcileSpec(ctx context.Context, cluster *k8sv1alpha1.Cluster, application *coreoamv1beta1.Application) error {
+	// a new binding redeploys the same generation, e.g. after the namespace annotation changed
+	moved := clusterChanged(application, cluster)
+	if application.Status.ObservedGeneration == application.Generation && !moved {
 		return nil
 	}
 
@@ -104,7 +132,10 @@ func (r *ApplicationReconciler) reconcileSpec(ctx context.Context, application *
 		}
 	}
 
-	rawSpec, err := json.Marshal(application)
+	// the cluster binding is an operator concern, wadm doesn't know about it
+	manifest := application.DeepCopy()
+	manifest.Spec.Cluster = nil
+	rawSpec, err := json.Marshal(manifest)
 	if err != nil {
 		return err
 	}
@@ -114,16 +145,23 @@ func (r *ApplicationReconciler) reconcileSpec(ctx context.Context, application *
 		return err
 	}
 
-	nc, err := NatsForCluster(
-		ctx,
-		r.Client,
-		&k8sv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"}})
+	if moved {
+		// take the application off the Cluster it ran on before deploying it to the new one
+		previous, err := r.deployedCluster(ctx, application)
+		if err != nil {
+			return err
+		}
+		if err := r.finalize(ctx, previous, application); err != nil {
+			return fmt.Errorf("cleanup on cluster %s/%s: %w", application.Status.Cluster.Namespace, application.Status.Cluster.Name, err)
+		}
+	}
+
+	bus, err := r.Connections.Bus(ctx, client.ObjectKeyFromObject(cluster))
 	if err != nil {
 		return err
 	}
-	defer nc.Close()
 
-	c := r.wadmClient(wasmbus.NewNatsBus(nc), application)
+	c := r.wadmClient(bus, cluster, application)
 	putResp, err := c.ModelPut(ctx, &wadm.ModelPutRequest{
 		Manifest: *wadmManifest,
 	})
@@ -146,21 +184,18 @@ func (r *ApplicationReconciler) reconcileSpec(ctx context.Context, application *
 
 	application.Status.ObservedGeneration = application.Generation
 	application.Status.ObservedVersion = deployResp.Version
+	application.Status.Cluster = &corev1.ObjectReference{Namespace: cluster.GetNamespace(), Name: cluster.GetName()}
 
 	return r.Status().Update(ctx, application)
 }
 
-func (r *ApplicationReconciler) reconcileStatus(ctx context.Context, application *coreoamv1beta1.Application) error {
-	nc, err := NatsForCluster(
-		ctx,
-		r.Client,
-		&k8sv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"}})
+func (r *ApplicationReconciler) reconcileStatus(ctx context.Context, cluster *k8sv1alpha1.Cluster, application *coreoamv1beta1.Application) error {
+	bus, err := r.Connections.Bus(ctx, client.ObjectKeyFromObject(cluster))
 	if err != nil {
 		return err
 	}
-	defer nc.Close()
 
-	c := r.wadmClient(wasmbus.NewNatsBus(nc), application)
+	c := r.wadmClient(bus, cluster, application)
 	req := &wadm.ModelStatusRequest{
 		Name: application.Name,
 	}
@@ -189,21 +224,27 @@ func (r *ApplicationReconciler) reconcileStatus(ctx context.Context, application
 	return r.Status().Update(ctx, application)
 }
 
-func (r *ApplicationReconciler) finalize(ctx context.Context, application *coreoamv1beta1.Application) error {
+func (r *ApplicationReconciler) finalize(ctx context.Context, cluster *k8sv1alpha1.Cluster, application *coreoamv1beta1.Application) error {
 	if application.Status.ObservedVersion == "" {
 		// never deployed, nothing to do
 		return nil
 	}
-	nc, err := NatsForCluster(
-		ctx,
-		r.Client,
-		&k8sv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"}})
+	if cluster == nil {
+		// cluster is gone, and so is wadm
+		return nil
+	}
+	bus, err := r.Connections.Bus(ctx, client.ObjectKeyFromObject(cluster))
 	if err != nil {
 		return err
 	}
-	defer nc.Close()
 
-	c := r.wadmClient(wasmbus.NewNatsBus(nc), application)
+	c := r.wadmClient(bus, cluster, application)
+
+	if _, err := c.ModelUndeploy(ctx, &wadm.ModelUndeployRequest{
+		Name: application.Name,
+	}); err != nil {
+		return err
+	}
 
 	_, err = c.ModelDelete(ctx, &wadm.ModelDeleteRequest{
 		Name: application.Name,
@@ -212,7 +253,11 @@ func (r *ApplicationReconciler) finalize(ctx context.Context, application *coreo
 	return err
 }
 
-func (r *ApplicationReconciler) lattice(application *coreoamv1beta1.Application) string {
+func (r *ApplicationReconciler) lattice(cluster *k8sv1alpha1.Cluster, application *coreoamv1beta1.Application) string {
+	if external := cluster.Spec.Wadm.External; external != nil && external.Lattice != "" {
+		return external.Lattice
+	}
+
 	lattice := r.Lattice
 	if lattice == "" {
 		lattice = application.GetNamespace()
@@ -220,8 +265,8 @@ func (r *ApplicationReconciler) lattice(application *coreoamv1beta1.Application)
 	return lattice
 }
 
-func (r *ApplicationReconciler) wadmClient(bus wasmbus.Bus, application *coreoamv1beta1.Application) *wadm.Client {
-	return wadm.NewClient(bus, r.lattice(application))
+func (r *ApplicationReconciler) wadmClient(bus wasmbus.Bus, cluster *k8sv1alpha1.Cluster, application *coreoamv1beta1.Application) *wadm.Client {
+	return wadmapi.NewClient(bus, r.lattice(cluster, application), cluster.WadmAPIPrefix())
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -236,37 +281,3 @@ func wadmStatusToPhase(status wadm.StatusType) coreoamv1beta1.ApplicationPhase {
 	// TODO(lxf): keeping this so we can translate the status from wadm to oam
 	return coreoamv1beta1.ApplicationPhase(status)
 }
-
-func NatsForCluster(ctx context.Context, apiClient client.Client, cluster *k8sv1alpha1.Cluster) (*nats.Conn, error) {
-	var creds corev1.Secret
-	if err := apiClient.Get(
-		ctx,
-		client.ObjectKey{Namespace: cluster.GetNamespace(), Name: "nats-" + cluster.GetName()},
-		&creds); err != nil {
-		return nil, err
-	}
-	rawCreds, ok := creds.Data["user.jwt"]
-	if !ok {
-		return nil, fmt.Errorf("missing user jwt")
-	}
-
-	jwt, err := nkeys.ParseDecoratedJWT(rawCreds)
-	if err != nil {
-		return nil, err
-	}
-	key, err := nkeys.ParseDecoratedNKey(rawCreds)
-	if err != nil {
-		return nil, err
-	}
-	seed, err := key.Seed()
-	if err != nil {
-		return nil, err
-	}
-
-	options := []nats.Option{
-		nats.UserJWTAndSeed(jwt, string(seed)),
-	}
-
-	return nats.Connect("nats://localhost:4223", options...)
-	//return nats.Connect("nats://nats-"+cluster.GetName()+"."+cluster.GetNamespace()+":4222", options...)
-}
@@ -21,13 +21,19 @@ import (
 	"encoding/json"
 	"time"
 
+	"github.com/nats-io/jwt/v2"
+	"github.com/nats-io/nkeys"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
 	coreoamv1beta1 "go.wasmcloud.dev/operator/api/oam/core/v1beta1"
+	"go.wasmcloud.dev/operator/internal/natsconn"
 	"go.wasmcloud.dev/x/wasmbus/wadm"
 )
 
@@ -35,9 +41,45 @@ var _ = Describe("Application Controller", func() {
 	Context("When reconciling a resource", func() {
 
 		ctx := context.Background()
+		clusterKey := types.NamespacedName{Name: "wasmcloud", Namespace: "default"}
 
 		BeforeEach(func() {
+			By("creating a Cluster pointing at the wash NATS server and wadm")
+			cluster := &k8sv1alpha1.Cluster{}
+			err := k8sClient.Get(ctx, clusterKey, cluster)
+			if err != nil && errors.IsNotFound(err) {
+				cluster = &k8sv1alpha1.Cluster{
+					ObjectMeta: metav1.ObjectMeta{Name: clusterKey.Name, Namespace: clusterKey.Namespace},
+					Spec: k8sv1alpha1.ClusterSpec{
+						Nats: k8sv1alpha1.NatsSpec{
+							External: &k8sv1alpha1.NatsExternalSpec{
+								URLs: []string{natsURL},
+								CredentialsSecret: corev1.SecretKeySelector{
+									LocalObjectReference: corev1.LocalObjectReference{Name: "wash-creds"},
+									Key:                  "user.creds",
+								},
+							},
+						},
+						Wadm: k8sv1alpha1.WadmSpec{
+							External: &k8sv1alpha1.WadmExternalSpec{Lattice: "default", APIPrefix: "wadm.api"},
+						},
+					},
+				}
+				Expect(k8sClient.Create(ctx, cluster)).To(Succeed())
+			}
 
+			By("creating the Cluster client credentials")
+			secret := &corev1.Secret{}
+			secretKey := types.NamespacedName{Name: cluster.NatsClientSecret(), Namespace: clusterKey.Namespace}
+			err = k8sClient.Get(ctx, secretKey, secret)
+			if err != nil && errors.IsNotFound(err) {
+				secret = &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: secretKey.Name, Namespace: secretKey.Namespace},
+					// wash runs NATS without auth, any valid creds do
+					Data: map[string][]byte{"user.jwt": newUserCreds()},
+				}
+				Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+			}
 		})
 
 		AfterEach(func() {
@@ -68,10 +110,11 @@ var _ = Describe("Application Controller", func() {
 
 			By("Reconciling the created resource")
 			controllerReconciler := &ApplicationReconciler{
-				Client: k8sClient,
-				Scheme: k8sClient.Scheme(),
-				//				Bus:     bus,
-				Lattice: "default",
+				Client:         k8sClient,
+				Scheme:         k8sClient.Scheme(),
+				Connections:    natsconn.NewManager(k8sClient),
+				Lattice:        "default",
+				DefaultCluster: clusterKey,
 			}
 
 			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
@@ -83,6 +126,8 @@ var _ = Describe("Application Controller", func() {
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(resource.Status.ObservedVersion).To(Not(Equal("")))
+			Expect(resource.Status.Cluster).NotTo(BeNil())
+			Expect(resource.Status.Cluster.Name).To(Equal(clusterKey.Name))
 			Expect(resource.Status.ObservedGeneration).To(Equal(resource.Generation))
 			Expect(resource.Status.Phase).NotTo(Equal(coreoamv1beta1.ApplicationPhase("")))
 
@@ -90,6 +135,81 @@ var _ = Describe("Application Controller", func() {
 			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
 		})
 
+		It("should move the resource when its cluster binding changes", func() {
+			const resourceName = "test-rebind"
+			typeNamespacedName := types.NamespacedName{
+				Name:      resourceName,
+				Namespace: "default",
+			}
+			movedKey := types.NamespacedName{Name: "wasmcloud-moved", Namespace: "default"}
+
+			By("creating a second Cluster on the same NATS server and wadm")
+			cluster := &k8sv1alpha1.Cluster{}
+			Expect(k8sClient.Get(ctx, clusterKey, cluster)).To(Succeed())
+			moved := &k8sv1alpha1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: movedKey.Name, Namespace: movedKey.Namespace},
+				Spec:       cluster.Spec,
+			}
+			Expect(k8sClient.Create(ctx, moved)).To(Succeed())
+			Expect(k8sClient.Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: moved.NatsClientSecret(), Namespace: movedKey.Namespace},
+				Data:       map[string][]byte{"user.jwt": newUserCreds()},
+			})).To(Succeed())
+
+			By("creating the custom resource for the Kind Application")
+			resource := &coreoamv1beta1.Application{}
+			wadmManifest, err := wadm.LoadManifest("testdata/application.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			rawManifest, err := wadmManifest.ToJSON()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(json.Unmarshal(rawManifest, resource)).To(Succeed())
+			resource.Name = resourceName
+			resource.Namespace = "default"
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+
+			By("deploying it to the default Cluster")
+			controllerReconciler := &ApplicationReconciler{
+				Client:         k8sClient,
+				Scheme:         k8sClient.Scheme(),
+				Connections:    natsconn.NewManager(k8sClient),
+				Lattice:        "default",
+				DefaultCluster: clusterKey,
+			}
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Cluster.Name).To(Equal(clusterKey.Name))
+			generation := resource.Generation
+
+			By("binding the namespace to the second Cluster")
+			namespace := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "default"}, namespace)).To(Succeed())
+			namespace.Annotations = map[string]string{coreoamv1beta1.ClusterAnnotation: movedKey.Name}
+			Expect(k8sClient.Update(ctx, namespace)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Generation).To(Equal(generation))
+			Expect(resource.Status.Cluster.Name).To(Equal(movedKey.Name))
+			Expect(resource.Status.ObservedVersion).NotTo(Equal(""))
+
+			By("binding it back through its spec")
+			resource.Spec.Cluster = &corev1.ObjectReference{Name: clusterKey.Name}
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Cluster.Name).To(Equal(clusterKey.Name))
+
+			By("Deleting resources")
+			delete(namespace.Annotations, coreoamv1beta1.ClusterAnnotation)
+			Expect(k8sClient.Update(ctx, namespace)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, moved)).To(Succeed())
+		})
+
 		It("should update the resource status", func() {
 			const resourceName = "test-update"
 			typeNamespacedName := types.NamespacedName{
@@ -115,10 +235,11 @@ var _ = Describe("Application Controller", func() {
 
 			By("Reconciling the created resource until it is ready")
 			controllerReconciler := &ApplicationReconciler{
-				Client: k8sClient,
-				Scheme: k8sClient.Scheme(),
-				//	Bus:     bus,
-				Lattice: "default",
+				Client:         k8sClient,
+				Scheme:         k8sClient.Scheme(),
+				Connections:    natsconn.NewManager(k8sClient),
+				Lattice:        "default",
+				DefaultCluster: clusterKey,
 			}
 
 			attempts := 15
@@ -146,3 +267,22 @@ var _ = Describe("Application Controller", func() {
 		})
 	})
 })
+
+// newUserCreds returns decorated NATS user credentials signed by a throwaway account.
+func newUserCreds() []byte {
+	accountKp, err := nkeys.CreateAccount()
+	Expect(err).NotTo(HaveOccurred())
+	userKp, err := nkeys.CreateUser()
+	Expect(err).NotTo(HaveOccurred())
+	userPub, err := userKp.PublicKey()
+	Expect(err).NotTo(HaveOccurred())
+	userSeed, err := userKp.Seed()
+	Expect(err).NotTo(HaveOccurred())
+
+	userJWT, err := jwt.NewUserClaims(userPub).Encode(accountKp)
+	Expect(err).NotTo(HaveOccurred())
+	creds, err := jwt.FormatUserConfig(userJWT, userSeed)
+	Expect(err).NotTo(HaveOccurred())
+
+	return creds
+}
@@ -0,0 +1,64 @@
+package oam
+
+import (
+	"testing"
+
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	coreoamv1beta1 "go.wasmcloud.dev/operator/api/oam/core/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWadmTarget(t *testing.T) {
+	cases := map[string]struct {
+		// lattice the operator was started with
+		lattice  string
+		external *k8sv1alpha1.WadmExternalSpec
+
+		wantLattice string
+		wantPrefix  string
+	}{
+		"Managed": {
+			wantLattice: "team-a",
+			wantPrefix:  "wadm.api",
+		},
+		"ManagedOperatorLattice": {
+			lattice:     "default",
+			wantLattice: "default",
+			wantPrefix:  "wadm.api",
+		},
+		"External": {
+			lattice:     "default",
+			external:    &k8sv1alpha1.WadmExternalSpec{Lattice: "prod", APIPrefix: "prod.wadm"},
+			wantLattice: "prod",
+			wantPrefix:  "prod.wadm",
+		},
+		"ExternalDefaults": {
+			// defaulted by the API server, unless the Cluster predates the fields
+			lattice:     "default",
+			external:    &k8sv1alpha1.WadmExternalSpec{},
+			wantLattice: "default",
+			wantPrefix:  "wadm.api",
+		},
+		"ExternalPrefixOnly": {
+			external:    &k8sv1alpha1.WadmExternalSpec{APIPrefix: "prod.wadm"},
+			wantLattice: "team-a",
+			wantPrefix:  "prod.wadm",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := &ApplicationReconciler{Lattice: tc.lattice}
+			cluster := &k8sv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "wasmcloud"}}
+			cluster.Spec.Wadm.External = tc.external
+			application := &coreoamv1beta1.Application{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "app"}}
+
+			if got := r.lattice(cluster, application); got != tc.wantLattice {
+				t.Errorf("lattice = %q, want %q", got, tc.wantLattice)
+			}
+			if got := cluster.WadmAPIPrefix(); got != tc.wantPrefix {
+				t.Errorf("WadmAPIPrefix = %q, want %q", got, tc.wantPrefix)
+			}
+		})
+	}
+}
@@ -33,6 +33,7 @@ import (
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
 	coreoamv1beta1 "go.wasmcloud.dev/operator/api/oam/core/v1beta1"
 	"go.wasmcloud.dev/x/wasmbus"
 	"go.wasmcloud.dev/x/wasmbus/wasmbustest"
@@ -49,6 +50,8 @@ var (
 	ctx       context.Context
 	cancel    context.CancelFunc
 	bus       wasmbus.Bus
+	// URL of the wash NATS server, which the test Cluster points at
+	natsURL string
 )
 
 func TestControllers(t *testing.T) {
@@ -84,10 +87,13 @@ var _ = BeforeSuite(func() {
 
 	err = coreoamv1beta1.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
+	err = k8sv1alpha1.AddToScheme(scheme.Scheme)
+	Expect(err).NotTo(HaveOccurred())
 
 	By("setting up wash")
 	nc, washOff := wasmbustest.WithWash(GinkgoT())
 	bus = wasmbus.NewNatsBus(nc)
+	natsURL = nc.ConnectedUrl()
 
 	DeferCleanup(func() {
 		washOff(GinkgoT())
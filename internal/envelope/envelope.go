@@ -0,0 +1,131 @@
+// Package envelope encrypts small secrets, such as nkey seeds, before they are stored.
+// Each secret is encrypted with its own data key, and the data key is wrapped by a KeyEncrypter
+// holding the key encryption key, either locally or in a KMS.
+package envelope
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// sealed values start with this prefix, so plaintext values can still be read while migrating
+const sealedPrefix = "sealed:v1:"
+
+const dataKeySize = 32
+
+// KeyEncrypter wraps and unwraps data keys with a key encryption key.
+type KeyEncrypter interface {
+	// KeyID identifies the key encryption key. It is recorded in every envelope.
+	KeyID() string
+	Wrap(ctx context.Context, dataKey []byte) ([]byte, error)
+	Unwrap(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+type envelope struct {
+	KeyID string `json:"kid"`
+	Key   []byte `json:"key"`
+	Data  []byte `json:"data"`
+}
+
+// IsSealed reports whether value was produced by Seal.
+func IsSealed(value []byte) bool {
+	return strings.HasPrefix(string(value), sealedPrefix)
+}
+
+// Seal encrypts plaintext with a new data key wrapped by kek.
+func Seal(ctx context.Context, kek KeyEncrypter, plaintext []byte) ([]byte, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+
+	data, err := encrypt(dataKey, plaintext, []byte(kek.KeyID()))
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped, err := kek.Wrap(ctx, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("wrapping data key: %w", err)
+	}
+
+	raw, err := json.Marshal(envelope{KeyID: kek.KeyID(), Key: wrapped, Data: data})
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(sealedPrefix + base64.StdEncoding.EncodeToString(raw)), nil
+}
+
+// Open decrypts a value sealed with kek.
+func Open(ctx context.Context, kek KeyEncrypter, sealed []byte) ([]byte, error) {
+	encoded, ok := strings.CutPrefix(string(sealed), sealedPrefix)
+	if !ok {
+		return nil, errors.New("not a sealed value")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return nil, err
+	}
+
+	if env.KeyID != kek.KeyID() {
+		return nil, fmt.Errorf("sealed with key %q, not %q", env.KeyID, kek.KeyID())
+	}
+
+	dataKey, err := kek.Unwrap(ctx, env.Key)
+	if err != nil {
+		return nil, fmt.Errorf("unwrapping data key: %w", err)
+	}
+
+	return decrypt(dataKey, env.Data, []byte(env.KeyID))
+}
+
+// encrypt seals plaintext with AES-GCM under key, returning the nonce followed by the ciphertext.
+func encrypt(key []byte, plaintext []byte, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func decrypt(key []byte, ciphertext []byte, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
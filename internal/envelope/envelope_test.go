@@ -0,0 +1,82 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+// staticKeyEncrypter wraps data keys with a fixed key, standing in for a KMS.
+type staticKeyEncrypter struct {
+	id  string
+	key []byte
+}
+
+func (e staticKeyEncrypter) KeyID() string { return e.id }
+
+func (e staticKeyEncrypter) Wrap(_ context.Context, dataKey []byte) ([]byte, error) {
+	return encrypt(e.key, dataKey, []byte(e.id))
+}
+
+func (e staticKeyEncrypter) Unwrap(_ context.Context, wrapped []byte) ([]byte, error) {
+	return decrypt(e.key, wrapped, []byte(e.id))
+}
+
+func newStaticKeyEncrypter(id string, fill byte) staticKeyEncrypter {
+	return staticKeyEncrypter{id: id, key: bytes.Repeat([]byte{fill}, dataKeySize)}
+}
+
+func TestSealOpen(t *testing.T) {
+	ctx := context.Background()
+	kek := newStaticKeyEncrypter("test", 1)
+	plaintext := []byte("SUAIBDPBAUTWCWBKIO6XHQNINK5FWJW4OHLXC3HQ2KFE4PEJUA44CNHTC4")
+
+	sealed, err := Seal(ctx, kek, plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !IsSealed(sealed) {
+		t.Errorf("IsSealed(sealed) = false")
+	}
+	if IsSealed(plaintext) {
+		t.Errorf("IsSealed(plaintext) = true")
+	}
+	if bytes.Contains(sealed, plaintext) {
+		t.Errorf("sealed value contains the plaintext")
+	}
+
+	again, err := Seal(ctx, kek, plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(sealed, again) {
+		t.Errorf("sealing twice should use different data keys")
+	}
+
+	opened, err := Open(ctx, kek, sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plaintext, opened) {
+		t.Errorf("Open(...) = %q, want %q", opened, plaintext)
+	}
+
+	t.Run("OtherKeyID", func(t *testing.T) {
+		if _, err := Open(ctx, newStaticKeyEncrypter("other", 1), sealed); err == nil {
+			t.Errorf("Open(...) with another key id should fail")
+		}
+	})
+
+	t.Run("OtherKey", func(t *testing.T) {
+		if _, err := Open(ctx, newStaticKeyEncrypter("test", 2), sealed); err == nil {
+			t.Errorf("Open(...) with another key should fail")
+		}
+	})
+
+	t.Run("NotSealed", func(t *testing.T) {
+		if _, err := Open(ctx, kek, plaintext); err == nil {
+			t.Errorf("Open(...) of plaintext should fail")
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package envelope
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SecretKeyName is the Secret entry holding the key encryption key.
+const SecretKeyName = "key"
+
+// SecretKeyEncrypter keeps its key encryption key in a Kubernetes Secret, created on first use.
+// Keep the Secret in a namespace only the operator can read.
+type SecretKeyEncrypter struct {
+	client client.Client
+	secret types.NamespacedName
+
+	lock sync.Mutex
+	key  []byte
+}
+
+var _ KeyEncrypter = &SecretKeyEncrypter{}
+
+func NewSecretKeyEncrypter(apiClient client.Client, secret types.NamespacedName) *SecretKeyEncrypter {
+	return &SecretKeyEncrypter{
+		client: apiClient,
+		secret: secret,
+	}
+}
+
+func (e *SecretKeyEncrypter) KeyID() string {
+	return "secret:" + e.secret.String()
+}
+
+func (e *SecretKeyEncrypter) Wrap(ctx context.Context, dataKey []byte) ([]byte, error) {
+	key, err := e.loadKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return encrypt(key, dataKey, []byte(e.KeyID()))
+}
+
+func (e *SecretKeyEncrypter) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	key, err := e.loadKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return decrypt(key, wrapped, []byte(e.KeyID()))
+}
+
+// loadKey reads the key encryption key, generating the Secret when it doesn't exist yet.
+// The key is kept in memory once loaded.
+func (e *SecretKeyEncrypter) loadKey(ctx context.Context) ([]byte, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.key != nil {
+		return e.key, nil
+	}
+
+	var secret corev1.Secret
+	err := e.client.Get(ctx, e.secret, &secret)
+	if apierrors.IsNotFound(err) {
+		key := make([]byte, dataKeySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+
+		secret = corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: e.secret.Namespace, Name: e.secret.Name},
+			Data:       map[string][]byte{SecretKeyName: key},
+		}
+		err = e.client.Create(ctx, &secret)
+		if apierrors.IsAlreadyExists(err) {
+			// lost a race with another replica, use its key
+			err = e.client.Get(ctx, e.secret, &secret)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := secret.Data[SecretKeyName]
+	if len(key) != dataKeySize {
+		return nil, fmt.Errorf("%s: %q must hold a %d byte key", e.secret, SecretKeyName, dataKeySize)
+	}
+
+	e.key = key
+	return key, nil
+}
@@ -2,25 +2,44 @@ package lattice
 
 import (
 	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
+	"go.wasmcloud.dev/operator/internal/natsconn"
 	"go.wasmcloud.dev/x/wasmbus"
 	"go.wasmcloud.dev/x/wasmbus/events"
+	"k8s.io/apimachinery/pkg/types"
 )
 
+// HostExpiry is how long a host is considered alive after its last event.
+// Hosts send a heartbeat every 30 seconds.
+const HostExpiry = 90 * time.Second
+
 type Component struct {
 	Id string
 }
 
 type Host struct {
 	Id        string
+	Labels    map[string]string
+	Version   string
 	FirstSeen time.Time
 	LastSeen  time.Time
 }
 
+// Cache tracks the hosts of a lattice from the events they publish.
 type Cache struct {
-	Lattice string
-	Bus     wasmbus.Bus
+	Lattice     string
+	Cluster     types.NamespacedName
+	Connections *natsconn.Manager
+
+	lock sync.Mutex
+	// stops the events subscription started by Watch
+	cancel context.CancelFunc
+	hosts  map[string]*Host
 }
 
 func (c *Cache) NeedLeaderElection() bool {
@@ -28,19 +47,144 @@ func (c *Cache) NeedLeaderElection() bool {
 }
 
 func (c *Cache) Start(ctx context.Context) error {
-	evSubscription, err := events.Subscribe(c.Bus, c.Lattice, wasmbus.PatternAll, wasmbus.NoBackLog, c)
-	if err != nil {
+	return c.Connections.Serve(ctx, c.Cluster, c.subscriber)
+}
+
+// Watch follows the lattice events in the background until Stop, unless already following them.
+// Returns an error while the Cluster can't be reached, the subscription catches up once it can.
+func (c *Cache) Watch(ctx context.Context) error {
+	if _, err := c.Connections.Bus(ctx, c.Cluster); err != nil {
 		return err
 	}
 
-	<-ctx.Done()
+	c.lock.Lock()
+	defer c.lock.Unlock()
 
-	if err := evSubscription.Drain(); err != nil {
-		return err
+	if c.cancel != nil {
+		return nil
+	}
+
+	// the subscription outlives the reconcile that started it
+	watchCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	c.cancel = cancel
+	go func() {
+		_ = c.Connections.Serve(watchCtx, c.Cluster, c.subscriber)
+	}()
+
+	return nil
+}
+
+// Stop unsubscribes from the lattice events.
+func (c *Cache) Stop() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.cancel != nil {
+		c.cancel()
+		c.cancel = nil
 	}
+}
+
+// subscriber subscribes the cache to the lattice events on bus, again for every new connection.
+func (c *Cache) subscriber(bus wasmbus.Bus) natsconn.Server {
+	return &eventSubscriber{bus: bus, cache: c}
+}
+
+type eventSubscriber struct {
+	bus   wasmbus.Bus
+	cache *Cache
+	sub   wasmbus.Subscription
+}
 
+func (s *eventSubscriber) Serve() error {
+	sub, err := events.Subscribe(s.bus, s.cache.Lattice, wasmbus.PatternAll, wasmbus.NoBackLog, s.cache)
+	if err != nil {
+		return err
+	}
+	s.sub = sub
 	return nil
 }
 
-func (c *Cache) HandleEvent(context.Context, events.Event)            {}
+func (s *eventSubscriber) Drain() error {
+	if s.sub == nil {
+		return nil
+	}
+	return s.sub.Drain()
+}
+
+func (c *Cache) HandleEvent(_ context.Context, ev events.Event) {
+	c.observe(ev, time.Now())
+}
+
 func (c *Cache) HandleError(context.Context, *wasmbus.Message, error) {}
+
+// observe records a host event received at now. Other events are ignored.
+func (c *Cache) observe(ev events.Event, now time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.hosts == nil {
+		c.hosts = make(map[string]*Host)
+	}
+
+	switch data := ev.Data.(type) {
+	case events.HostStopped:
+		delete(c.hosts, hostID(ev, data.HostId))
+	case events.HostStarted:
+		c.seen(hostID(ev, data.HostId), data.Labels, "", now)
+	case events.HostHeartbeat:
+		c.seen(hostID(ev, data.HostId), data.Labels, data.Version, now)
+	}
+}
+
+// seen refreshes a host. The caller holds c.lock.
+func (c *Cache) seen(id string, labels map[string]string, version string, now time.Time) {
+	if id == "" {
+		return
+	}
+
+	host, ok := c.hosts[id]
+	if !ok {
+		host = &Host{Id: id, FirstSeen: now}
+		c.hosts[id] = host
+	}
+	host.LastSeen = now
+	if labels != nil {
+		host.Labels = labels
+	}
+	if version != "" {
+		host.Version = version
+	}
+}
+
+// hostID returns the ID carried by the event data, hosts also publish events with their ID as source.
+func hostID(ev events.Event, id string) string {
+	if id != "" {
+		return id
+	}
+	return ev.Source
+}
+
+// Hosts returns the hosts heard from within HostExpiry of now, sorted by ID.
+// Expired hosts are forgotten.
+func (c *Cache) Hosts(now time.Time) []Host {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	hosts := make([]Host, 0, len(c.hosts))
+	for id, host := range c.hosts {
+		if now.Sub(host.LastSeen) > HostExpiry {
+			delete(c.hosts, id)
+			continue
+		}
+		h := *host
+		h.Labels = maps.Clone(host.Labels)
+		hosts = append(hosts, h)
+	}
+
+	slices.SortFunc(hosts, func(a, b Host) int {
+		return strings.Compare(a.Id, b.Id)
+	})
+
+	return hosts
+}
@@ -0,0 +1,56 @@
+package lattice
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"go.wasmcloud.dev/x/wasmbus/events"
+)
+
+func TestCacheObserve(t *testing.T) {
+	start := time.Now()
+	cache := &Cache{Lattice: "default"}
+
+	cache.observe(events.Event{
+		Source: "NHOSTA",
+		Data:   events.HostHeartbeat{Labels: map[string]string{"site": "warehouse-1"}, Version: "1.4.0"},
+	}, start)
+
+	cache.observe(events.Event{
+		Source: "NHOSTC",
+		Data:   events.HostStarted{HostId: "NHOSTB", Labels: map[string]string{"site": "warehouse-2"}},
+	}, start)
+
+	// other events don't tell whether a host is alive
+	cache.observe(events.Event{Source: "NHOSTD", Data: struct{}{}}, start)
+
+	later := start.Add(time.Minute)
+	cache.observe(events.Event{
+		Source: "NHOSTA",
+		Data:   events.HostHeartbeat{Labels: map[string]string{"site": "warehouse-1"}, Version: "1.4.0"},
+	}, later)
+
+	want := []Host{
+		{Id: "NHOSTA", Labels: map[string]string{"site": "warehouse-1"}, Version: "1.4.0", FirstSeen: start, LastSeen: later},
+		{Id: "NHOSTB", Labels: map[string]string{"site": "warehouse-2"}, FirstSeen: start, LastSeen: start},
+	}
+	if diff := cmp.Diff(want, cache.Hosts(later)); diff != "" {
+		t.Errorf("hosts: -want, +got:\n%s", diff)
+	}
+
+	t.Run("Expiry", func(t *testing.T) {
+		want := want[:1]
+		if diff := cmp.Diff(want, cache.Hosts(start.Add(HostExpiry+time.Second))); diff != "" {
+			t.Errorf("hosts: -want, +got:\n%s", diff)
+		}
+	})
+
+	t.Run("Stopped", func(t *testing.T) {
+		cache.observe(events.Event{Source: "NHOSTA", Data: events.HostStopped{Reason: "shutdown"}}, later)
+
+		if hosts := cache.Hosts(later); len(hosts) != 0 {
+			t.Errorf("stopped hosts should be forgotten, got %v", hosts)
+		}
+	})
+}
@@ -0,0 +1,394 @@
+package natsconn
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nkeys"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	"go.wasmcloud.dev/x/wasmbus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Manager keeps one long-lived NATS connection per Cluster.
+// Connections reconnect on their own and pick up rotated credentials
+// the next time they are requested, or at the latest on the next refresh.
+type Manager struct {
+	client client.Client
+	// added to every connection
+	opts []nats.Option
+
+	lock  sync.Mutex
+	conns map[connectionKey]*connection
+}
+
+// Serve retries connecting this often at first, backing off up to refreshInterval
+const serveRetryInterval = time.Second
+
+// open connections re-read their credentials this often, so renewed user JWTs are in use
+// well before the ones the connections authenticated with expire
+const refreshInterval = time.Minute
+
+type connectionKey struct {
+	cluster types.NamespacedName
+	user    connectionUser
+}
+
+// connectionUser selects the Cluster user a connection authenticates as.
+type connectionUser int
+
+const (
+	clientUser connectionUser = iota
+	// the system account user
+	systemUser
+	// the user answering auth callout requests
+	authUser
+)
+
+func (u connectionUser) String() string {
+	switch u {
+	case systemUser:
+		return "system"
+	case authUser:
+		return "auth"
+	default:
+		return "client"
+	}
+}
+
+type connection struct {
+	lock  sync.RWMutex
+	creds []byte
+	jwt   string
+	kp    nkeys.KeyPair
+	ca    []byte
+
+	conn *nats.Conn
+	bus  wasmbus.Bus
+	// closed once the manager drops the connection
+	done chan struct{}
+}
+
+// Server is served over a Cluster connection, see Manager.Serve.
+type Server interface {
+	Serve() error
+	Drain() error
+}
+
+// NewManager returns a Manager reading Clusters and their credentials through apiClient.
+// opts are added to every connection, such as a custom dialer.
+func NewManager(apiClient client.Client, opts ...nats.Option) *Manager {
+	return &Manager{
+		client: apiClient,
+		opts:   opts,
+		conns:  make(map[connectionKey]*connection),
+	}
+}
+
+// Bus returns the wasmbus for the given Cluster, connecting if needed.
+func (m *Manager) Bus(ctx context.Context, key types.NamespacedName) (wasmbus.Bus, error) {
+	c, err := m.connection(ctx, connectionKey{cluster: key})
+	if err != nil {
+		return nil, err
+	}
+	return c.bus, nil
+}
+
+// Conn returns the raw NATS connection for the given Cluster, connecting if needed.
+func (m *Manager) Conn(ctx context.Context, key types.NamespacedName) (*nats.Conn, error) {
+	c, err := m.connection(ctx, connectionKey{cluster: key})
+	if err != nil {
+		return nil, err
+	}
+	return c.conn, nil
+}
+
+// SystemConn returns a NATS connection bound to the Cluster system account,
+// used for server level requests such as reloads and JetStream peer management.
+func (m *Manager) SystemConn(ctx context.Context, key types.NamespacedName) (*nats.Conn, error) {
+	c, err := m.connection(ctx, connectionKey{cluster: key, user: systemUser})
+	if err != nil {
+		return nil, err
+	}
+	return c.conn, nil
+}
+
+// AuthConn returns a NATS connection as the Cluster auth callout user,
+// used to answer authorization requests from the servers.
+func (m *Manager) AuthConn(ctx context.Context, key types.NamespacedName) (*nats.Conn, error) {
+	c, err := m.connection(ctx, connectionKey{cluster: key, user: authUser})
+	if err != nil {
+		return nil, err
+	}
+	return c.conn, nil
+}
+
+// Serve runs the server built by newServer over the Cluster client connection until ctx is done.
+// The Cluster may not be reachable yet, so connecting is retried with backoff, and the server is
+// built again whenever the connection is replaced.
+func (m *Manager) Serve(ctx context.Context, key types.NamespacedName, newServer func(wasmbus.Bus) Server) error {
+	logger := log.FromContext(ctx).WithValues("cluster", key)
+
+	retry := serveRetryInterval
+	for {
+		c, err := m.connection(ctx, connectionKey{cluster: key})
+		if err == nil {
+			server := newServer(c.bus)
+			if err = server.Serve(); err == nil {
+				retry = serveRetryInterval
+				select {
+				case <-c.done:
+					logger.Info("nats connection replaced, serving again")
+				case <-ctx.Done():
+				}
+				if err := server.Drain(); err != nil && ctx.Err() == nil {
+					logger.Error(err, "draining server")
+				}
+				if ctx.Err() != nil {
+					return nil
+				}
+				continue
+			}
+		}
+
+		logger.Error(err, "serving over nats, retrying", "after", retry)
+		select {
+		case <-time.After(retry):
+		case <-ctx.Done():
+			return nil
+		}
+		retry = min(retry*2, refreshInterval)
+	}
+}
+
+// Remove closes and forgets the connections for the given Cluster.
+func (m *Manager) Remove(key types.NamespacedName) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, user := range []connectionUser{clientUser, systemUser, authUser} {
+		connKey := connectionKey{cluster: key, user: user}
+		if c, ok := m.conns[connKey]; ok {
+			c.conn.Close()
+			m.drop(connKey, c)
+		}
+	}
+}
+
+// drop forgets a connection and notifies its servers. The caller holds m.lock.
+func (m *Manager) drop(key connectionKey, c *connection) {
+	delete(m.conns, key)
+	close(c.done)
+}
+
+func (m *Manager) NeedLeaderElection() bool {
+	return false
+}
+
+func (m *Manager) Start(ctx context.Context) error {
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+
+	for done := false; !done; {
+		select {
+		case <-ticker.C:
+			m.refresh(ctx)
+		case <-ctx.Done():
+			done = true
+		}
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for key, c := range m.conns {
+		if err := c.conn.Drain(); err != nil {
+			c.conn.Close()
+		}
+		m.drop(key, c)
+	}
+
+	return nil
+}
+
+// refresh re-reads the credentials of every open connection.
+// Connections of Clusters that no longer exist are closed.
+func (m *Manager) refresh(ctx context.Context) {
+	logger := log.FromContext(ctx)
+
+	m.lock.Lock()
+	keys := make([]connectionKey, 0, len(m.conns))
+	for key := range m.conns {
+		keys = append(keys, key)
+	}
+	m.lock.Unlock()
+
+	for _, key := range keys {
+		var cluster k8sv1alpha1.Cluster
+		if err := m.client.Get(ctx, key.cluster, &cluster); apierrors.IsNotFound(err) {
+			logger.Info("cluster is gone, closing its nats connections", "cluster", key.cluster)
+			m.Remove(key.cluster)
+			continue
+		}
+
+		if _, err := m.connection(ctx, key); err != nil {
+			logger.Error(err, "refreshing nats credentials", "cluster", key.cluster, "user", key.user)
+		}
+	}
+}
+
+func (m *Manager) connection(ctx context.Context, key connectionKey) (*connection, error) {
+	var cluster k8sv1alpha1.Cluster
+	if err := m.client.Get(ctx, key.cluster, &cluster); err != nil {
+		return nil, err
+	}
+
+	secretName := cluster.NatsClientSecret()
+	switch key.user {
+	case systemUser:
+		secretName = cluster.NatsSystemSecret()
+	case authUser:
+		secretName = cluster.NatsAuthSecret()
+	}
+
+	var secret corev1.Secret
+	if err := m.client.Get(
+		ctx,
+		client.ObjectKey{Namespace: cluster.GetNamespace(), Name: secretName},
+		&secret); err != nil {
+		return nil, err
+	}
+	creds, ok := secret.Data["user.jwt"]
+	if !ok {
+		return nil, fmt.Errorf("missing user jwt")
+	}
+	ca := secret.Data["ca.crt"]
+
+	if c, ok, err := m.current(ctx, key, creds, ca); ok || err != nil {
+		return c, err
+	}
+
+	c := &connection{ca: ca, done: make(chan struct{})}
+	if err := c.setCredentials(creds); err != nil {
+		return nil, err
+	}
+
+	opts := append([]nats.Option{
+		nats.Name("wasmcloud-operator"),
+		nats.MaxReconnects(-1),
+		nats.UserJWT(c.userJWT, c.sign),
+	}, m.opts...)
+	if len(ca) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("invalid ca bundle")
+		}
+		opts = append(opts, nats.Secure(&tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}))
+	}
+
+	// dial without holding the lock, an unreachable Cluster must not stall the others
+	nc, err := nats.Connect(cluster.NatsURL(), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if existing, ok := m.conns[key]; ok {
+		if !existing.conn.IsClosed() {
+			// connected concurrently, keep the one already handed out
+			nc.Close()
+			return existing, nil
+		}
+		// closed since, its servers must serve the new connection
+		m.drop(key, existing)
+	}
+
+	c.conn = nc
+	c.bus = wasmbus.NewNatsBus(nc)
+	m.conns[key] = c
+
+	return c, nil
+}
+
+// current returns the open connection for key, updated to the given credentials.
+// Connections are dropped when closed or when their CA changed.
+func (m *Manager) current(ctx context.Context, key connectionKey, creds []byte, ca []byte) (*connection, bool, error) {
+	logger := log.FromContext(ctx)
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	c, ok := m.conns[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	if c.conn.IsClosed() {
+		m.drop(key, c)
+		return nil, false, nil
+	}
+
+	if !bytes.Equal(c.ca, ca) {
+		// TLS settings are fixed at connect time, start over
+		logger.Info("nats ca changed, reconnecting", "cluster", key.cluster, "user", key.user)
+		c.conn.Close()
+		m.drop(key, c)
+		return nil, false, nil
+	}
+
+	if bytes.Equal(c.creds, creds) {
+		return c, true, nil
+	}
+
+	logger.Info("nats credentials changed, reconnecting", "cluster", key.cluster, "user", key.user)
+	if err := c.setCredentials(creds); err != nil {
+		return nil, false, err
+	}
+	if err := c.conn.ForceReconnect(); err != nil {
+		return nil, false, err
+	}
+	return c, true, nil
+}
+
+func (c *connection) setCredentials(creds []byte) error {
+	jwt, err := nkeys.ParseDecoratedJWT(creds)
+	if err != nil {
+		return err
+	}
+	kp, err := nkeys.ParseDecoratedNKey(creds)
+	if err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.creds = creds
+	c.jwt = jwt
+	c.kp = kp
+
+	return nil
+}
+
+func (c *connection) userJWT() (string, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.jwt, nil
+}
+
+func (c *connection) sign(nonce []byte) ([]byte, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.kp.Sign(nonce)
+}
@@ -0,0 +1,414 @@
+package natsconn
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nats-io/jwt/v2"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nkeys"
+	k8sv1alpha1 "go.wasmcloud.dev/operator/api/k8s/v1alpha1"
+	"go.wasmcloud.dev/x/wasmbus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// fakeServer speaks enough of the NATS protocol for clients to connect, and reports the JWT
+// of every CONNECT it receives.
+type fakeServer struct {
+	listener net.Listener
+	connects chan string
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	s := &fakeServer{listener: listener, connects: make(chan string, 16)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(`INFO {"server_id":"FAKE","version":"2.10.0","proto":1,"max_payload":1048576,"nonce":"aW52YWxpZA"}` + "\r\n")); err != nil {
+		return
+	}
+
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		op, args, _ := strings.Cut(strings.TrimSpace(line), " ")
+		switch strings.ToUpper(op) {
+		case "CONNECT":
+			var connect struct {
+				JWT string `json:"jwt"`
+			}
+			if err := json.Unmarshal([]byte(args), &connect); err == nil {
+				s.connects <- connect.JWT
+			}
+		case "PING":
+			if _, err := conn.Write([]byte("PONG\r\n")); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *fakeServer) url() string {
+	return "nats://" + s.listener.Addr().String()
+}
+
+func (s *fakeServer) expectConnect(t *testing.T, want string) {
+	t.Helper()
+
+	select {
+	case got := <-s.connects:
+		if got != want {
+			t.Errorf("connected with jwt %s, want %s", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a connection")
+	}
+}
+
+// newCreds returns decorated user credentials and their JWT.
+func newCreds(t *testing.T) ([]byte, string) {
+	t.Helper()
+
+	accountKp, err := nkeys.CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	userKp, err := nkeys.CreateUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	userPub, err := userKp.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	userSeed, err := userKp.Seed()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userJWT, err := jwt.NewUserClaims(userPub).Encode(accountKp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds, err := jwt.FormatUserConfig(userJWT, userSeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return creds, userJWT
+}
+
+func newTestManager(t *testing.T, server *fakeServer, objs ...client.Object) (*Manager, client.Client, *k8sv1alpha1.Cluster) {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := k8sv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	cluster := &k8sv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "wasmcloud"}}
+	cluster.Spec.Nats.External = &k8sv1alpha1.NatsExternalSpec{URLs: []string{server.url()}}
+
+	apiClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(append(objs, cluster)...).Build()
+	return NewManager(apiClient), apiClient, cluster
+}
+
+func clientSecret(cluster *k8sv1alpha1.Cluster, creds []byte) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: cluster.GetNamespace(), Name: cluster.NatsClientSecret()},
+		Data:       map[string][]byte{"user.jwt": creds},
+	}
+}
+
+func TestManagerCredentialRotation(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeServer(t)
+	m, apiClient, cluster := newTestManager(t, server)
+	key := types.NamespacedName{Namespace: cluster.GetNamespace(), Name: cluster.GetName()}
+
+	creds, userJWT := newCreds(t)
+	secret := clientSecret(cluster, creds)
+	if err := apiClient.Create(ctx, secret); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := m.Conn(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Remove(key) })
+	server.expectConnect(t, userJWT)
+
+	same, err := m.Conn(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if same != conn {
+		t.Error("unchanged credentials opened a new connection")
+	}
+
+	renewed, renewedJWT := newCreds(t)
+	secret.Data["user.jwt"] = renewed
+	if err := apiClient.Update(ctx, secret); err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := m.Conn(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated != conn {
+		t.Error("rotated credentials should reuse the connection")
+	}
+	server.expectConnect(t, renewedJWT)
+}
+
+type fakeNatsServer struct {
+	drained atomic.Bool
+}
+
+func (s *fakeNatsServer) Serve() error { return nil }
+
+func (s *fakeNatsServer) Drain() error {
+	s.drained.Store(true)
+	return nil
+}
+
+func TestManagerRemove(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := newFakeServer(t)
+	m, apiClient, cluster := newTestManager(t, server)
+	key := types.NamespacedName{Namespace: cluster.GetNamespace(), Name: cluster.GetName()}
+
+	servers := make(chan *fakeNatsServer, 4)
+	served := make(chan error, 1)
+	go func() {
+		served <- m.Serve(ctx, key, func(bus wasmbus.Bus) Server {
+			s := &fakeNatsServer{}
+			servers <- s
+			return s
+		})
+	}()
+
+	nextServer := func() *fakeNatsServer {
+		t.Helper()
+		select {
+		case s := <-servers:
+			return s
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a server")
+			return nil
+		}
+	}
+
+	// the client secret doesn't exist yet, serving is retried until it does
+	creds, userJWT := newCreds(t)
+	if err := apiClient.Create(ctx, clientSecret(cluster, creds)); err != nil {
+		t.Fatal(err)
+	}
+	first := nextServer()
+	server.expectConnect(t, userJWT)
+
+	conn, err := m.Conn(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.Remove(key)
+	if !conn.IsClosed() {
+		t.Error("removed connection should be closed")
+	}
+
+	second := nextServer()
+	server.expectConnect(t, userJWT)
+	if !first.drained.Load() {
+		t.Error("server of the removed connection should be drained")
+	}
+
+	reconnected, err := m.Conn(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reconnected == conn {
+		t.Error("removed connection was handed out again")
+	}
+
+	cancel()
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve didn't stop")
+	}
+	if !second.drained.Load() {
+		t.Error("server should be drained on shutdown")
+	}
+	m.Remove(key)
+}
+
+// gatedDialer holds back the first dial until the gate opens.
+type gatedDialer struct {
+	addr    string
+	dialing chan struct{}
+	gate    chan struct{}
+	dials   atomic.Int32
+}
+
+func (d *gatedDialer) Dial(network string, _ string) (net.Conn, error) {
+	if d.dials.Add(1) == 1 {
+		close(d.dialing)
+		<-d.gate
+	}
+	return net.Dial(network, d.addr)
+}
+
+func TestManagerReplaceClosed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := newFakeServer(t)
+	creds, _ := newCreds(t)
+	_, apiClient, cluster := newTestManager(t, server)
+	key := types.NamespacedName{Namespace: cluster.GetNamespace(), Name: cluster.GetName()}
+	if err := apiClient.Create(ctx, clientSecret(cluster, creds)); err != nil {
+		t.Fatal(err)
+	}
+
+	dialer := &gatedDialer{addr: server.listener.Addr().String(), dialing: make(chan struct{}), gate: make(chan struct{})}
+	m := NewManager(apiClient, nats.SetCustomDialer(dialer))
+	t.Cleanup(func() { m.Remove(key) })
+
+	// a slow connect, overtaken by the one of the server below
+	slow := make(chan *nats.Conn, 1)
+	go func() {
+		conn, err := m.Conn(ctx, key)
+		if err != nil {
+			t.Error(err)
+		}
+		slow <- conn
+	}()
+	<-dialer.dialing
+
+	servers := make(chan *fakeNatsServer, 4)
+	go func() {
+		_ = m.Serve(ctx, key, func(bus wasmbus.Bus) Server {
+			s := &fakeNatsServer{}
+			servers <- s
+			return s
+		})
+	}()
+
+	nextServer := func() *fakeNatsServer {
+		t.Helper()
+		select {
+		case s := <-servers:
+			return s
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a server")
+			return nil
+		}
+	}
+
+	first := nextServer()
+	served, err := m.Conn(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// closed behind the back of the manager, before the slow connect completes
+	served.Close()
+	close(dialer.gate)
+
+	select {
+	case conn := <-slow:
+		if conn == served || conn.IsClosed() {
+			t.Error("the closed connection should be replaced")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the slow connect")
+	}
+
+	nextServer()
+	if !first.drained.Load() {
+		t.Error("server of the closed connection should be drained")
+	}
+}
+
+func TestManagerRefreshDeletedCluster(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeServer(t)
+	creds, _ := newCreds(t)
+	m, apiClient, cluster := newTestManager(t, server)
+	key := types.NamespacedName{Namespace: cluster.GetNamespace(), Name: cluster.GetName()}
+	if err := apiClient.Create(ctx, clientSecret(cluster, creds)); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := m.Conn(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Remove(key) })
+
+	m.refresh(ctx)
+	if conn.IsClosed() {
+		t.Fatal("connections of existing clusters should stay open")
+	}
+
+	if err := apiClient.Delete(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+	m.refresh(ctx)
+
+	if !conn.IsClosed() {
+		t.Error("connections of deleted clusters should be closed")
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if len(m.conns) != 0 {
+		t.Errorf("connections of deleted clusters should be forgotten, got %d", len(m.conns))
+	}
+}
@@ -1,8 +1,13 @@
 package pki
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha1"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
@@ -11,47 +16,101 @@ import (
 	"time"
 )
 
-const KeySize = 4096
+type KeyAlgorithm string
 
-func NewKeyPair() (*KeyPair, error) {
-	key, err := rsa.GenerateKey(rand.Reader, KeySize)
+const (
+	// ECDSA keys use the P-256 curve.
+	ECDSA   KeyAlgorithm = "ECDSA"
+	Ed25519 KeyAlgorithm = "Ed25519"
+	RSA     KeyAlgorithm = "RSA"
+)
+
+const (
+	RSAKeySize = 3072
+
+	DefaultCALifetime = 10 * 365 * 24 * time.Hour
+	DefaultLifetime   = 90 * 24 * time.Hour
+
+	// backdate certificates so they are valid on nodes with a slightly late clock
+	clockSkew = 5 * time.Minute
+)
+
+// serials are 128 bit random numbers, as recommended by the CA/Browser forum baseline requirements
+var serialLimit = new(big.Int).Lsh(big.NewInt(1), 128)
+
+// Options shape new certificate authorities and certificates.
+type Options struct {
+	// KeyAlgorithm defaults to ECDSA.
+	KeyAlgorithm KeyAlgorithm
+	// Lifetime defaults to DefaultCALifetime for authorities and DefaultLifetime for certificates.
+	Lifetime time.Duration
+	// DNSNames are the subject alternative names of a certificate.
+	DNSNames []string
+}
+
+func NewKeyPair(algorithm KeyAlgorithm) (*KeyPair, error) {
+	var key crypto.Signer
+	var err error
+
+	switch algorithm {
+	case "", ECDSA:
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case Ed25519:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	case RSA:
+		key, err = rsa.GenerateKey(rand.Reader, RSAKeySize)
+	default:
+		return nil, fmt.Errorf("unsupported key algorithm %q", algorithm)
+	}
 	if err != nil {
 		return nil, err
 	}
-	pub := &key.PublicKey
 
 	return &KeyPair{
 		PrivateKey: key,
-		PublicKey:  pub,
+		PublicKey:  key.Public(),
 	}, nil
 }
 
+// Algorithm reports the KeyAlgorithm of a public key, or "" when it isn't one we generate.
+func Algorithm(pub crypto.PublicKey) KeyAlgorithm {
+	switch pub.(type) {
+	case *ecdsa.PublicKey:
+		return ECDSA
+	case ed25519.PublicKey:
+		return Ed25519
+	case *rsa.PublicKey:
+		return RSA
+	}
+	return ""
+}
+
 func LoadCertificateAuthority(rawCert []byte, rawKey []byte) (*CertificateAuthority, error) {
 	bearer, err := loadBearer(rawCert, rawKey)
 	if err != nil {
 		return nil, err
 	}
 
+	if !bearer.Certificate.IsCA {
+		return nil, fmt.Errorf("certificate %s is not a CA", bearer.Certificate.Subject.CommonName)
+	}
+
 	return &CertificateAuthority{
 		CertificateBearer: *bearer,
 	}, nil
 }
 
 func loadBearer(rawCert []byte, rawKey []byte) (*CertificateBearer, error) {
-	certBlock, _ := pem.Decode(rawCert)
-	if certBlock == nil {
-		return nil, fmt.Errorf("Failed to decode CA certificate")
-	}
-	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	cert, err := ParseCertificate(rawCert)
 	if err != nil {
 		return nil, err
 	}
 
 	keyBlock, _ := pem.Decode(rawKey)
 	if keyBlock == nil {
-		return nil, fmt.Errorf("Failed to decode CA private key")
+		return nil, fmt.Errorf("failed to decode private key")
 	}
-	key, err := x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
+	key, err := parsePrivateKey(keyBlock.Bytes)
 	if err != nil {
 		return nil, err
 	}
@@ -60,30 +119,101 @@ func loadBearer(rawCert []byte, rawKey []byte) (*CertificateBearer, error) {
 		Certificate: cert,
 		KeyPair: &KeyPair{
 			PrivateKey: key,
-			PublicKey:  &key.PublicKey,
+			PublicKey:  key.Public(),
 		},
 	}, nil
 }
 
-func NewCertificateAuthority(name string) (*CertificateAuthority, error) {
-	kp, err := NewKeyPair()
+// parsePrivateKey reads PKCS#8 keys, falling back to the PKCS#1 and SEC 1 keys written by older releases.
+func parsePrivateKey(der []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("failed to parse private key")
+}
+
+// ParseCertificate decodes the first PEM certificate in raw.
+func ParseCertificate(raw []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(raw)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("failed to decode certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// RenewalDue reports whether cert is in the last third of its lifetime at now.
+func RenewalDue(cert *x509.Certificate, now time.Time) bool {
+	lifetime := cert.NotAfter.Sub(cert.NotBefore)
+	return !now.Before(cert.NotAfter.Add(-lifetime / 3))
+}
+
+func newTemplate(name string, lifetime time.Duration, pub crypto.PublicKey) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, serialLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	keyID, err := subjectKeyID(pub)
 	if err != nil {
 		return nil, err
 	}
 
-	certificate := &x509.Certificate{
-		SerialNumber: big.NewInt(1653),
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
 		Subject: pkix.Name{
 			CommonName:   name,
 			Organization: []string{"wasmCloud"},
 		},
-		NotBefore:             time.Now(),
-		NotAfter:              time.Now().AddDate(30, 0, 0),
-		IsCA:                  true,
-		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
-		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
-		BasicConstraintsValid: true,
+		NotBefore:    now.Add(-clockSkew),
+		NotAfter:     now.Add(lifetime),
+		SubjectKeyId: keyID,
+	}, nil
+}
+
+// subjectKeyID follows RFC 5280 4.2.1.2, method 1.
+func subjectKeyID(pub crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
 	}
+	sum := sha1.Sum(der)
+	return sum[:], nil
+}
+
+func NewCertificateAuthority(name string, opts Options) (*CertificateAuthority, error) {
+	kp, err := NewKeyPair(opts.KeyAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	lifetime := opts.Lifetime
+	if lifetime == 0 {
+		lifetime = DefaultCALifetime
+	}
+
+	certificate, err := newTemplate(name, lifetime, kp.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	certificate.IsCA = true
+	certificate.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	certificate.BasicConstraintsValid = true
+	// only issues leaf certificates
+	certificate.MaxPathLenZero = true
 
 	return &CertificateAuthority{
 		CertificateBearer: CertificateBearer{
@@ -93,11 +223,23 @@ func NewCertificateAuthority(name string) (*CertificateAuthority, error) {
 	}, nil
 }
 
+// SelfSign signs the CA certificate with its own key and replaces the template with the result.
 func (ca *CertificateAuthority) SelfSign() ([]byte, error) {
-	return ca.Sign(ca.Certificate, ca.KeyPair.PublicKey)
+	certPEM, err := ca.Sign(ca.Certificate, ca.KeyPair.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := ParseCertificate(certPEM)
+	if err != nil {
+		return nil, err
+	}
+	ca.Certificate = cert
+
+	return certPEM, nil
 }
 
-func (ca *CertificateAuthority) Sign(template *x509.Certificate, pubKey *rsa.PublicKey) ([]byte, error) {
+func (ca *CertificateAuthority) Sign(template *x509.Certificate, pubKey crypto.PublicKey) ([]byte, error) {
 	newCert, err := x509.CreateCertificate(rand.Reader, template, ca.Certificate, pubKey, ca.KeyPair.PrivateKey)
 	if err != nil {
 		return nil, err
@@ -114,8 +256,13 @@ func (cb *CertificateBearer) CertificatePEM() []byte {
 	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cb.Certificate.Raw})
 }
 
-func (cb *CertificateBearer) PrivateKeyPEM() []byte {
-	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(cb.KeyPair.PrivateKey)})
+// PrivateKeyPEM encodes the private key as PKCS#8.
+func (cb *CertificateBearer) PrivateKeyPEM() ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(cb.KeyPair.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
 }
 
 type CertificateAuthority struct {
@@ -127,26 +274,32 @@ type Client struct {
 }
 
 type KeyPair struct {
-	PrivateKey *rsa.PrivateKey
-	PublicKey  *rsa.PublicKey
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
 }
 
-func NewClient(name string) (*Client, error) {
-	kp, err := NewKeyPair()
+// NewClient creates a key pair and a certificate template for name,
+// valid for both client and server authentication on opts.DNSNames.
+func NewClient(name string, opts Options) (*Client, error) {
+	kp, err := NewKeyPair(opts.KeyAlgorithm)
 	if err != nil {
 		return nil, err
 	}
 
-	certSpec := &x509.Certificate{
-		SerialNumber: big.NewInt(1658),
-		Subject: pkix.Name{
-			CommonName: name,
-		},
-		NotBefore:    time.Now(),
-		NotAfter:     time.Now().AddDate(10, 0, 0),
-		SubjectKeyId: []byte{1, 2, 3, 4, 6},
-		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
-		KeyUsage:     x509.KeyUsageDigitalSignature,
+	lifetime := opts.Lifetime
+	if lifetime == 0 {
+		lifetime = DefaultLifetime
+	}
+
+	certSpec, err := newTemplate(name, lifetime, kp.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	certSpec.DNSNames = opts.DNSNames
+	certSpec.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth}
+	certSpec.KeyUsage = x509.KeyUsageDigitalSignature
+	if _, ok := kp.PublicKey.(*rsa.PublicKey); ok {
+		certSpec.KeyUsage |= x509.KeyUsageKeyEncipherment
 	}
 
 	return &Client{
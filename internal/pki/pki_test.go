@@ -0,0 +1,236 @@
+package pki
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIssue(t *testing.T) {
+	for _, algorithm := range []KeyAlgorithm{ECDSA, Ed25519, RSA} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			ca, err := NewCertificateAuthority("test", Options{KeyAlgorithm: algorithm})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ca.SelfSign(); err != nil {
+				t.Fatal(err)
+			}
+
+			client, err := NewClient("nats", Options{KeyAlgorithm: algorithm, DNSNames: []string{"nats-test", "*.natsd-test"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			certPEM, err := ca.Sign(client.Certificate, client.KeyPair.PublicKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cert, err := ParseCertificate(certPEM)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(algorithm, Algorithm(cert.PublicKey)); diff != "" {
+				t.Errorf("Algorithm(...): -want, +got:\n%s", diff)
+			}
+
+			roots := x509.NewCertPool()
+			roots.AddCert(ca.Certificate)
+			for _, name := range []string{"nats-test", "nats-test-0.natsd-test"} {
+				if _, err := cert.Verify(x509.VerifyOptions{Roots: roots, DNSName: name}); err != nil {
+					t.Errorf("Verify(%s): %v", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestUnsupportedAlgorithm(t *testing.T) {
+	if _, err := NewKeyPair("DSA"); err == nil {
+		t.Error("NewKeyPair(DSA): expected an error")
+	}
+}
+
+func TestRandomSerials(t *testing.T) {
+	a, err := NewClient("a", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewClient("b", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a.Certificate.SerialNumber.Cmp(b.Certificate.SerialNumber) == 0 {
+		t.Errorf("serial numbers should differ, both are %s", a.Certificate.SerialNumber)
+	}
+	if cmp.Equal(a.Certificate.SubjectKeyId, b.Certificate.SubjectKeyId) {
+		t.Errorf("subject key ids should differ")
+	}
+}
+
+func TestLifetime(t *testing.T) {
+	cases := map[string]struct {
+		create func() (*x509.Certificate, error)
+		want   time.Duration
+	}{
+		"DefaultCA": {
+			create: func() (*x509.Certificate, error) {
+				ca, err := NewCertificateAuthority("test", Options{})
+				if err != nil {
+					return nil, err
+				}
+				return ca.Certificate, nil
+			},
+			want: DefaultCALifetime,
+		},
+		"DefaultClient": {
+			create: func() (*x509.Certificate, error) {
+				client, err := NewClient("test", Options{})
+				if err != nil {
+					return nil, err
+				}
+				return client.Certificate, nil
+			},
+			want: DefaultLifetime,
+		},
+		"Custom": {
+			create: func() (*x509.Certificate, error) {
+				client, err := NewClient("test", Options{Lifetime: time.Hour})
+				if err != nil {
+					return nil, err
+				}
+				return client.Certificate, nil
+			},
+			want: time.Hour,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cert, err := tc.create()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tc.want+clockSkew, cert.NotAfter.Sub(cert.NotBefore)); diff != "" {
+				t.Errorf("lifetime: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadCertificateAuthority(t *testing.T) {
+	ca, err := NewCertificateAuthority("test", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, err := ca.SelfSign()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM, err := ca.PrivateKeyPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if block, _ := pem.Decode(keyPEM); block.Type != "PRIVATE KEY" {
+		t.Errorf("private key PEM type: %s", block.Type)
+	}
+
+	loaded, err := LoadCertificateAuthority(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !loaded.Certificate.Equal(ca.Certificate) {
+		t.Errorf("loaded certificate doesn't match")
+	}
+	if diff := cmp.Diff(certPEM, loaded.CertificatePEM()); diff != "" {
+		t.Errorf("CertificatePEM(): -want, +got:\n%s", diff)
+	}
+}
+
+// PKCS#1 keys were written by earlier releases under a "PRIVATE KEY" header.
+func TestLoadLegacyKey(t *testing.T) {
+	ca, err := NewCertificateAuthority("test", Options{KeyAlgorithm: RSA})
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, err := ca.SelfSign()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(ca.KeyPair.PrivateKey.(*rsa.PrivateKey)),
+	})
+
+	if _, err := LoadCertificateAuthority(certPEM, keyPEM); err != nil {
+		t.Errorf("LoadCertificateAuthority(...): %v", err)
+	}
+}
+
+func TestLoadCertificateAuthorityNotCA(t *testing.T) {
+	ca, err := NewCertificateAuthority("test", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ca.SelfSign(); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewClient("client", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, err := ca.Sign(client.Certificate, client.KeyPair.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM, err := client.PrivateKeyPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadCertificateAuthority(certPEM, keyPEM); err == nil {
+		t.Error("LoadCertificateAuthority(...): expected an error for a leaf certificate")
+	}
+	if _, err := LoadClient(certPEM, keyPEM); err != nil {
+		t.Errorf("LoadClient(...): %v", err)
+	}
+}
+
+func TestRenewalDue(t *testing.T) {
+	notBefore := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cert := &x509.Certificate{
+		NotBefore: notBefore,
+		NotAfter:  notBefore.Add(90 * time.Hour),
+	}
+
+	cases := map[string]struct {
+		now  time.Time
+		want bool
+	}{
+		"Fresh":      {now: notBefore.Add(time.Hour), want: false},
+		"BeforeDue":  {now: notBefore.Add(59 * time.Hour), want: false},
+		"Due":        {now: notBefore.Add(60 * time.Hour), want: true},
+		"Expired":    {now: notBefore.Add(91 * time.Hour), want: true},
+		"NotStarted": {now: notBefore.Add(-time.Hour), want: false},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, RenewalDue(cert, tc.now)); diff != "" {
+				t.Errorf("RenewalDue(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
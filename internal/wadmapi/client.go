@@ -0,0 +1,35 @@
+package wadmapi
+
+import (
+	"context"
+	"strings"
+
+	"go.wasmcloud.dev/x/wasmbus"
+	"go.wasmcloud.dev/x/wasmbus/wadm"
+)
+
+// DefaultPrefix is the subject prefix wadm serves its API on unless told otherwise.
+const DefaultPrefix = "wadm.api"
+
+// NewClient returns a wadm client for the given lattice.
+// Requests are rewritten to apiPrefix when wadm was started with a custom `--api-prefix`.
+func NewClient(bus wasmbus.Bus, lattice string, apiPrefix string) *wadm.Client {
+	if apiPrefix != "" && apiPrefix != DefaultPrefix {
+		bus = &prefixBus{Bus: bus, prefix: apiPrefix}
+	}
+	return wadm.NewClient(bus, lattice)
+}
+
+type prefixBus struct {
+	wasmbus.Bus
+	prefix string
+}
+
+func (b *prefixBus) Request(ctx context.Context, msg *wasmbus.Message) (*wasmbus.Message, error) {
+	if rest, ok := strings.CutPrefix(msg.Subject, DefaultPrefix+"."); ok {
+		rewritten := *msg
+		rewritten.Subject = b.prefix + "." + rest
+		msg = &rewritten
+	}
+	return b.Bus.Request(ctx, msg)
+}